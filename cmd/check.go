@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/goget"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// check fetches import paths from a running server as the go command does and reports
+// every violation of its rules. Without import paths, it checks the modules, packages
+// and aliases of the module registry; when a registry is configured, it also reports
+// go-import tags that differ from what the registry resolves the path to.
+func check(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("check", stderr)
+	loader := newServiceLoader(fs)
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of each request")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	base, err := url.Parse(fs.Arg(0))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		fmt.Fprintf(stderr, "vanity-go check: invalid base URL %q\n", fs.Arg(0))
+		return exitUsage
+	}
+
+	cfg, ok := loadConfig("check", loader, stderr)
+	if !ok {
+		return exitError
+	}
+	var svc *gosvc.Service
+	if cfg.Registry != "" {
+		if svc, err = loadService(ctx, cfg); err != nil {
+			fmt.Fprintf(stderr, "vanity-go check: %v\n", err)
+			return exitError
+		}
+	}
+	importPaths := fs.Args()[1:]
+	if len(importPaths) == 0 {
+		if svc == nil {
+			fmt.Fprintln(stderr, "vanity-go check: no import paths given and no module registry file")
+			return exitUsage
+		}
+		importPaths = registeredImportPaths(svc)
+	}
+
+	checker := &goget.Checker{BaseURL: base, Domain: cfg.Domain, Client: &http.Client{Timeout: *timeout}}
+	results := make([]goget.Result, 0, len(importPaths))
+	failed := 0
+	for _, importPath := range importPaths {
+		res := checker.Check(ctx, importPath)
+		if svc != nil && res.Import != nil {
+			if want := expectedImport(ctx, svc, cfg.Domain, importPath); want != "" && want != res.Import.String() {
+				res.Violations = append(res.Violations, fmt.Sprintf("go-import %q differs from the registry: %q", res.Import.String(), want))
+			}
+		}
+		if len(res.Violations) > 0 {
+			failed++
+		}
+		results = append(results, res)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+	} else {
+		err = printResults(stdout, results, failed)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go check: %v\n", err)
+		return exitError
+	}
+	if failed > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+// registeredImportPaths returns the import paths of the modules, the packages they list
+// and the aliases of svc.
+func registeredImportPaths(svc *gosvc.Service) []string {
+	var paths []string
+	for _, mc := range svc.Config().Modules {
+		root := svc.Domain() + "/" + mc.Path
+		paths = append(paths, root)
+		for _, pkg := range mc.Packages {
+			paths = append(paths, root+"/"+pkg)
+		}
+	}
+	for _, a := range svc.Aliases() {
+		paths = append(paths, a.ImportPath)
+	}
+	return paths
+}
+
+// expectedImport returns the go-import content svc serves for importPath,
+// or an empty string when it is not under the vanity domain.
+func expectedImport(ctx context.Context, svc *gosvc.Service, domain, importPath string) string {
+	path, err := requestPath(domain, importPath)
+	if err != nil {
+		return ""
+	}
+	m := svc.Resolve(ctx, path)
+	return m.ImportPath + " " + m.VCS + " " + m.Repository
+}
+
+// printResults writes one line per import path, followed by its violations, and a summary.
+func printResults(w io.Writer, results []goget.Result, failed int) error {
+	for _, res := range results {
+		if len(res.Violations) == 0 {
+			fmt.Fprintf(w, "ok    %s: %s\n", res.ImportPath, res.Import)
+			continue
+		}
+		fmt.Fprintf(w, "FAIL  %s\n", res.ImportPath)
+		for _, v := range res.Violations {
+			fmt.Fprintf(w, "      %s\n", v)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d import paths checked, %d failed\n", len(results), failed)
+	return err
+}
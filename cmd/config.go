@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"go.gllm.dev/vanity-go/internal/config"
+	"go.gllm.dev/vanity-go/internal/errjoin"
+)
+
+// configCmd inspects the configuration. Its only subcommand, dump, prints the effective
+// value of every setting with the layer it comes from, followed by any configuration error.
+// It accepts the same flags as serve, so the effect of a command line can be checked.
+func configCmd(_ context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("config", stderr)
+	loader := config.NewLoader(fs)
+	if len(args) == 0 || args[0] != "dump" {
+		fs.Usage()
+		return exitUsage
+	}
+	if code, ok := parseFlags(fs, args[1:]); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	cfg, err := loader.Load(os.LookupEnv)
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SETTING\tVALUE\tSOURCE")
+	for _, v := range cfg.Values() {
+		source := v.Source.String()
+		if v.Origin != "" {
+			source += " " + v.Origin
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Key, v.Value, source)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "vanity-go config: %v\n", err)
+		return exitError
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, "vanity-go config: invalid configuration:")
+		for _, err := range errjoin.Split(err) {
+			fmt.Fprintf(stderr, "  %v\n", err)
+		}
+		return exitError
+	}
+	return exitOK
+}
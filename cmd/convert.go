@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// convert translates a govanityurls vanity.yaml into a module registry file.
+// Settings of govanityurls that belong to the server configuration are listed
+// as comments at the top of the output.
+func convert(_ context.Context, args []string, stdout, stderr io.Writer) int {
+	var output string
+	fs := newFlagSet("convert", stderr)
+	fs.StringVar(&output, "o", "", "write the registry to this file instead of standard output")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	g, err := gosvc.ReadGovanityurlsConfig(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go convert: %v\n", err)
+		return exitError
+	}
+	cfg, err := g.Convert()
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go convert: %v\n", err)
+		return exitError
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Module registry converted from the govanityurls configuration %s.\n", fs.Arg(0))
+	if g.Host != "" {
+		fmt.Fprintf(&buf, "# host: set VANITY_DOMAIN=%s\n", g.Host)
+	}
+	if g.CacheMaxAge != nil {
+		fmt.Fprintf(&buf, "# cache_max_age: set CACHE_MAX_AGE=%s\n", time.Duration(*g.CacheMaxAge)*time.Second)
+	}
+	if err := gosvc.WriteConfig(&buf, cfg); err != nil {
+		fmt.Fprintf(stderr, "vanity-go convert: %v\n", err)
+		return exitError
+	}
+
+	if output == "" {
+		_, err = stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(output, buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go convert: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
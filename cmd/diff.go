@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go.gllm.dev/vanity-go/internal/errjoin"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// diff reports how replacing the module registry file old with new changes the answers
+// of the server: every path whose go-import, go-source, redirect or access policy changes,
+// and every newly registered path capturing the paths below it. The exit code is
+// exitDangerous when a change may break or hijack builds, such as a switch of repository host.
+func diff(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("diff", stderr)
+	loader := newServiceLoader(fs)
+	asJSON := fs.Bool("json", false, "print the changes as JSON")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	cfg, ok := loadConfig("diff", loader, stderr)
+	if !ok {
+		return exitError
+	}
+	svc := gosvc.New(cfg.Domain, cfg.Repository)
+	var services [2]*gosvc.Service
+	for i, path := range fs.Args() {
+		registry, err := gosvc.ReadConfig(path)
+		if err == nil {
+			services[i], err = svc.Preview(ctx, registry)
+		}
+		if err != nil {
+			for _, err := range errjoin.Split(err) {
+				fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			}
+			return exitError
+		}
+	}
+
+	impacts := gosvc.Impacts(ctx, services[0], services[1])
+	var err error
+	if *asJSON {
+		if impacts == nil {
+			impacts = []gosvc.Impact{}
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(impacts)
+	} else {
+		err = printImpacts(stdout, impacts)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go diff: %v\n", err)
+		return exitError
+	}
+
+	for _, impact := range impacts {
+		if len(impact.Dangers) > 0 {
+			return exitDangerous
+		}
+	}
+	return exitOK
+}
+
+// printImpacts writes impacts to w, one block per path followed by a summary line.
+func printImpacts(w io.Writer, impacts []gosvc.Impact) error {
+	dangerous := 0
+	for _, impact := range impacts {
+		fmt.Fprintf(w, "%s\n", impact.ImportPath)
+		if impact.Captures {
+			from := "the repository base URL"
+			if impact.Before.Rule != "" {
+				from = "the rule " + impact.Before.Rule
+			}
+			fmt.Fprintf(w, "  captures:  %s and the paths below it, served by %s before\n", impact.ImportPath, from)
+		}
+		for _, field := range []struct{ name, before, after string }{
+			{"go-import", impact.Before.GoImport, impact.After.GoImport},
+			{"go-source", impact.Before.GoSource, impact.After.GoSource},
+			{"redirect", orNone(impact.Before.Redirect), orNone(impact.After.Redirect)},
+			{"access", impact.Before.Access, impact.After.Access},
+		} {
+			if field.before != field.after {
+				fmt.Fprintf(w, "  %-10s %s\n  %-10s -> %s\n", field.name+":", field.before, "", field.after)
+			}
+		}
+		for _, reason := range impact.Dangers {
+			fmt.Fprintf(w, "  DANGER:    %s\n", reason)
+		}
+		if len(impact.Dangers) > 0 {
+			dangerous++
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d paths changed, %d dangerous\n", len(impacts), dangerous)
+	return err
+}
+
+// orNone returns s, or "(none)" when it is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
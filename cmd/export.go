@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.gllm.dev/vanity-go/internal/adapters/export"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// exportSite writes the pages of the module registry into a directory for static hosting.
+func exportSite(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var packages bool
+	var opts export.Options
+	fs := newFlagSet("export", stderr)
+	loader := newServiceLoader(fs)
+	fs.BoolVar(&packages, "packages", false, "also write pages for the packages listed in the registry")
+	fs.BoolVar(&opts.Index, "index", false, "write an index.html listing every module")
+	fs.BoolVar(&opts.Manifest, "manifest", false, "write a modules.json describing every module")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	settings, ok := loadConfig("export", loader, stderr)
+	if !ok {
+		return exitError
+	}
+	if settings.Registry == "" {
+		fmt.Fprintln(stderr, "vanity-go export: no module registry file given: use -registry or VANITY_REGISTRY")
+		return exitUsage
+	}
+
+	svc := gosvc.New(settings.Domain, settings.Repository)
+	cfg, err := gosvc.ReadConfig(settings.Registry)
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go export: %v\n", err)
+		return exitError
+	}
+	if err := svc.Load(ctx, cfg); err != nil {
+		fmt.Fprintf(stderr, "vanity-go export: invalid module registry %s:\n%v\n", settings.Registry, err)
+		return exitError
+	}
+	if packages {
+		for _, mc := range cfg.Modules {
+			for _, pkg := range mc.Packages {
+				opts.Packages = append(opts.Packages, mc.Path+"/"+pkg)
+			}
+		}
+	}
+
+	written, err := export.Write(ctx, svc, fs.Arg(0), opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go export: %v\n", err)
+		return exitError
+	}
+	for _, path := range written {
+		fmt.Fprintln(stdout, path)
+	}
+	return exitOK
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/config"
+	"go.gllm.dev/vanity-go/internal/services/adminsvc"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// historyCmd inspects the versions of the module registry kept in the history file
+// of a server. Its subcommands are list, which prints every version kept; show, which
+// prints the module registry of a version; and diff, which prints the import paths
+// two versions register differently, the second defaulting to the latest version.
+func historyCmd(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("history", stderr)
+	loader := config.NewLoader(fs, append(serviceSettings, "admin.history_file")...)
+	if len(args) == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	sub := args[0]
+	if code, ok := parseFlags(fs, args[1:]); !ok {
+		return code
+	}
+	var numbers []int
+	for _, arg := range fs.Args() {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			fmt.Fprintf(stderr, "vanity-go history: invalid version %q\n", arg)
+			return exitUsage
+		}
+		numbers = append(numbers, n)
+	}
+	switch {
+	case sub == "list" && len(numbers) == 0:
+	case sub == "show" && len(numbers) == 1:
+	case sub == "diff" && (len(numbers) == 1 || len(numbers) == 2):
+	default:
+		fs.Usage()
+		return exitUsage
+	}
+
+	cfg, ok := loadConfig("history", loader, stderr)
+	if !ok {
+		return exitError
+	}
+	if cfg.Admin.HistoryFile == "" {
+		fmt.Fprintln(stderr, "vanity-go history: no history file configured (ADMIN_HISTORY_FILE)")
+		return exitUsage
+	}
+	history, err := adminsvc.OpenHistory(cfg.Admin.HistoryFile, cfg.Admin.HistoryRetention)
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go history: %v\n", err)
+		return exitError
+	}
+
+	switch sub {
+	case "list":
+		err = listVersions(stdout, history)
+	case "show":
+		err = showVersion(stdout, history, numbers[0])
+	case "diff":
+		if len(numbers) == 1 {
+			latest, _ := history.Latest()
+			numbers = append(numbers, latest.Number)
+		}
+		err = diffVersions(ctx, stdout, gosvc.New(cfg.Domain, cfg.Repository), history, numbers[0], numbers[1])
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go history: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// listVersions prints the versions of history, oldest first.
+func listVersions(w io.Writer, history *adminsvc.History) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tTIME\tAUTHOR\tSOURCE")
+	for _, v := range history.Versions() {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", v.Number, v.Time.Format(time.RFC3339), v.Author, v.Source)
+	}
+	return tw.Flush()
+}
+
+// showVersion prints the module registry of version n of history.
+func showVersion(w io.Writer, history *adminsvc.History, n int) error {
+	v, err := history.Version(n)
+	if err != nil {
+		return err
+	}
+	return gosvc.WriteConfig(w, v.Registry)
+}
+
+// diffVersions prints the import paths versions from and to of history register differently,
+// marking with "!" those resolving to another repository or VCS.
+func diffVersions(ctx context.Context, w io.Writer, svc *gosvc.Service, history *adminsvc.History, from, to int) error {
+	before, err := history.Version(from)
+	if err != nil {
+		return err
+	}
+	after, err := history.Version(to)
+	if err != nil {
+		return err
+	}
+	changes, err := adminsvc.DiffVersions(ctx, svc, before, after)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tIMPORT PATH\tBEFORE\tAFTER")
+	for _, c := range changes {
+		mark := ""
+		if c.Retargeted {
+			mark = "!"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", mark, c.ImportPath, describe(c.Before), describe(c.After))
+	}
+	return tw.Flush()
+}
+
+// describe summarises where a registered module or alias resolves to.
+func describe(m *gosvc.Module) string {
+	switch {
+	case m == nil:
+		return "(unregistered)"
+	case m.MovedTo != "":
+		return fmt.Sprintf("alias of %s (%s %s)", m.MovedTo, m.VCS, m.Repository)
+	}
+	return fmt.Sprintf("%s %s, %s", m.VCS, m.Repository, m.Status.OrActive())
+}
@@ -2,52 +2,129 @@ package main
 
 import (
 	"context"
-	"errors"
-	"log/slog"
-	"net/http"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-	"os/signal"
-	"sync"
-	"syscall"
+	"strings"
+)
+
+// command is a vanity-go subcommand.
+type command struct {
+	// name is the word selecting the command on the command line.
+	name string
+	// usage is the argument synopsis shown in help output.
+	usage string
+	// summary is a one-line description of the command.
+	summary string
+	// run executes the command with the arguments following its name and returns the exit code.
+	run func(ctx context.Context, args []string, stdout, stderr io.Writer) int
+}
 
-	"go.gllm.dev/vanity-go/di"
+// Exit codes returned by the commands.
+const (
+	// exitOK reports success.
+	exitOK = 0
+	// exitError reports a failure, such as an invalid module registry.
+	exitError = 1
+	// exitUsage reports invalid command line arguments.
+	exitUsage = 2
+	// exitDangerous reports a registry change that may break or hijack builds.
+	exitDangerous = 3
 )
 
+// commands returns the available subcommands. Running the binary without one starts the server.
+func commands() []command {
+	return []command{
+		{name: "serve", usage: "[flags]", summary: "start the HTTP server (default)", run: serve},
+		{name: "validate", usage: "[flags] [config]", summary: "check a module registry file and report every error", run: validate},
+		{name: "render", usage: "[flags] <import-path>", summary: "print the HTML served for an import path", run: render},
+		{name: "export", usage: "[flags] <dir>", summary: "write the registered module pages as a static site", run: exportSite},
+		{name: "convert", usage: "[-o file] <vanity.yaml>", summary: "convert a govanityurls configuration into a module registry", run: convert},
+		{name: "config", usage: "dump [flags]", summary: "show the effective configuration and where each value comes from", run: configCmd},
+		{name: "check", usage: "[flags] <base-url> [import-path...]", summary: "check the answers of a running server against the rules of the go command", run: check},
+		{name: "diff", usage: "[flags] <old> <new>", summary: "report how a module registry change affects every path", run: diff},
+		{name: "resolve", usage: "[flags] <import-path>", summary: "show how an import path resolves to a module", run: resolve},
+		{name: "history", usage: "list [flags] | show [flags] <version> | diff [flags] <from> [<to>]", summary: "show the versions of the module registry kept by the server", run: historyCmd},
+	}
+}
+
 func main() {
-	ctx := context.Background()
-	slog.Info("Starting vanity-go server")
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	server, err := di.ProvideRestServer()
-	if err != nil {
-		slog.Error("Failed to initialize dependencies", slog.String("error", err.Error()))
-		os.Exit(1)
+// run dispatches args to the selected subcommand and returns the process exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		return serve(ctx, nil, stdout, stderr)
 	}
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	name := args[0]
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd.run(ctx, args[1:], stdout, stderr)
+		}
+	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
+	switch {
+	case name == "help" || name == "-h" || name == "-help" || name == "--help":
+		printUsage(stdout)
+		return exitOK
+	case strings.HasPrefix(name, "-"):
+		// Flags without a command configure the default serve command.
+		return serve(ctx, args, stdout, stderr)
+	}
 
-	go func() {
-		<-sigCh
-		slog.Info("Received shutdown signal")
+	fmt.Fprintf(stderr, "vanity-go: unknown command %q\n\n", name)
+	printUsage(stderr)
+	return exitUsage
+}
 
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30)
-		defer cancel()
+// printUsage writes the list of subcommands to w.
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: vanity-go <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "vanity-go <command> -h" for the flags of a command.`)
+}
 
-		if err := server.Stop(shutdownCtx); err != nil {
-			slog.Error("Failed to shutdown server gracefully", slog.String("error", err.Error()))
+// newFlagSet creates the flag set of the named command, reporting errors to stderr.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("vanity-go "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			fs.Usage = func() {
+				fmt.Fprintf(stderr, "vanity-go %s: %s\n\nUsage: vanity-go %s %s\n", cmd.name, cmd.summary, cmd.name, cmd.usage)
+				if hasFlags(fs) {
+					fmt.Fprintln(stderr)
+					fmt.Fprintln(stderr, "Flags:")
+					fs.PrintDefaults()
+				}
+			}
 		}
+	}
+	return fs
+}
 
-		wg.Done()
-	}()
+// hasFlags reports whether fs defines any flag.
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
 
-	if err := server.Start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		slog.Error("Failed to start server", slog.String("error", err.Error()))
-		os.Exit(1)
+// parseFlags parses args into fs and returns the exit code to use when parsing stops the command.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK, false
+		}
+		return exitUsage, false
 	}
-
-	wg.Wait()
-	slog.Info("Server stopped")
+	return exitOK, true
 }
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/gohdl"
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"go.gllm.dev/vanity-go/internal/upstream/upstreamtest"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "modules.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("VANITY_DOMAIN", "go.gllm.dev")
+	t.Setenv("VANITY_REPOSITORY", "https://github.com/gllm-dev")
+	t.Setenv("VANITY_REGISTRY", "")
+	t.Setenv("VANITY_CONFIG", "")
+
+	valid := writeConfig(t, "modules:\n  - path: tools\n    repository: https://gitlab.com/gllm-dev/tools\n")
+	out := t.TempDir()
+	govanityurls := writeConfig(t, "host: go.gllm.dev\ncache_max_age: 60\npaths:\n  /foo:\n    repo: https://github.com/gllm-dev/foo\n")
+	invalid := writeConfig(t, "modules:\n  - path: \"\"\n  - path: tools\n    vcs: cvs\n")
+	aliased := writeConfig(t, "modules:\n  - path: newname\naliases:\n  - path: oldname\n    target: newname\n")
+	moved := writeConfig(t, "modules:\n  - path: tools\n    repository: https://github.com/gllm-dev/tools\n  - path: app\n")
+	history := writeConfig(t, `version: 1
+time: 2026-03-02T10:15:00Z
+author: system
+source: startup
+registry:
+  modules:
+    - path: tools
+      repository: https://gitlab.com/gllm-dev/tools
+---
+version: 2
+time: 2026-03-02T11:00:00Z
+author: alice
+source: admin
+registry:
+  modules:
+    - path: tools
+    - path: app
+`)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name:       "validate ok",
+			args:       []string{"validate", valid},
+			wantCode:   exitOK,
+			wantStdout: []string{"1 modules OK"},
+		},
+		{
+			name:       "validate reports every error",
+			args:       []string{"validate", invalid},
+			wantCode:   exitError,
+			wantStderr: []string{"path is required", `unknown vcs "cvs"`},
+		},
+		{
+			name:       "validate without file",
+			args:       []string{"validate"},
+			wantCode:   exitUsage,
+			wantStderr: []string{"no module registry file"},
+		},
+		{
+			name:       "render registered module",
+			args:       []string{"render", "-registry", valid, "go.gllm.dev/tools/cmd"},
+			wantCode:   exitOK,
+			wantStdout: []string{`content="go.gllm.dev/tools git https://gitlab.com/gllm-dev/tools"`},
+		},
+		{
+			name:       "render outside domain",
+			args:       []string{"render", "example.com/tools"},
+			wantCode:   exitError,
+			wantStderr: []string{"not under the vanity domain"},
+		},
+		{
+			name:       "resolve",
+			args:       []string{"resolve", "-registry", valid, "https://go.gllm.dev/tools/cmd/x?go-get=1"},
+			wantCode:   exitOK,
+			wantStdout: []string{"rule:       tools\n", "module:     go.gllm.dev/tools\n", "subdir:     cmd/x\n", "status:     active\n"},
+		},
+		{
+			name:       "resolve alias",
+			args:       []string{"resolve", "-registry", aliased, "go.gllm.dev/oldname/cmd"},
+			wantCode:   exitOK,
+			wantStdout: []string{"rule:       oldname\n", "repository: https://github.com/gllm-dev/newname\n", "moved to:   go.gllm.dev/newname\n"},
+		},
+		{
+			name:       "validate aliases",
+			args:       []string{"validate", aliased},
+			wantCode:   exitOK,
+			wantStdout: []string{"1 modules, 1 aliases OK"},
+		},
+		{
+			name:       "resolve fallback",
+			args:       []string{"resolve", "go.gllm.dev/other"},
+			wantCode:   exitOK,
+			wantStdout: []string{"(none; repository base URL)", "https://github.com/gllm-dev/other"},
+		},
+		{
+			name:       "resolve missing argument",
+			args:       []string{"resolve"},
+			wantCode:   exitUsage,
+			wantStderr: []string{"Usage: vanity-go resolve"},
+		},
+		{
+			name:       "export",
+			args:       []string{"export", "-registry", valid, "-manifest", out},
+			wantCode:   exitOK,
+			wantStdout: []string{"modules.json\n", "tools/index.html\n"},
+		},
+		{
+			name:       "export without registry",
+			args:       []string{"export", out},
+			wantCode:   exitUsage,
+			wantStderr: []string{"no module registry file"},
+		},
+		{
+			name:       "config dump",
+			args:       []string{"config", "dump", "-port", "9000"},
+			wantCode:   exitOK,
+			wantStdout: []string{"domain", "go.gllm.dev", "env VANITY_DOMAIN", "9000", "flag -port"},
+		},
+		{
+			name:       "config dump reports errors",
+			args:       []string{"config", "dump", "-log-format", "xml"},
+			wantCode:   exitError,
+			wantStdout: []string{"log.format"},
+			wantStderr: []string{`invalid format "xml"`},
+		},
+		{
+			name:       "invalid configuration",
+			args:       []string{"resolve", "-domain", "", "go.gllm.dev/tools"},
+			wantCode:   exitError,
+			wantStderr: []string{"domain is required"},
+		},
+		{
+			name:     "convert",
+			args:     []string{"convert", govanityurls},
+			wantCode: exitOK,
+			wantStdout: []string{
+				"# host: set VANITY_DOMAIN=go.gllm.dev\n",
+				"# cache_max_age: set CACHE_MAX_AGE=1m0s\n",
+				"  - path: foo\n",
+				"display: https://github.com/gllm-dev/foo https://github.com/gllm-dev/foo/tree/master{/dir}",
+			},
+		},
+		{
+			name:       "validate govanityurls",
+			args:       []string{"validate", govanityurls},
+			wantCode:   exitOK,
+			wantStdout: []string{"1 modules OK"},
+		},
+		{
+			name:       "diff",
+			args:       []string{"diff", valid, moved},
+			wantCode:   exitDangerous,
+			wantStdout: []string{"go.gllm.dev/app\n  captures:", "DANGER:    repository host changes from gitlab.com to github.com\n", "2 paths changed, 1 dangerous\n"},
+		},
+		{
+			name:       "diff json",
+			args:       []string{"diff", "-json", moved, moved},
+			wantCode:   exitOK,
+			wantStdout: []string{"[]\n"},
+		},
+		{
+			name:       "diff invalid registry",
+			args:       []string{"diff", valid, invalid},
+			wantCode:   exitError,
+			wantStderr: []string{`unknown vcs "cvs"`},
+		},
+		{
+			name:       "diff missing argument",
+			args:       []string{"diff", valid},
+			wantCode:   exitUsage,
+			wantStderr: []string{"Usage: vanity-go diff"},
+		},
+		{
+			name:       "history list",
+			args:       []string{"history", "list", "-admin-history-file", history},
+			wantCode:   exitOK,
+			wantStdout: []string{"1        2026-03-02T10:15:00Z  system  startup\n", "2        2026-03-02T11:00:00Z  alice   admin\n"},
+		},
+		{
+			name:       "history show",
+			args:       []string{"history", "show", "-admin-history-file", history, "1"},
+			wantCode:   exitOK,
+			wantStdout: []string{"  - path: tools\n    repository: https://gitlab.com/gllm-dev/tools\n"},
+		},
+		{
+			name:     "history diff",
+			args:     []string{"history", "diff", "-admin-history-file", history, "1"},
+			wantCode: exitOK,
+			wantStdout: []string{
+				"   go.gllm.dev/app    (unregistered)",
+				"!  go.gllm.dev/tools  git https://gitlab.com/gllm-dev/tools, active  git https://github.com/gllm-dev/tools, active\n",
+			},
+		},
+		{
+			name:       "history unknown version",
+			args:       []string{"history", "show", "-admin-history-file", history, "3"},
+			wantCode:   exitError,
+			wantStderr: []string{"version 3: not found"},
+		},
+		{
+			name:       "history without file",
+			args:       []string{"history", "list"},
+			wantCode:   exitUsage,
+			wantStderr: []string{"no history file configured"},
+		},
+		{
+			name:       "unknown command",
+			args:       []string{"deploy"},
+			wantCode:   exitUsage,
+			wantStderr: []string{`unknown command "deploy"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(context.Background(), tt.args, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+				}
+			}
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr = %q, want it to contain %q", stderr.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestRender_MatchesServer(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"render", "-domain", "go.gllm.dev", "-repository", "https://github.com/gllm-dev", "go.gllm.dev/vanity-go"}
+	if code := run(context.Background(), args, &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d; stderr: %s", code, stderr.String())
+	}
+
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	if want := svc.Vanity(context.Background(), "vanity-go"); stdout.String() != want {
+		t.Errorf("render output = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestCheck_Server(t *testing.T) {
+	t.Setenv("VANITY_DOMAIN", "go.gllm.dev")
+	t.Setenv("VANITY_REPOSITORY", "https://github.com/gllm-dev")
+	ctx := context.Background()
+
+	registry := writeConfig(t, "modules:\n  - path: tools\n    repository: https://gitlab.com/gllm-dev/tools\n    packages: [cli]\naliases:\n  - path: old\n    target: tools\n")
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	cfg, err := gosvc.ReadConfig(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Load(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := gohdl.New(svc, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
+	srv := httptest.NewServer(http.HandlerFunc(h.Handle))
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(ctx, []string{"check", "-registry", registry, srv.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("check of the served registry = %d; stdout: %s; stderr: %s", code, stdout.String(), stderr.String())
+	}
+	for _, want := range []string{"ok    go.gllm.dev/tools/cli: go.gllm.dev/tools git https://gitlab.com/gllm-dev/tools\n", "ok    go.gllm.dev/old:", "3 import paths checked, 0 failed\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+		}
+	}
+
+	// A registry the server does not serve is reported.
+	other := writeConfig(t, "modules:\n  - path: tools\n")
+	stdout.Reset()
+	if code := run(ctx, []string{"check", "-registry", other, srv.URL, "go.gllm.dev/tools"}, &stdout, &stderr); code != exitError {
+		t.Errorf("check of another registry = %d, want %d", code, exitError)
+	}
+	if !strings.Contains(stdout.String(), "differs from the registry") {
+		t.Errorf("stdout = %q, want the registry difference", stdout.String())
+	}
+}
+
+func TestValidate_VerifyGoMod(t *testing.T) {
+	srv := upstreamtest.NewServer(t, map[string]map[string]string{
+		"app":    {"go.mod": "module go.gllm.dev/app\n"},
+		"forked": {"go.mod": "module github.com/gllm-dev/forked\n"},
+	})
+	t.Setenv("VANITY_DOMAIN", "go.gllm.dev")
+	t.Setenv("VANITY_REPOSITORY", srv.URL)
+	ctx := context.Background()
+	registry := writeConfig(t, "modules:\n  - path: app\n  - path: forked\n")
+
+	tests := []struct {
+		mode       string
+		wantCode   int
+		wantStderr string
+	}{
+		{mode: "off", wantCode: exitOK},
+		{mode: "warn", wantCode: exitOK, wantStderr: "warning: go.gllm.dev/forked: go.mod declares module github.com/gllm-dev/forked"},
+		{mode: "reject", wantCode: exitError, wantStderr: "go.mod does not match the import path: go.gllm.dev/forked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(ctx, []string{"validate", "-upstream-verify-go-mod=" + tt.mode, registry}, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("validate = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) || (tt.wantStderr == "" && stderr.Len() > 0) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// render prints the HTML the server returns for an import path, byte for byte.
+func render(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("render", stderr)
+	loader := newServiceLoader(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	cfg, ok := loadConfig("render", loader, stderr)
+	if !ok {
+		return exitError
+	}
+	svc, err := loadService(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go render: %v\n", err)
+		return exitError
+	}
+	path, err := requestPath(cfg.Domain, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go render: %v\n", err)
+		return exitError
+	}
+
+	if _, err := stdout.Write(svc.Page(ctx, path).HTML); err != nil {
+		fmt.Fprintf(stderr, "vanity-go render: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// resolve explains how an import path resolves: the registry rule that matched,
+// the module root, its repository, the package directory inside the module,
+// the lifecycle status and where a renamed module moved to.
+func resolve(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("resolve", stderr)
+	loader := newServiceLoader(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	cfg, ok := loadConfig("resolve", loader, stderr)
+	if !ok {
+		return exitError
+	}
+	svc, err := loadService(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go resolve: %v\n", err)
+		return exitError
+	}
+	path, err := requestPath(cfg.Domain, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go resolve: %v\n", err)
+		return exitError
+	}
+
+	res := svc.Explain(ctx, path)
+	rule := res.Rule
+	if rule == "" {
+		rule = "(none; repository base URL)"
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "rule:\t%s\n", rule)
+	fmt.Fprintf(tw, "module:\t%s\n", res.Module.ImportPath)
+	fmt.Fprintf(tw, "repository:\t%s\n", res.Module.Repository)
+	fmt.Fprintf(tw, "vcs:\t%s\n", res.Module.VCS)
+	fmt.Fprintf(tw, "subdir:\t%s\n", res.Subdir)
+	fmt.Fprintf(tw, "status:\t%s\n", res.Module.Status.OrActive())
+	if res.Module.Replacement != "" {
+		fmt.Fprintf(tw, "replacement:\t%s\n", res.Module.Replacement)
+	}
+	if res.Module.MovedTo != "" {
+		fmt.Fprintf(tw, "moved to:\t%s\n", res.Module.MovedTo)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "vanity-go resolve: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
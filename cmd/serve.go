@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.gllm.dev/vanity-go/di"
+	"go.gllm.dev/vanity-go/internal/config"
+)
+
+// serve starts the HTTP server and blocks until it is stopped by SIGINT or SIGTERM.
+// SIGHUP reloads the module registry. The repositories of the modules are checked
+// in the background when upstream checks are enabled, and the go.mod of the modules
+// loaded at startup is verified in the background when go.mod verification is enabled.
+func serve(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("serve", stderr)
+	loader := config.NewLoader(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "vanity-go serve: unexpected argument %q\n", fs.Arg(0))
+		return exitUsage
+	}
+
+	cfg, ok := loadConfig("serve", loader, stderr)
+	if !ok {
+		return exitError
+	}
+
+	app, err := di.ProvideApp(cfg)
+	if err != nil {
+		slog.Error("Failed to initialize dependencies", slog.String("error", err.Error()))
+		return exitError
+	}
+	server, logger, tp := app.Server, app.Logger, app.TracerProvider
+
+	logger.InfoContext(ctx, "Starting vanity-go server")
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func() {
+		for range hupCh {
+			if err := app.Reload(ctx, "SIGHUP"); err != nil {
+				logger.ErrorContext(ctx, "Failed to reload module registry", slog.String("error", err.Error()))
+				continue
+			}
+			logger.InfoContext(ctx, "Module registry reloaded", slog.Int("modules", len(app.Service.Modules())))
+		}
+	}()
+
+	checkCtx, stopChecks := context.WithCancel(ctx)
+	defer stopChecks()
+	go app.Upstream.VerifyRegistered(checkCtx)
+	go app.Upstream.Run(checkCtx)
+	go app.Upstream.RunVersions(checkCtx)
+	go app.Vuln.Run(checkCtx)
+	if app.Docs != nil {
+		go app.Docs.Run(checkCtx)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	go func() {
+		<-sigCh
+		logger.InfoContext(ctx, "Received shutdown signal")
+		stopChecks()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := server.Stop(shutdownCtx); err != nil {
+			logger.ErrorContext(ctx, "Failed to shutdown server gracefully", slog.String("error", err.Error()))
+		}
+
+		if err := tp.Shutdown(shutdownCtx); err != nil {
+			logger.ErrorContext(ctx, "Failed to flush traces", slog.String("error", err.Error()))
+		}
+
+		if app.Audit != nil {
+			if err := app.Audit.Close(); err != nil {
+				logger.ErrorContext(ctx, "Failed to close audit log", slog.String("error", err.Error()))
+			}
+		}
+
+		wg.Done()
+	}()
+
+	if err := server.Start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.ErrorContext(ctx, "Failed to start server", slog.String("error", err.Error()))
+		return exitError
+	}
+
+	wg.Wait()
+	logger.InfoContext(ctx, "Server stopped")
+	return exitOK
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.gllm.dev/vanity-go/internal/config"
+	"go.gllm.dev/vanity-go/internal/errjoin"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// serviceSettings are the settings accepted as flags by the commands that run without the server.
+var serviceSettings = []string{"domain", "repository", "registry"}
+
+// loadConfig loads the configuration registered on fs by loader from the environment
+// and reports every error to stderr under the name of the command.
+func loadConfig(name string, loader *config.Loader, stderr io.Writer) (*config.Config, bool) {
+	cfg, err := loader.Load(os.LookupEnv)
+	if err != nil {
+		fmt.Fprintf(stderr, "vanity-go %s: invalid configuration:\n", name)
+		for _, err := range errjoin.Split(err) {
+			fmt.Fprintf(stderr, "  %v\n", err)
+		}
+		return nil, false
+	}
+	return cfg, true
+}
+
+// newServiceLoader registers the service settings and -config-file on fs.
+func newServiceLoader(fs *flag.FlagSet) *config.Loader {
+	return config.NewLoader(fs, serviceSettings...)
+}
+
+// loadService creates the service and loads the module registry, if one is configured.
+func loadService(ctx context.Context, cfg *config.Config) (*gosvc.Service, error) {
+	svc := gosvc.New(cfg.Domain, cfg.Repository)
+	if cfg.Registry == "" {
+		return svc, nil
+	}
+	registry, err := gosvc.ReadConfig(cfg.Registry)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.Load(ctx, registry); err != nil {
+		return nil, fmt.Errorf("invalid module registry %s:\n%w", cfg.Registry, err)
+	}
+	return svc, nil
+}
+
+// requestPath returns the path the server sees when the go command fetches importPath.
+// A scheme and query, as in "https://go.gllm.dev/tools?go-get=1", are accepted and dropped.
+func requestPath(domain, importPath string) (string, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(importPath, "https://"), "http://")
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	if p == domain {
+		return "", nil
+	}
+	if path, ok := strings.CutPrefix(p, domain+"/"); ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("import path %q is not under the vanity domain %q", importPath, domain)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.gllm.dev/vanity-go/internal/config"
+	"go.gllm.dev/vanity-go/internal/errjoin"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"go.gllm.dev/vanity-go/internal/services/upstreamsvc"
+)
+
+// verifySettings are the settings of the verification of the go.mod of the modules.
+var verifySettings = []string{"upstream.verify_go_mod", "upstream.clone_dir", "upstream.check_timeout"}
+
+// validate checks a module registry file the way serve loads it and reports every error found.
+// The file is the argument, or the -registry flag when no argument is given. When go.mod
+// verification is enabled, the go.mod files of every module are verified as well.
+func validate(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("validate", stderr)
+	loader := config.NewLoader(fs, append(serviceSettings, verifySettings...)...)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	settings, ok := loadConfig("validate", loader, stderr)
+	if !ok {
+		return exitError
+	}
+	path := settings.Registry
+	if fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+	if path == "" {
+		fmt.Fprintln(stderr, "vanity-go validate: no module registry file given")
+		return exitUsage
+	}
+
+	svc := gosvc.New(settings.Domain, settings.Repository)
+	cfg, err := gosvc.ReadConfig(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return exitError
+	}
+	if err := svc.Validate(cfg); err != nil {
+		for _, err := range errjoin.Split(err) {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+		}
+		return exitError
+	}
+
+	if settings.Upstream.VerifyGoMod != upstreamsvc.VerifyOff {
+		if err := svc.Load(ctx, cfg); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			return exitError
+		}
+		verifier := upstreamsvc.New(settings.Upstream, svc, prometheus.NewRegistry(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+		warnings, err := verifier.Verify(ctx, svc.Modules())
+		for _, w := range warnings {
+			fmt.Fprintf(stderr, "%s: warning: %s\n", path, w)
+		}
+		if err != nil {
+			for _, err := range errjoin.Split(err) {
+				fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			}
+			return exitError
+		}
+	}
+
+	if len(cfg.Aliases) > 0 {
+		fmt.Fprintf(stdout, "%s: %d modules, %d aliases OK\n", path, len(cfg.Modules), len(cfg.Aliases))
+		return exitOK
+	}
+	fmt.Fprintf(stdout, "%s: %d modules OK\n", path, len(cfg.Modules))
+	return exitOK
+}
@@ -4,48 +4,181 @@
 package di
 
 import (
-	"errors"
+	"context"
 	"github.com/google/wire"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"log/slog"
 	"os"
 
 	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest"
+	"go.gllm.dev/vanity-go/internal/audit"
+	"go.gllm.dev/vanity-go/internal/config"
+	"go.gllm.dev/vanity-go/internal/logging"
+	"go.gllm.dev/vanity-go/internal/services/adminsvc"
+	"go.gllm.dev/vanity-go/internal/services/docsvc"
 	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"go.gllm.dev/vanity-go/internal/services/upstreamsvc"
+	"go.gllm.dev/vanity-go/internal/services/vulnsvc"
+	"go.gllm.dev/vanity-go/internal/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 type Domain string
 type Repository string
 
-func ProvideDomain() (Domain, error) {
-	domain := os.Getenv("VANITY_DOMAIN")
-	if domain == "" {
-		return "", errors.New("VANITY_DOMAIN environment variable not set")
+// RegistryPath is the path of the module registry file; empty when none is configured.
+type RegistryPath string
+
+// App groups the components main needs to run the server.
+type App struct {
+	Server         *rest.Server
+	Logger         *slog.Logger
+	TracerProvider *sdktrace.TracerProvider
+	Service        *gosvc.Service
+	RegistryPath   RegistryPath
+	// Admin changes the registered modules on reloads and through the admin API.
+	Admin *adminsvc.Service
+	// Audit records admin calls and registry reloads; nil when auditing is disabled.
+	Audit *audit.Log
+	// Upstream checks the repositories of the registered modules in the background.
+	Upstream *upstreamsvc.Service
+	// Vuln reads the known vulnerabilities of the registered modules in the background.
+	Vuln *vulnsvc.Service
+	// Docs renders the documentation of the registered modules; nil when it is disabled.
+	Docs *docsvc.Service
+}
+
+// Reload re-reads the module registry file and atomically replaces the registered modules.
+// It does nothing when no registry file is configured. The reload is recorded in the
+// audit log, if any, with source naming what triggered it.
+func (a *App) Reload(ctx context.Context, source string) error {
+	return a.Admin.Reload(ctx, source)
+}
+
+// loadRegistry reads the module registry file at path into svc.
+func loadRegistry(ctx context.Context, svc *gosvc.Service, path RegistryPath) error {
+	cfg, err := gosvc.ReadConfig(string(path))
+	if err != nil {
+		return err
 	}
-	return Domain(domain), nil
+	return svc.Load(ctx, cfg)
+}
+
+func ProvideDomain(cfg *config.Config) Domain {
+	return Domain(cfg.Domain)
+}
+
+func ProvideRepository(cfg *config.Config) Repository {
+	return Repository(cfg.Repository)
 }
 
-func ProvideRepository() (Repository, error) {
-	repository := os.Getenv("VANITY_REPOSITORY")
-	if repository == "" {
-		return "", errors.New("VANITY_REPOSITORY environment variable not set")
+func ProvideRegistryPath(cfg *config.Config) RegistryPath {
+	return RegistryPath(cfg.Registry)
+}
+
+func ProvideService(domain Domain, repository Repository, path RegistryPath) (*gosvc.Service, error) {
+	svc := gosvc.New(string(domain), string(repository))
+	if path != "" {
+		if err := loadRegistry(context.Background(), svc, path); err != nil {
+			return nil, err
+		}
 	}
-	return Repository(repository), nil
+	return svc, nil
+}
+
+func ProvideAdminService(cfg *adminsvc.Config, svc *gosvc.Service, path RegistryPath, log *audit.Log, upstream *upstreamsvc.Service) (*adminsvc.Service, error) {
+	return adminsvc.New(cfg, svc, string(path), log, upstream)
 }
 
-func ProvideService(domain Domain, repository Repository) *gosvc.Service {
-	return gosvc.New(string(domain), string(repository))
+func ProvideUpstreamService(cfg *upstreamsvc.Config, svc *gosvc.Service, reg *prometheus.Registry, logger *slog.Logger) *upstreamsvc.Service {
+	return upstreamsvc.New(cfg, svc, reg, logger)
+}
+
+func ProvideVulnService(cfg *vulnsvc.Config, svc *gosvc.Service, logger *slog.Logger) *vulnsvc.Service {
+	return vulnsvc.New(cfg, svc, logger)
+}
+
+// ProvideDocsService creates the documentation service, or returns nil when no
+// directory of checkouts is configured.
+func ProvideDocsService(cfg *docsvc.Config, svc *gosvc.Service, logger *slog.Logger) *docsvc.Service {
+	if cfg.Dir == "" {
+		return nil
+	}
+	return docsvc.New(cfg, svc, logger)
+}
+
+// ProvideAuditLog opens the audit log, or returns nil when auditing is disabled.
+func ProvideAuditLog(cfg *audit.Config) (*audit.Log, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+	return audit.Open(cfg.File)
+}
+
+func ProvideLogger(cfg *logging.Config, level *slog.LevelVar) *slog.Logger {
+	return logging.New(cfg, level, os.Stderr)
+}
+
+// ProvideTracerProvider creates the tracer provider and installs it, together with
+// the W3C trace context propagator, as the global OpenTelemetry defaults.
+func ProvideTracerProvider(cfg *telemetry.Config) (*sdktrace.TracerProvider, error) {
+	tp, err := telemetry.NewTracerProvider(cfg, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return tp, nil
+}
+
+// ProvideMetricsRegistry creates the Prometheus registry exposed on /metrics,
+// preloaded with the Go runtime and process collectors.
+func ProvideMetricsRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
 }
 
 var serviceSet = wire.NewSet(
 	ProvideDomain,
 	ProvideRepository,
+	ProvideRegistryPath,
 	ProvideService,
+	ProvideAdminService,
+	ProvideUpstreamService,
+	ProvideVulnService,
+	ProvideDocsService,
+)
+
+var loggingSet = wire.NewSet(
+	logging.NewLevel,
+	ProvideLogger,
+)
+
+var telemetrySet = wire.NewSet(
+	ProvideTracerProvider,
 )
 
-func ProvideRestServer() (*rest.Server, error) {
+// ProvideApp builds the application from an already loaded configuration.
+func ProvideApp(cfg *config.Config) (*App, error) {
 	wire.Build(
+		wire.FieldsOf(new(*config.Config), "Server", "Log", "Tracing", "RateLimit", "Admin", "Audit", "Upstream", "Vuln", "Docs"),
 		rest.New,
-		rest.LoadConfig,
+		ProvideMetricsRegistry,
+		ProvideAuditLog,
 		serviceSet,
+		loggingSet,
+		telemetrySet,
+		wire.Struct(new(App), "*"),
 	)
 
 	return nil, nil
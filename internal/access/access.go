@@ -0,0 +1,228 @@
+// Package access restricts which clients may resolve a module: clients from allowed
+// networks, or presenting HTTP basic auth credentials, a bearer token or a verified
+// TLS client certificate.
+//
+// Basic auth is what the go command sends for hosts listed in .netrc or returned by GOAUTH,
+// so private modules can be fetched without any other client configuration.
+package access
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"strings"
+
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/secret"
+)
+
+// Config is an access policy as written in the module registry.
+// A client is allowed when it matches any of the networks or credentials.
+//
+// Example:
+//
+//	networks: [10.0.0.0/8, 192.168.1.10]
+//	basic_auth:
+//	  - username: ci
+//	    password_file: /run/secrets/ci-password
+//	bearer:
+//	  - token_file: /run/secrets/deploy-token
+//	subjects: [ci.corp.example, "CN=build,OU=Platform,O=Corp"]
+type Config struct {
+	// Networks are CIDRs or single addresses of allowed clients.
+	Networks []string `yaml:"networks,omitempty"`
+	// BasicAuth are the accepted HTTP basic auth credentials.
+	BasicAuth []BasicAuthConfig `yaml:"basic_auth,omitempty"`
+	// Bearer are the accepted bearer tokens.
+	Bearer []BearerConfig `yaml:"bearer,omitempty"`
+	// Subjects are the accepted subjects of TLS client certificates verified by the server,
+	// each either a common name or a full distinguished name (e.g., "CN=build,O=Corp").
+	Subjects []string `yaml:"subjects,omitempty"`
+}
+
+// BasicAuthConfig is an accepted user. Exactly one of Password and PasswordFile is set.
+type BasicAuthConfig struct {
+	Username string `yaml:"username"`
+	// Password is the password, inline in the registry.
+	Password string `yaml:"password,omitempty"`
+	// PasswordFile is the path of the file holding the password, re-read when it changes.
+	PasswordFile string `yaml:"password_file,omitempty"`
+}
+
+// BearerConfig is an accepted bearer token. Exactly one of Token and TokenFile is set.
+type BearerConfig struct {
+	// Token is the token, inline in the registry.
+	Token string `yaml:"token,omitempty"`
+	// TokenFile is the path of the file holding the token, re-read when it changes.
+	TokenFile string `yaml:"token_file,omitempty"`
+}
+
+// Policy is a validated access policy.
+type Policy struct {
+	// config is the configuration the policy was created from.
+	config   Config
+	networks []netip.Prefix
+	users    map[string]*secret.Secret
+	tokens   []*secret.Secret
+	subjects map[string]bool
+}
+
+// New validates cfg and reads the secrets it refers to.
+// Every problem found is reported, joined with errors.Join.
+// A policy must allow at least one network or credential.
+func New(cfg Config) (*Policy, error) {
+	p := &Policy{config: cfg, users: make(map[string]*secret.Secret, len(cfg.BasicAuth))}
+	var errs []error
+
+	for _, network := range cfg.Networks {
+		prefixes, err := clientip.ParsePrefixes(network)
+		if err != nil || len(prefixes) != 1 {
+			errs = append(errs, fmt.Errorf("invalid network %q", network))
+			continue
+		}
+		p.networks = append(p.networks, prefixes[0])
+	}
+
+	for i, user := range cfg.BasicAuth {
+		if user.Username == "" || strings.Contains(user.Username, ":") {
+			errs = append(errs, fmt.Errorf("basic_auth %d: username must be set and must not contain a colon", i+1))
+			continue
+		}
+		if _, ok := p.users[user.Username]; ok {
+			errs = append(errs, fmt.Errorf("basic_auth %d: duplicate username %q", i+1, user.Username))
+			continue
+		}
+		s, err := newSecret("password", user.Password, user.PasswordFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("basic_auth %d (%q): %w", i+1, user.Username, err))
+			continue
+		}
+		p.users[user.Username] = s
+	}
+
+	for i, bearer := range cfg.Bearer {
+		s, err := newSecret("token", bearer.Token, bearer.TokenFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("bearer %d: %w", i+1, err))
+			continue
+		}
+		p.tokens = append(p.tokens, s)
+	}
+
+	p.subjects = make(map[string]bool, len(cfg.Subjects))
+	for _, subject := range cfg.Subjects {
+		if subject == "" {
+			errs = append(errs, errors.New("empty subject"))
+			continue
+		}
+		p.subjects[subject] = true
+	}
+
+	if len(cfg.Networks) == 0 && len(cfg.BasicAuth) == 0 && len(cfg.Bearer) == 0 && len(cfg.Subjects) == 0 {
+		errs = append(errs, errors.New("no networks, basic_auth, bearer or subjects allowed"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newSecret returns the secret given inline or as the path of the file holding it.
+func newSecret(name, value, path string) (*secret.Secret, error) {
+	switch {
+	case value != "" && path != "":
+		return nil, fmt.Errorf("%s and %s_file are mutually exclusive", name, name)
+	case path != "":
+		return secret.FromFile(path)
+	case value != "":
+		return secret.New(value), nil
+	default:
+		return nil, fmt.Errorf("%s or %s_file is required", name, name)
+	}
+}
+
+// Equal reports whether p and q were created from the same configuration.
+// A nil policy, allowing everyone, only equals another nil policy.
+func (p *Policy) Equal(q *Policy) bool {
+	if p == nil || q == nil {
+		return p == q
+	}
+	return reflect.DeepEqual(p.config, q.config)
+}
+
+// String describes who the policy allows without revealing any secret
+// (e.g., "networks 10.0.0.0/8; basic auth ci; 1 bearer token"), or "everyone" for a nil policy.
+func (p *Policy) String() string {
+	if p == nil {
+		return "everyone"
+	}
+	var parts []string
+	if len(p.config.Networks) > 0 {
+		parts = append(parts, "networks "+strings.Join(p.config.Networks, ", "))
+	}
+	if len(p.config.BasicAuth) > 0 {
+		users := make([]string, len(p.config.BasicAuth))
+		for i, user := range p.config.BasicAuth {
+			users[i] = user.Username
+		}
+		parts = append(parts, "basic auth "+strings.Join(users, ", "))
+	}
+	if n := len(p.config.Bearer); n == 1 {
+		parts = append(parts, "1 bearer token")
+	} else if n > 1 {
+		parts = append(parts, fmt.Sprintf("%d bearer tokens", n))
+	}
+	if len(p.config.Subjects) > 0 {
+		parts = append(parts, "subjects "+strings.Join(p.config.Subjects, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Allows reports whether the request r from the client address client is allowed.
+// Secrets are read on every call, so rotated files take effect immediately.
+// Secrets that cannot be read match nothing and are reported in the error.
+func (p *Policy) Allows(r *http.Request, client netip.Addr) (bool, error) {
+	for _, network := range p.networks {
+		if network.Contains(client) {
+			return true, nil
+		}
+	}
+
+	// Only chains verified against the client CAs count; unverified certificates are ignored.
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		subject := r.TLS.VerifiedChains[0][0].Subject
+		if p.subjects[subject.CommonName] || p.subjects[subject.String()] {
+			return true, nil
+		}
+	}
+
+	var errs []error
+	if username, password, ok := r.BasicAuth(); ok {
+		if s, ok := p.users[username]; ok {
+			want, err := s.Value()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("password of %q: %w", username, err))
+			} else if subtle.ConstantTimeCompare([]byte(password), []byte(want)) == 1 {
+				return true, nil
+			}
+		}
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		for _, s := range p.tokens {
+			want, err := s.Value()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("bearer token: %w", err))
+				continue
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1 {
+				return true, errors.Join(errs...)
+			}
+		}
+	}
+
+	return false, errors.Join(errs...)
+}
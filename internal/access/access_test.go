@@ -0,0 +1,246 @@
+package access
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNew_Errors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr []string
+	}{
+		{
+			name:    "empty",
+			cfg:     Config{},
+			wantErr: []string{"no networks, basic_auth, bearer or subjects allowed"},
+		},
+		{
+			name:    "invalid network",
+			cfg:     Config{Networks: []string{"10.0.0.0/33", "10.0.0.1,10.0.0.2"}},
+			wantErr: []string{`invalid network "10.0.0.0/33"`, `invalid network "10.0.0.1,10.0.0.2"`},
+		},
+		{
+			name: "invalid users",
+			cfg: Config{BasicAuth: []BasicAuthConfig{
+				{Username: "", Password: "p"},
+				{Username: "a:b", Password: "p"},
+				{Username: "ci"},
+				{Username: "ops", Password: "p", PasswordFile: "f"},
+				{Username: "dev", PasswordFile: missing},
+				{Username: "root", Password: "p"},
+				{Username: "root", Password: "q"},
+			}},
+			wantErr: []string{
+				"basic_auth 1: username must be set",
+				"basic_auth 2: username must be set",
+				`basic_auth 3 ("ci"): password or password_file is required`,
+				`basic_auth 4 ("ops"): password and password_file are mutually exclusive`,
+				`basic_auth 5 ("dev"): failed to read secret file`,
+				`basic_auth 7: duplicate username "root"`,
+			},
+		},
+		{
+			name:    "invalid bearer",
+			cfg:     Config{Bearer: []BearerConfig{{}}},
+			wantErr: []string{"bearer 1: token or token_file is required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if err == nil {
+				t.Fatal("New() expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("New() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicy_Allows(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("t0ken\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(Config{
+		Networks:  []string{"10.0.0.0/8", "192.168.1.10"},
+		BasicAuth: []BasicAuthConfig{{Username: "ci", Password: "s3cret"}},
+		Bearer:    []BearerConfig{{TokenFile: tokenFile}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		client   string
+		username string
+		password string
+		bearer   string
+		want     bool
+	}{
+		{name: "allowed network", client: "10.1.2.3", want: true},
+		{name: "allowed host", client: "192.168.1.10", want: true},
+		{name: "other host", client: "192.168.1.11"},
+		{name: "basic auth", client: "203.0.113.1", username: "ci", password: "s3cret", want: true},
+		{name: "wrong password", client: "203.0.113.1", username: "ci", password: "guess"},
+		{name: "unknown user", client: "203.0.113.1", username: "root", password: "s3cret"},
+		{name: "bearer", client: "203.0.113.1", bearer: "t0ken", want: true},
+		{name: "wrong bearer", client: "203.0.113.1", bearer: "s3cret"},
+		{name: "nothing", client: "203.0.113.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/internal?go-get=1", nil)
+			if tt.username != "" {
+				r.SetBasicAuth(tt.username, tt.password)
+			}
+			if tt.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			got, err := p.Allows(r, netip.MustParseAddr(tt.client))
+			if err != nil {
+				t.Fatalf("Allows() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Allows_UnreadableSecret(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("t0ken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(Config{Bearer: []BearerConfig{{TokenFile: tokenFile}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(tokenFile); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/internal", nil)
+	r.Header.Set("Authorization", "Bearer t0ken")
+	allowed, err := p.Allows(r, netip.MustParseAddr("203.0.113.1"))
+	if allowed {
+		t.Error("Allows() = true with a removed token file")
+	}
+	if err == nil {
+		t.Error("Allows() should report the unreadable token file")
+	}
+}
+
+func TestPolicy_Allows_Subjects(t *testing.T) {
+	p, err := New(Config{Subjects: []string{"ci", "CN=build,O=Corp"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		subject  pkix.Name
+		verified bool
+		want     bool
+	}{
+		{name: "common name", subject: pkix.Name{CommonName: "ci", Organization: []string{"Corp"}}, verified: true, want: true},
+		{name: "distinguished name", subject: pkix.Name{CommonName: "build", Organization: []string{"Corp"}}, verified: true, want: true},
+		{name: "other organization", subject: pkix.Name{CommonName: "build", Organization: []string{"Other"}}, verified: true},
+		{name: "unverified", subject: pkix.Name{CommonName: "ci"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/internal?go-get=1", nil)
+			cert := &x509.Certificate{Subject: tt.subject}
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+			if tt.verified {
+				r.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+			}
+			got, err := p.Allows(r, netip.MustParseAddr("203.0.113.1"))
+			if err != nil {
+				t.Fatalf("Allows() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_String(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *Config
+		want string
+	}{
+		{name: "nil", want: "everyone"},
+		{
+			name: "every kind",
+			cfg: &Config{
+				Networks:  []string{"10.0.0.0/8", "192.168.1.10"},
+				BasicAuth: []BasicAuthConfig{{Username: "ci", Password: "secret"}, {Username: "ops", Password: "secret"}},
+				Bearer:    []BearerConfig{{Token: "secret"}},
+				Subjects:  []string{"build"},
+			},
+			want: "networks 10.0.0.0/8, 192.168.1.10; basic auth ci, ops; 1 bearer token; subjects build",
+		},
+		{name: "bearer tokens", cfg: &Config{Bearer: []BearerConfig{{Token: "a"}, {Token: "b"}}}, want: "2 bearer tokens"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p *Policy
+			if tt.cfg != nil {
+				var err error
+				if p, err = New(*tt.cfg); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Equal(t *testing.T) {
+	newPolicy := func(cfg Config) *Policy {
+		p, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	a := newPolicy(Config{Networks: []string{"10.0.0.0/8"}, Bearer: []BearerConfig{{Token: "a"}}})
+	same := newPolicy(Config{Networks: []string{"10.0.0.0/8"}, Bearer: []BearerConfig{{Token: "a"}}})
+	rotated := newPolicy(Config{Networks: []string{"10.0.0.0/8"}, Bearer: []BearerConfig{{Token: "b"}}})
+
+	if !a.Equal(same) {
+		t.Error("Equal() = false for policies of the same configuration")
+	}
+	if a.Equal(rotated) {
+		t.Error("Equal() = true for policies with different tokens")
+	}
+	if a.Equal(nil) || !(*Policy)(nil).Equal(nil) {
+		t.Error("Equal() with nil policies is wrong")
+	}
+}
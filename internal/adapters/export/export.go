@@ -0,0 +1,173 @@
+// Package export writes the vanity pages of the module registry as a static site,
+// so they can be served by any static host such as GitHub Pages or an S3 bucket.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// Options select the optional files of an export.
+type Options struct {
+	// Packages are extra request paths, relative to the vanity domain, that get their own page
+	// (e.g., "tools/cli/cmd/cli"). Static hosts cannot answer paths without a file.
+	Packages []string
+	// Index writes an index.html at the root listing the active and deprecated modules.
+	Index bool
+	// Manifest writes a modules.json at the root describing every module but the hidden ones.
+	Manifest bool
+}
+
+// Manifest is the content of modules.json.
+type Manifest struct {
+	// Domain is the vanity domain.
+	Domain string `json:"domain"`
+	// Modules are the registered modules ordered by import path.
+	// Hidden modules are left out.
+	Modules []ManifestModule `json:"modules"`
+}
+
+// ManifestModule describes a module in the manifest.
+type ManifestModule struct {
+	ImportPath string `json:"import_path"`
+	VCS        string `json:"vcs"`
+	Repository string `json:"repository"`
+	// Status is the lifecycle state of the module.
+	Status gosvc.Status `json:"status"`
+	// Replacement is the import path a deprecated module points its users to, if any.
+	Replacement string `json:"replacement,omitempty"`
+	// ETag is the entity tag the server sends with the module page.
+	ETag string `json:"etag"`
+}
+
+// Files returns the files of the export, keyed by slash-separated path relative to the output directory.
+// Pages are byte-identical to the responses of the server for the same paths.
+// Paths restricted by an access policy are not exported.
+func Files(ctx context.Context, svc *gosvc.Service, opts Options) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	domain := svc.Domain()
+
+	// Static hosts cannot enforce access policies, so restricted paths are left out entirely.
+	// They cannot tell the go command from browsers either: hidden modules still get their
+	// pages, as the go command needs them, and aliases get the page with the notice
+	// instead of redirecting.
+	var modules []gosvc.Module
+	for _, m := range svc.Modules() {
+		path := strings.TrimPrefix(m.ImportPath, domain+"/")
+		if svc.Policy(path) != nil {
+			continue
+		}
+		modules = append(modules, m)
+		files[path+"/index.html"] = svc.Page(ctx, path).HTML
+	}
+	for _, m := range svc.Aliases() {
+		path := strings.TrimPrefix(m.ImportPath, domain+"/")
+		if svc.Policy(path) != nil {
+			continue
+		}
+		files[path+"/index.html"] = svc.Page(ctx, path).HTML
+	}
+	for _, path := range opts.Packages {
+		if path == "" || !filepath.IsLocal(path) || strings.Contains(path, "\\") {
+			return nil, fmt.Errorf("invalid package path %q", path)
+		}
+		if svc.Policy(path) != nil {
+			continue
+		}
+		files[path+"/index.html"] = svc.Page(ctx, path).HTML
+	}
+
+	if opts.Index {
+		var b bytes.Buffer
+		listed := manifestModules(ctx, svc, modules, gosvc.Status.Listed)
+		if err := indexTemplate.Execute(&b, Manifest{Domain: domain, Modules: listed}); err != nil {
+			return nil, fmt.Errorf("failed to render index: %w", err)
+		}
+		files["index.html"] = b.Bytes()
+	}
+
+	if opts.Manifest {
+		described := manifestModules(ctx, svc, modules, func(s gosvc.Status) bool { return s != gosvc.StatusHidden })
+		b, err := json.MarshalIndent(Manifest{Domain: domain, Modules: described}, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		files["modules.json"] = append(b, '\n')
+	}
+
+	return files, nil
+}
+
+// Write writes the export into dir and returns the written paths in lexical order.
+// Existing files in dir are kept unless overwritten, so files such as a CNAME survive.
+// Writing the same registry twice produces identical files.
+func Write(ctx context.Context, svc *gosvc.Service, dir string, opts Options) ([]string, error) {
+	files, err := Files(ctx, svc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		name := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		if err := os.WriteFile(name, files[path], 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return paths, nil
+}
+
+// manifestModules describes the modules whose status is included, for the index and the manifest.
+func manifestModules(ctx context.Context, svc *gosvc.Service, modules []gosvc.Module, include func(gosvc.Status) bool) []ManifestModule {
+	out := make([]ManifestModule, 0, len(modules))
+	for _, m := range modules {
+		if !include(m.Status) {
+			continue
+		}
+		out = append(out, ManifestModule{
+			ImportPath:  m.ImportPath,
+			VCS:         m.VCS,
+			Repository:  m.Repository,
+			Status:      m.Status.OrActive(),
+			Replacement: m.Replacement,
+			ETag:        svc.Page(ctx, strings.TrimPrefix(m.ImportPath, svc.Domain()+"/")).Header.Get("Etag"),
+		})
+	}
+	return out
+}
+
+// indexTemplate lists the modules of the domain, linking each one to its documentation.
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Domain}}</title>
+</head>
+<body>
+<h1>{{.Domain}}</h1>
+<ul>
+{{- range .Modules}}
+<li><a href="https://pkg.go.dev/{{.ImportPath}}">{{.ImportPath}}</a> ({{.VCS}}: <a href="{{.Repository}}">{{.Repository}}</a>)
+{{- if eq .Status "deprecated"}} deprecated{{with .Replacement}}, use <a href="https://pkg.go.dev/{{.}}">{{.}}</a>{{end}}{{end}}</li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
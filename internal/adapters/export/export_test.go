@@ -0,0 +1,143 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.gllm.dev/vanity-go/internal/access"
+	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/gohdl"
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+func newService(t *testing.T) *gosvc.Service {
+	t.Helper()
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &gosvc.Config{Modules: []gosvc.ModuleConfig{
+		{Path: "vanity-go"},
+		{Path: "tools", Repository: "https://gitlab.com/gllm-dev/tools"},
+		{Path: "tools/cli", VCS: "hg"},
+		{Path: "legacy", Status: "deprecated", Replacement: "go.gllm.dev/tools"},
+		{Path: "attic", Status: "archived"},
+		{Path: "internal", Status: "hidden"},
+		{Path: "private", Access: &access.Config{Networks: []string{"10.0.0.0/8"}}},
+	}, Aliases: []gosvc.AliasConfig{
+		{Path: "oldtools", Target: "tools", Redirect: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestWrite_MatchesHandler(t *testing.T) {
+	ctx := context.Background()
+	svc := newService(t)
+	dir := t.TempDir()
+
+	written, err := Write(ctx, svc, dir, Options{Packages: []string{"tools/cmd/tool", "tools/cli/cmd"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"attic/index.html",
+		"internal/index.html",
+		"legacy/index.html",
+		"oldtools/index.html",
+		"tools/cli/cmd/index.html",
+		"tools/cli/index.html",
+		"tools/cmd/tool/index.html",
+		"tools/index.html",
+		"vanity-go/index.html",
+	}
+	if strings.Join(written, ",") != strings.Join(want, ",") {
+		t.Fatalf("Write() = %v, want %v", written, want)
+	}
+
+	h := gohdl.New(svc, slog.New(slog.NewTextHandler(io.Discard, nil)), 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
+	for _, path := range written {
+		got, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.Handle(rec, httptest.NewRequest("GET", "/"+strings.TrimSuffix(path, "/index.html")+"?go-get=1", nil))
+		if string(got) != rec.Body.String() {
+			t.Errorf("%s = %q, want the handler response %q", path, got, rec.Body.String())
+		}
+	}
+}
+
+func TestFiles_Deterministic(t *testing.T) {
+	ctx := context.Background()
+	opts := Options{Index: true, Manifest: true}
+
+	first, err := Files(ctx, newService(t), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Files(ctx, newService(t), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first) != len(second) {
+		t.Fatalf("got %d and %d files", len(first), len(second))
+	}
+	for path, content := range first {
+		if string(second[path]) != string(content) {
+			t.Errorf("%s differs between exports", path)
+		}
+	}
+}
+
+func TestFiles_IndexAndManifest(t *testing.T) {
+	files, err := Files(context.Background(), newService(t), Options{Index: true, Manifest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := string(files["index.html"])
+	for _, want := range []string{"go.gllm.dev/vanity-go", "go.gllm.dev/tools/cli", "https://gitlab.com/gllm-dev/tools", "go.gllm.dev/legacy", "deprecated, use"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index.html should contain %q, got %s", want, index)
+		}
+	}
+	for _, unwanted := range []string{"go.gllm.dev/attic", "go.gllm.dev/internal"} {
+		if strings.Contains(index, unwanted) {
+			t.Errorf("index.html should not contain %q, got %s", unwanted, index)
+		}
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(files["modules.json"], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Domain != "go.gllm.dev" || len(manifest.Modules) != 5 {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	if m := manifest.Modules[3]; m.ImportPath != "go.gllm.dev/tools/cli" || m.VCS != "hg" || m.Status != gosvc.StatusActive || m.ETag == "" {
+		t.Errorf("manifest module = %+v", m)
+	}
+	if m := manifest.Modules[1]; m.ImportPath != "go.gllm.dev/legacy" || m.Status != gosvc.StatusDeprecated || m.Replacement != "go.gllm.dev/tools" {
+		t.Errorf("manifest module = %+v", m)
+	}
+}
+
+func TestFiles_InvalidPackage(t *testing.T) {
+	for _, path := range []string{"", "../outside", "/abs"} {
+		if _, err := Files(context.Background(), newService(t), Options{Packages: []string{path}}); err == nil {
+			t.Errorf("Files() with package %q should fail", path)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"go.gllm.dev/vanity-go/internal/audit"
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/secret"
+)
+
+// adminTokens are the bearer tokens accepted by the admin endpoints.
+type adminTokens struct {
+	// shared is the token shared by every admin; nil when not configured.
+	shared *secret.Secret
+	// named are the tokens of individual admins as "name:token" lines; nil when not configured.
+	named *secret.Secret
+}
+
+// identify returns the name of the admin holding token, empty for the shared token,
+// and whether token is valid. Every token is compared, in constant time.
+func (t adminTokens) identify(token string) (name string, ok bool, err error) {
+	if t.shared != nil {
+		want, err := t.shared.Value()
+		if err != nil {
+			return "", false, err
+		}
+		ok = subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+	}
+	if t.named != nil {
+		value, err := t.named.Value()
+		if err != nil {
+			return "", false, err
+		}
+		named, err := parseAdminTokens(value)
+		if err != nil {
+			return "", false, err
+		}
+		for n, want := range named {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1 {
+				name, ok = n, true
+			}
+		}
+	}
+	return name, ok, nil
+}
+
+// parseAdminTokens parses named admin tokens: "name:token" entries separated by
+// newlines or commas. Blank entries are ignored; names and tokens must be unique.
+func parseAdminTokens(value string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == ',' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(entry, ":")
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if !ok || name == "" || token == "" {
+			return nil, errors.New("admin tokens must be name:token entries")
+		}
+		if _, dup := tokens[name]; dup {
+			return nil, fmt.Errorf("duplicate admin %q", name)
+		}
+		if seen[token] {
+			return nil, fmt.Errorf("admin %q shares its token with another admin", name)
+		}
+		tokens[name], seen[token] = token, true
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("no admin tokens")
+	}
+	return tokens, nil
+}
+
+// identityKey is the key of the admin identity in a request context.
+type identityKey struct{}
+
+// identity is the admin making a request that passed requireAdmin.
+type identity struct {
+	// name identifies the admin, see adminIdentity.
+	name string
+	// shared reports whether the admin used the shared token.
+	shared bool
+}
+
+// requireAdmin wraps next so it is only reachable with one of the admin bearer tokens.
+// The tokens are read on every request, so rotated token files take effect immediately.
+// Requests without a valid token receive a 401 response. When the tokens cannot be read
+// every request is refused with a 503 response.
+//
+// The identity of the admin, see adminIdentity, is passed to next in the request context
+// and set as the actor of the audit event of the request, if any.
+func requireAdmin(tokens adminTokens, logger *slog.Logger, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		name, ok, err := tokens.identify(got)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "Failed to read admin token", slog.String("error", err.Error()))
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if !ok || got == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="vanity-go"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id := identity{name: name}
+		if name == "" {
+			id = identity{name: certificateIdentity(r), shared: true}
+		}
+		audit.SetActor(r.Context(), id.name)
+		next(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, id)))
+	}
+}
+
+// requireNamedAdmin wraps next, behind requireAdmin, so it is only reachable with a named
+// admin token. Callers using the shared token receive a 403 response, as they cannot be
+// told apart reliably enough to approve each other's changes, nor from the named admins.
+func requireNamedAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if id, _ := r.Context().Value(identityKey{}).(identity); id.shared {
+			http.Error(w, "Forbidden: a named admin token is required", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// certificateIdentity returns the identity of an admin using the shared token: "cert:"
+// followed by the common name of its verified TLS client certificate, if any, so that
+// admins sharing the token can be told apart, and "token:shared" otherwise. Names of
+// named tokens never hold a colon, so these identities never collide with them.
+func certificateIdentity(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		if cn := r.TLS.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+			return "cert:" + cn
+		}
+	}
+	return "token:shared"
+}
+
+// adminIdentity returns the identity of the admin making a request that passed requireAdmin:
+// the name of its named token, or else the identity of the shared token, see
+// certificateIdentity.
+func adminIdentity(r *http.Request) string {
+	id, _ := r.Context().Value(identityKey{}).(identity)
+	return id.name
+}
+
+// withAudit wraps next so that every admin call that may change something, any method
+// but GET and HEAD, is recorded in log once served, together with its actor and the
+// changes the handlers added to the event in the request context. Refused calls are
+// recorded whatever their method, with an empty actor when no valid token was given.
+func withAudit(log *audit.Log, clients *clientip.Resolver, logger *slog.Logger, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		event := &audit.Event{
+			Source: clients.Resolve(r).String(),
+			Action: r.Method + " " + r.URL.Path,
+		}
+		rec := record(w)
+		next(rec, r.WithContext(audit.NewContext(r.Context(), event)))
+
+		event.Status = rec.Status()
+		refused := event.Status == http.StatusUnauthorized || event.Status == http.StatusServiceUnavailable
+		if !refused && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			return
+		}
+		if err := log.Record(*event); err != nil {
+			logger.ErrorContext(r.Context(), "Failed to record admin call", slog.String("error", err.Error()), slog.String("action", event.Action))
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.gllm.dev/vanity-go/internal/audit"
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/ratelimit"
+	"go.gllm.dev/vanity-go/internal/secret"
+	"go.gllm.dev/vanity-go/internal/services/adminsvc"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"go.gllm.dev/vanity-go/internal/services/upstreamsvc"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token, err := secret.FromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := requireAdmin(adminTokens{shared: token}, logger, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	call := func(auth string) int {
+		req := httptest.NewRequest("GET", "/admin/log/level", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		return rec.Code
+	}
+
+	if got := call("Bearer first"); got != http.StatusNoContent {
+		t.Errorf("valid token: status = %d, want %d", got, http.StatusNoContent)
+	}
+	if got := call("Bearer wrong"); got != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want %d", got, http.StatusUnauthorized)
+	}
+	if got := call(""); got != http.StatusUnauthorized {
+		t.Errorf("missing token: status = %d, want %d", got, http.StatusUnauthorized)
+	}
+
+	if err := os.WriteFile(path, []byte("second\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := call("Bearer first"); got != http.StatusUnauthorized {
+		t.Errorf("rotated out token: status = %d, want %d", got, http.StatusUnauthorized)
+	}
+	if got := call("Bearer second"); got != http.StatusNoContent {
+		t.Errorf("rotated in token: status = %d, want %d", got, http.StatusNoContent)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := call("Bearer second"); got != http.StatusServiceUnavailable {
+		t.Errorf("unreadable token: status = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+}
+
+func TestWithAudit(t *testing.T) {
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := withAudit(log, clientip.New(nil), logger, requireAdmin(adminTokens{shared: secret.New("t0ken")}, logger, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			audit.AddChange(r.Context(), audit.Change{Target: "log.level", Before: "INFO", After: "DEBUG"})
+		}
+	}))
+	call := func(method, auth string, cert *x509.Certificate) {
+		req := httptest.NewRequest(method, "/admin/log/level", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		if cert != nil {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		h(httptest.NewRecorder(), req)
+	}
+
+	call(http.MethodGet, "Bearer t0ken", nil)
+	call(http.MethodPut, "Bearer t0ken", nil)
+	call(http.MethodPut, "Bearer t0ken", &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}})
+	call(http.MethodGet, "Bearer wrong", nil)
+
+	events, err := log.Query(audit.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("recorded %d events, want 3 (reads are not recorded): %+v", len(events), events)
+	}
+	if e := events[0]; e.Actor != "token:shared" || e.Source != "192.0.2.1" || e.Action != "PUT /admin/log/level" || e.Status != http.StatusOK || len(e.Changes) != 1 {
+		t.Errorf("admin call event = %+v", e)
+	}
+	if e := events[1]; e.Actor != "cert:alice" {
+		t.Errorf("actor with a client certificate = %q, want its common name", e.Actor)
+	}
+	if e := events[2]; e.Actor != "" || e.Status != http.StatusUnauthorized || len(e.Changes) != 0 {
+		t.Errorf("refused call event = %+v", e)
+	}
+}
+
+func TestParseAdminTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "lines", value: "alice:a1\nbob: b2\n\n", want: map[string]string{"alice": "a1", "bob": "b2"}},
+		{name: "commas", value: "alice:a1,bob:b2", want: map[string]string{"alice": "a1", "bob": "b2"}},
+		{name: "missing token", value: "alice", wantErr: "name:token"},
+		{name: "duplicate name", value: "alice:a1\nalice:a2", wantErr: `duplicate admin "alice"`},
+		{name: "shared token", value: "alice:t\nbob:t", wantErr: "shares its token"},
+		{name: "empty", value: "\n", wantErr: "no admin tokens"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAdminTokens(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseAdminTokens() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseAdminTokens() = %v, want %v", got, tt.want)
+			}
+			for name, token := range tt.want {
+				if got[name] != token {
+					t.Errorf("parseAdminTokens()[%q] = %q, want %q", name, got[name], token)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireAdmin_Identity(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tokens := adminTokens{shared: secret.New("shared"), named: secret.New("alice:a1\nbob:b2")}
+	var identity string
+	h := requireAdmin(tokens, logger, func(w http.ResponseWriter, r *http.Request) {
+		identity = adminIdentity(r)
+	})
+
+	tests := []struct {
+		name   string
+		token  string
+		cert   string
+		want   string
+		status int
+	}{
+		{name: "named token", token: "b2", want: "bob", status: http.StatusOK},
+		{name: "named token with certificate", token: "a1", cert: "ci", want: "alice", status: http.StatusOK},
+		{name: "shared token", token: "shared", want: "token:shared", status: http.StatusOK},
+		{name: "shared token with certificate", token: "shared", cert: "ci", want: "cert:ci", status: http.StatusOK},
+		{name: "shared token with the certificate of a named admin", token: "shared", cert: "bob", want: "cert:bob", status: http.StatusOK},
+		{name: "unknown token", token: "guess", status: http.StatusUnauthorized},
+		{name: "no token", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity = ""
+			req := httptest.NewRequest("GET", "/admin/changes", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if tt.cert != "" {
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: tt.cert}}}}}
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if identity != tt.want {
+				t.Errorf("adminIdentity() = %q, want %q", identity, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireNamedAdmin(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tokens := adminTokens{shared: secret.New("shared"), named: secret.New("alice:a1")}
+	h := requireAdmin(tokens, logger, requireNamedAdmin(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name   string
+		token  string
+		cert   string
+		status int
+	}{
+		{name: "named token", token: "a1", status: http.StatusOK},
+		{name: "shared token", token: "shared", status: http.StatusForbidden},
+		{name: "shared token with certificate", token: "shared", cert: "alice", status: http.StatusForbidden},
+		{name: "no token", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/admin/changes/1/approve", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if tt.cert != "" {
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: tt.cert}}}}}
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestServer_TwoPersonApproval(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// newRoutes returns the endpoints of a server with the given admin tokens and a
+	// registered module "tools".
+	newRoutes := func(t *testing.T, shared, named *secret.Secret) http.Handler {
+		t.Helper()
+		svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+		if err := svc.Load(context.Background(), &gosvc.Config{Modules: []gosvc.ModuleConfig{{Path: "tools"}}}); err != nil {
+			t.Fatal(err)
+		}
+		admin, err := adminsvc.New(adminsvc.DefaultConfig(), svc, "", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		upstream := upstreamsvc.New(upstreamsvc.DefaultConfig(), svc, prometheus.NewRegistry(), logger)
+		cfg := &Config{AdminToken: shared, AdminTokens: named}
+		return New(cfg, svc, logger, new(slog.LevelVar), &ratelimit.Config{}, prometheus.NewRegistry(), nil, admin, upstream, nil).routes()
+	}
+	// call returns the status of the admin call method target made with token.
+	call := func(h http.Handler, method, target, token, body string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	const retarget = `{"repository": "https://git.example.com/tools"}`
+
+	t.Run("shared token with named tokens", func(t *testing.T) {
+		h := newRoutes(t, secret.New("shared"), secret.New("alice:a1\nbob:b2"))
+		steps := []struct {
+			name   string
+			method string
+			target string
+			token  string
+			want   int
+		}{
+			{name: "shared request", method: http.MethodPut, target: "/admin/modules/tools", token: "shared", want: http.StatusForbidden},
+			{name: "shared delete", method: http.MethodDelete, target: "/admin/modules/tools", token: "shared", want: http.StatusForbidden},
+			{name: "shared rollback", method: http.MethodPost, target: "/admin/versions/1/rollback", token: "shared", want: http.StatusForbidden},
+			{name: "named request", method: http.MethodPut, target: "/admin/modules/tools", token: "a1", want: http.StatusAccepted},
+			{name: "self approval", method: http.MethodPost, target: "/admin/changes/1/approve", token: "a1", want: http.StatusForbidden},
+			{name: "shared approval", method: http.MethodPost, target: "/admin/changes/1/approve", token: "shared", want: http.StatusForbidden},
+			{name: "named approval", method: http.MethodPost, target: "/admin/changes/1/approve", token: "b2", want: http.StatusOK},
+		}
+		for _, step := range steps {
+			body := ""
+			if step.method == http.MethodPut {
+				body = retarget
+			}
+			if got := call(h, step.method, step.target, step.token, body); got != step.want {
+				t.Errorf("%s: status = %d, want %d", step.name, got, step.want)
+			}
+		}
+	})
+
+	t.Run("shared token alone", func(t *testing.T) {
+		h := newRoutes(t, secret.New("shared"), nil)
+		if got := call(h, http.MethodPut, "/admin/modules/tools", "shared", retarget); got != http.StatusAccepted {
+			t.Errorf("shared request: status = %d, want %d", got, http.StatusAccepted)
+		}
+	})
+}
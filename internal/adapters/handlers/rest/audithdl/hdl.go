@@ -0,0 +1,75 @@
+package audithdl
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/audit"
+)
+
+// defaultLimit is the number of events returned when the request sets no limit.
+const defaultLimit = 100
+
+// Handler exposes the audit log so it can be queried at runtime.
+type Handler struct {
+	log    *audit.Log
+	logger *slog.Logger
+}
+
+// New creates a new Handler querying the given audit log.
+// The logger is used to record failures to read it.
+func New(log *audit.Log, logger *slog.Logger) *Handler {
+	return &Handler{
+		log:    log,
+		logger: logger,
+	}
+}
+
+// List returns the audit events selected by the query parameters, oldest first:
+// since and until (RFC 3339 times), actor, action, target and limit, which defaults
+// to the 100 most recent events and is unlimited when 0.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := audit.Query{
+		Actor:  params.Get("actor"),
+		Action: params.Get("action"),
+		Target: params.Get("target"),
+		Limit:  defaultLimit,
+	}
+
+	for name, t := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := params.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*t = parsed
+		}
+	}
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = limit
+	}
+
+	events, err := h.log.Query(q)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to query audit log", slog.String("error", err.Error()))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []audit.Event{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(events)
+}
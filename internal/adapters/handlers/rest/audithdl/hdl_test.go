@@ -0,0 +1,79 @@
+package audithdl
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/audit"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestHandler_List(t *testing.T) {
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, actor := range []string{"system", "admin", "alice"} {
+		if err := log.Record(audit.Event{Time: start.Add(time.Duration(i) * time.Minute), Actor: actor}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := New(log, discardLogger)
+
+	tests := []struct {
+		name           string
+		query          string
+		wantStatusCode int
+		wantActors     []string
+	}{
+		{name: "all", query: "", wantStatusCode: http.StatusOK, wantActors: []string{"system", "admin", "alice"}},
+		{name: "actor", query: "?actor=alice", wantStatusCode: http.StatusOK, wantActors: []string{"alice"}},
+		{name: "since", query: "?since=2026-01-02T03:05:05Z", wantStatusCode: http.StatusOK, wantActors: []string{"admin", "alice"}},
+		{name: "limit", query: "?limit=1", wantStatusCode: http.StatusOK, wantActors: []string{"alice"}},
+		{name: "nothing", query: "?actor=bob", wantStatusCode: http.StatusOK, wantActors: []string{}},
+		{name: "invalid since", query: "?since=yesterday", wantStatusCode: http.StatusBadRequest},
+		{name: "invalid limit", query: "?limit=-1", wantStatusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			h.List(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatusCode)
+			}
+			if tt.wantStatusCode != http.StatusOK {
+				return
+			}
+			var events []audit.Event
+			if err := json.NewDecoder(rr.Body).Decode(&events); err != nil {
+				t.Fatal(err)
+			}
+			actors := []string{}
+			for _, e := range events {
+				actors = append(actors, e.Actor)
+			}
+			if len(actors) != len(tt.wantActors) {
+				t.Fatalf("actors = %v, want %v", actors, tt.wantActors)
+			}
+			for i := range actors {
+				if actors[i] != tt.wantActors[i] {
+					t.Errorf("actors = %v, want %v", actors, tt.wantActors)
+					break
+				}
+			}
+		})
+	}
+}
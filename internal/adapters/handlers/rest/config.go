@@ -1,10 +1,12 @@
 package rest
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"strconv"
+	"net/netip"
 	"time"
+
+	"go.gllm.dev/vanity-go/internal/secret"
 )
 
 // Config holds the configuration for the REST server.
@@ -17,11 +19,42 @@ type Config struct {
 	WriteTimeout time.Duration
 	// IdleTimeout is the maximum amount of time to wait for the next request when keep-alives are enabled.
 	IdleTimeout time.Duration
+	// AdminToken is the bearer token shared by the admins.
+	// The admin endpoints are disabled when both it and AdminTokens are nil.
+	AdminToken *secret.Secret
+	// AdminTokens are the bearer tokens of individual admins, as "name:token" entries
+	// separated by newlines or commas. Each admin is identified by its name, which
+	// two-person approval of repository changes requires.
+	AdminTokens *secret.Secret
+	// CacheMaxAge is how long clients and shared caches may reuse a vanity page.
+	CacheMaxAge time.Duration
+	// TrustedProxies are the networks of reverse proxies whose X-Forwarded-For
+	// header is trusted to identify the client.
+	TrustedProxies []netip.Prefix
+	// TLSCertFile and TLSKeyFile are the PEM files of the server certificate and its key.
+	// The server speaks plain HTTP when they are empty. Both files are re-read when they change.
+	TLSCertFile string
+	TLSKeyFile  string
+	// ClientCAFile is the PEM file of the CAs that sign client certificates.
+	// Client certificates are not requested when it is empty. It is re-read when it changes.
+	ClientCAFile string
+	// ClientAuth is ClientAuthRequire to refuse connections without a valid client certificate,
+	// or ClientAuthOptional to verify certificates only when clients present one.
+	ClientAuth string
 }
 
+// Client certificate authentication modes.
+const (
+	// ClientAuthRequire refuses TLS connections without a client certificate signed by a client CA.
+	ClientAuthRequire = "require"
+	// ClientAuthOptional verifies client certificates when they are presented, leaving access
+	// policies to decide about clients without one.
+	ClientAuthOptional = "optional"
+)
+
 const (
 	// Default values for the server configuration.
-	// These can be overridden by environment variables.
+	// These can be overridden through the config package.
 
 	// defaultPort is the default port for the server.
 	defaultPort = 8080
@@ -31,63 +64,57 @@ const (
 	defaultWriteTimeout = 10 * time.Second
 	// defaultIdleTimeout is the default idle timeout for the server.
 	defaultIdleTimeout = 120 * time.Second
+	// defaultCacheMaxAge is the default max-age of vanity pages.
+	defaultCacheMaxAge = 5 * time.Minute
 )
 
-// LoadConfig loads the server configuration from environment variables.
-func LoadConfig() (*Config, error) {
-	cfg := &Config{}
+// DefaultConfig returns the server configuration used when nothing is overridden.
+func DefaultConfig() *Config {
+	return &Config{
+		Port:         defaultPort,
+		ReadTimeout:  defaultReadTimeout,
+		WriteTimeout: defaultWriteTimeout,
+		IdleTimeout:  defaultIdleTimeout,
+		CacheMaxAge:  defaultCacheMaxAge,
+		ClientAuth:   ClientAuthRequire,
+	}
+}
 
-	port, exists := os.LookupEnv("SERVER_PORT")
-	if exists {
-		var err error
-		cfg.Port, err = strconv.Atoi(port)
-		if err != nil {
-			return nil, fmt.Errorf("invalid SERVER_PORT: %w", err)
-		}
-	} else {
-		cfg.Port = defaultPort
+// Validate reports every invalid value of the configuration.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Port <= 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("invalid port number %d", c.Port))
 	}
 
-	readTimeout, exists := os.LookupEnv("SERVER_READ_TIMEOUT")
-	if exists {
-		var err error
-		cfg.ReadTimeout, err = time.ParseDuration(readTimeout)
-		if err != nil {
-			return nil, fmt.Errorf("invalid SERVER_READ_TIMEOUT: %w", err)
-		}
-	} else {
-		cfg.ReadTimeout = defaultReadTimeout
+	if c.ReadTimeout <= 0 || c.WriteTimeout <= 0 || c.IdleTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("timeout must be positive"))
 	}
 
-	writeTimeout, exists := os.LookupEnv("SERVER_WRITE_TIMEOUT")
-	if exists {
-		var err error
-		cfg.WriteTimeout, err = time.ParseDuration(writeTimeout)
-		if err != nil {
-			return nil, fmt.Errorf("invalid SERVER_WRITE_TIMEOUT: %w", err)
-		}
-	} else {
-		cfg.WriteTimeout = defaultWriteTimeout
+	if c.CacheMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("cache max age must not be negative"))
 	}
 
-	idleTimeout, exists := os.LookupEnv("SERVER_IDLE_TIMEOUT")
-	if exists {
-		var err error
-		cfg.IdleTimeout, err = time.ParseDuration(idleTimeout)
+	if c.AdminTokens != nil {
+		value, err := c.AdminTokens.Value()
+		if err == nil {
+			_, err = parseAdminTokens(value)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("invalid SERVER_IDLE_TIMEOUT: %w", err)
+			errs = append(errs, fmt.Errorf("invalid admin tokens: %w", err))
 		}
-	} else {
-		cfg.IdleTimeout = defaultIdleTimeout
 	}
 
-	if cfg.Port <= 0 || cfg.Port > 65535 {
-		return nil, fmt.Errorf("invalid port number")
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("TLS certificate and key files must be set together"))
 	}
-
-	if cfg.ReadTimeout <= 0 || cfg.WriteTimeout <= 0 || cfg.IdleTimeout <= 0 {
-		return nil, fmt.Errorf("timeout must be positive")
+	if c.ClientCAFile != "" && c.TLSCertFile == "" {
+		errs = append(errs, fmt.Errorf("client CA file requires a TLS certificate"))
+	}
+	if c.ClientAuth != ClientAuthRequire && c.ClientAuth != ClientAuthOptional {
+		errs = append(errs, fmt.Errorf("invalid client auth %q", c.ClientAuth))
 	}
 
-	return cfg, nil
+	return errors.Join(errs...)
 }
@@ -1,10 +1,16 @@
 package gohdl
 
 import (
+	"fmt"
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/services/docsvc"
 	"go.gllm.dev/vanity-go/internal/services/gosvc"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Handler handles HTTP requests for Go vanity imports.
@@ -12,12 +18,77 @@ import (
 // for Go's import path resolution mechanism.
 type Handler struct {
 	service *gosvc.Service
+	logger  *slog.Logger
+	// cacheControl is the Cache-Control header sent with every page.
+	// It is built once so that serving a page does not allocate.
+	cacheControl []string
+	// privateCacheControl replaces cacheControl for paths with an access policy,
+	// so that shared caches do not serve them to other clients.
+	privateCacheControl []string
+	// clients resolves the client address checked against access policies.
+	clients *clientip.Resolver
+	// fetches counts requests of the go command by the status of the requested module.
+	// The counters are looked up once, as resolving label values allocates.
+	fetches map[gosvc.Status]prometheus.Counter
+	// docs holds the documentation pages shown to browsers; nil when it is disabled.
+	docs *docsvc.Service
 }
 
+// vary is the Vary header sent with every page, which is served as HTML or JSON.
+var vary = []string{"Accept"}
+
+// deniedCacheControl and deniedVary are sent with the 404 answered to clients denied by
+// an access policy: the answer depends on the credentials of the client, so it must not
+// be stored and then served to a client that would have been allowed.
+var (
+	deniedCacheControl = []string{"private, no-store"}
+	deniedVary         = []string{"Authorization"}
+)
+
 // New creates a new Handler instance with the provided gosvc.Service.
-// The service is responsible for generating the HTML content with proper meta tags.
-func New(service *gosvc.Service) *Handler {
-	return &Handler{service: service}
+// The service is responsible for generating the HTML content with proper meta tags,
+// and the logger records failures while writing responses.
+// Responses may be cached by clients and shared caches for up to cacheMaxAge;
+// a zero cacheMaxAge requires caches to revalidate every time.
+// The fetch counters are registered in reg, and clients resolves the address of the client
+// for access policies. Browsers get the documentation pages of docs, when it is not nil.
+func New(service *gosvc.Service, logger *slog.Logger, cacheMaxAge time.Duration, reg prometheus.Registerer, clients *clientip.Resolver, docs *docsvc.Service) *Handler {
+	fetches := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vanity_fetches_total",
+		Help: "Requests of the go command, by lifecycle status of the requested module.",
+	}, []string{"status"})
+	reg.MustRegister(fetches)
+
+	h := &Handler{
+		service:             service,
+		logger:              logger,
+		cacheControl:        []string{cacheControl(cacheMaxAge, true)},
+		privateCacheControl: []string{cacheControl(cacheMaxAge, false)},
+		clients:             clients,
+		docs:                docs,
+		fetches:             make(map[gosvc.Status]prometheus.Counter, len(gosvc.Statuses)+1),
+	}
+	for _, status := range gosvc.Statuses {
+		h.fetches[status] = fetches.WithLabelValues(string(status))
+	}
+	h.fetches[""] = h.fetches[gosvc.StatusActive]
+	return h
+}
+
+// cacheControl returns the Cache-Control header value for the given max age.
+// Only public responses may be stored by shared caches.
+func cacheControl(maxAge time.Duration, public bool) string {
+	scope := "private"
+	if public {
+		scope = "public"
+	}
+	if maxAge <= 0 {
+		if public {
+			return "no-cache"
+		}
+		return scope + ", no-cache"
+	}
+	return fmt.Sprintf("%s, max-age=%d", scope, int(maxAge.Seconds()))
 }
 
 // Handle processes HTTP requests for vanity import paths.
@@ -25,11 +96,21 @@ func New(service *gosvc.Service) *Handler {
 // with the appropriate go-import and go-source meta tags.
 //
 // The handler:
-//   - Returns 404 if no module path is provided
-//   - Generates HTML with meta tags using the service
-//   - Parses and executes the HTML template
-//   - Sets proper Content-Type header
-//   - Returns 404 on template execution errors
+//   - Returns 404 to clients not allowed by the access policy of the path, before resolving it,
+//     and marks it not to be stored
+//   - Gets the page for the requested path from the service, precomputed for registered modules
+//   - Counts requests of the go command by the status of the module
+//   - Returns 404 to clients other than the go command for hidden modules
+//   - Redirects browsers requesting a renamed module to its new path when its alias asks for it;
+//     the go command still gets the page of the old path
+//   - Answers clients other than the go command accepting application/json with the JSON form
+//     of the page: the module and its versions
+//   - Answers other clients with the rendered documentation of the path, if any: a package,
+//     a directory of packages or, for the root, the index of the domain
+//   - Sets a strong ETag derived from the resolved module and the Cache-Control header
+//   - Returns 304 for GET and HEAD requests whose If-None-Match matches the ETag
+//   - Otherwise writes the HTML with meta tags and sets proper Content-Type header
+//   - Returns 500 on response write errors
 //
 // Example:
 //
@@ -37,13 +118,112 @@ func New(service *gosvc.Service) *Handler {
 //	the actual repository for "domain.com/myproject".
 func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/")
-	html := h.service.Vanity(r.Context(), path)
+	cacheControl := h.cacheControl
+	if policy := h.service.Policy(path); policy != nil {
+		allowed, err := policy.Allows(r, h.clients.Resolve(r))
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "failed to check access policy", slog.String("path", path), slog.String("error", err.Error()))
+		}
+		if !allowed {
+			// 404 rather than 403, so that unauthorized clients cannot tell what exists.
+			header := w.Header()
+			header["Cache-Control"] = deniedCacheControl
+			header["Vary"] = deniedVary
+			http.NotFound(w, r)
+			return
+		}
+		cacheControl = h.privateCacheControl
+	}
+
+	page := h.service.Page(r.Context(), path)
+
+	goGet := IsGoGet(r.URL.RawQuery)
+	if goGet {
+		if c, ok := h.fetches[page.Module.Status]; ok {
+			c.Inc()
+		}
+	} else if page.Module.Status == gosvc.StatusHidden {
+		http.NotFound(w, r)
+		return
+	}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	_, err := w.Write([]byte(html))
+	// Assigning the precomputed header values directly avoids allocating on every request.
+	header := w.Header()
+	header["Cache-Control"] = cacheControl
+	if moved, ok := page.Header["X-Module-Moved"]; ok {
+		header["X-Module-Moved"] = moved
+		if page.Redirect && !goGet {
+			http.Redirect(w, r, page.Location(path), http.StatusMovedPermanently)
+			return
+		}
+	}
+	header["Vary"] = vary
+	body, pageHeader := page.HTML, page.Header
+	if !goGet && acceptsJSON(r.Header["Accept"]) {
+		body, pageHeader = page.JSON, page.JSONHeader
+	} else if !goGet && h.docs != nil && page.Module.MovedTo == "" {
+		if doc, ok := h.docs.Page(path); ok {
+			body, pageHeader = doc.HTML, doc.Header
+		}
+	}
+	header["Etag"] = pageHeader["Etag"]
+
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && matchesETag(r.Header.Get("If-None-Match"), pageHeader.Get("Etag")) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header["Content-Type"] = pageHeader["Content-Type"]
+	_, err := w.Write(body)
 	if err != nil {
-		slog.ErrorContext(r.Context(), "failed to write template", slog.String("error", err.Error()))
+		h.logger.ErrorContext(r.Context(), "failed to write template", slog.String("error", err.Error()))
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 }
+
+// IsGoGet reports whether a raw query string holds go-get=1, as in requests of the go command.
+// Unlike url.Values, it does not allocate.
+func IsGoGet(query string) bool {
+	for query != "" {
+		var param string
+		param, query, _ = strings.Cut(query, "&")
+		if param == "go-get=1" {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsJSON reports whether Accept header values ask for application/json.
+// Quality values are not weighed: listing the type is enough, as browsers do not.
+func acceptsJSON(accept []string) bool {
+	for _, value := range accept {
+		for value != "" {
+			var mediaType string
+			mediaType, value, _ = strings.Cut(value, ",")
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.EqualFold(strings.TrimSpace(mediaType), "application/json") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesETag reports whether an If-None-Match header value matches etag.
+// As required for If-None-Match, entity tags are compared weakly, so a W/ prefix is ignored.
+func matchesETag(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for ifNoneMatch != "" {
+		var candidate string
+		candidate, ifNoneMatch, _ = strings.Cut(ifNoneMatch, ",")
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
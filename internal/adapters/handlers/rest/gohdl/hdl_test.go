@@ -1,16 +1,30 @@
 package gohdl
 
 import (
+	"context"
+	"fmt"
+	"go.gllm.dev/vanity-go/internal/access"
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/services/docsvc"
 	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
 func TestNew(t *testing.T) {
 	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
-	h := New(svc)
+	h := New(svc, discardLogger, 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
 
 	if h == nil {
 		t.Fatal("expected non-nil handler")
@@ -18,6 +32,9 @@ func TestNew(t *testing.T) {
 	if h.service == nil {
 		t.Fatal("expected non-nil service in handler")
 	}
+	if h.logger == nil {
+		t.Fatal("expected non-nil logger in handler")
+	}
 }
 
 func TestHandler_Handle(t *testing.T) {
@@ -39,7 +56,8 @@ func TestHandler_Handle(t *testing.T) {
 				`<!DOCTYPE html>`,
 			},
 			wantHeader: map[string]string{
-				"Content-Type": "text/html; charset=utf-8",
+				"Content-Type":  "text/html; charset=utf-8",
+				"Cache-Control": "public, max-age=300",
 			},
 		},
 		{
@@ -132,7 +150,7 @@ func TestHandler_Handle(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Create service and handler
 			svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
-			h := New(svc)
+			h := New(svc, discardLogger, 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
 
 			// Create request
 			req, err := http.NewRequest("GET", tt.requestPath+tt.queryParams, nil)
@@ -178,7 +196,7 @@ func TestHandler_Handle_DifferentMethods(t *testing.T) {
 	for _, method := range methods {
 		t.Run(method, func(t *testing.T) {
 			svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
-			h := New(svc)
+			h := New(svc, discardLogger, 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
 
 			req, err := http.NewRequest(method, "/package", nil)
 			if err != nil {
@@ -229,7 +247,7 @@ func TestHandler_Handle_SpecialPaths(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
-			h := New(svc)
+			h := New(svc, discardLogger, 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
 
 			req, err := http.NewRequest("GET", tt.path, nil)
 			if err != nil {
@@ -253,9 +271,464 @@ func TestHandler_Handle_SpecialPaths(t *testing.T) {
 	}
 }
 
+func TestHandler_Handle_ConditionalRequests(t *testing.T) {
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	h := New(svc, discardLogger, time.Hour, prometheus.NewRegistry(), clientip.New(nil), nil)
+
+	req := httptest.NewRequest("GET", "/mypackage", nil)
+	rr := httptest.NewRecorder()
+	h.Handle(rr, req)
+
+	etag := rr.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) || len(etag) < 3 {
+		t.Fatalf("expected a strong ETag, got %q", etag)
+	}
+	if got := rr.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("Cache-Control = %q, want %q", got, "public, max-age=3600")
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		ifNoneMatch    string
+		wantStatusCode int
+	}{
+		{
+			name:           "matching ETag",
+			method:         "GET",
+			path:           "/mypackage",
+			ifNoneMatch:    etag,
+			wantStatusCode: http.StatusNotModified,
+		},
+		{
+			name:           "matching ETag with HEAD",
+			method:         "HEAD",
+			path:           "/mypackage",
+			ifNoneMatch:    etag,
+			wantStatusCode: http.StatusNotModified,
+		},
+		{
+			name:           "matching weak ETag in list",
+			method:         "GET",
+			path:           "/mypackage",
+			ifNoneMatch:    `"other", W/` + etag,
+			wantStatusCode: http.StatusNotModified,
+		},
+		{
+			name:           "wildcard",
+			method:         "GET",
+			path:           "/mypackage",
+			ifNoneMatch:    "*",
+			wantStatusCode: http.StatusNotModified,
+		},
+		{
+			name:           "stale ETag",
+			method:         "GET",
+			path:           "/mypackage",
+			ifNoneMatch:    `"stale"`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "ETag of another module",
+			method:         "GET",
+			path:           "/otherpackage",
+			ifNoneMatch:    etag,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "unsafe method ignores If-None-Match",
+			method:         "POST",
+			path:           "/mypackage",
+			ifNoneMatch:    etag,
+			wantStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			rr := httptest.NewRecorder()
+			h.Handle(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatusCode)
+			}
+			if rr.Header().Get("ETag") == "" {
+				t.Error("handler should always set ETag")
+			}
+			if tt.wantStatusCode == http.StatusNotModified && rr.Body.Len() != 0 {
+				t.Errorf("304 response should have no body, got %q", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Handle_ETagFollowsConfig(t *testing.T) {
+	get := func(repository string) string {
+		h := New(gosvc.New("go.gllm.dev", repository), discardLogger, time.Hour, prometheus.NewRegistry(), clientip.New(nil), nil)
+		rr := httptest.NewRecorder()
+		h.Handle(rr, httptest.NewRequest("GET", "/mypackage", nil))
+		return rr.Header().Get("ETag")
+	}
+
+	if get("https://github.com/gllm-dev") != get("https://github.com/gllm-dev") {
+		t.Error("ETag should be stable for the same configuration")
+	}
+	if get("https://github.com/gllm-dev") == get("https://gitlab.com/gllm-dev") {
+		t.Error("ETag should change when the repository changes")
+	}
+}
+
+func TestHandler_Handle_NoCache(t *testing.T) {
+	h := New(gosvc.New("go.gllm.dev", "https://github.com/gllm-dev"), discardLogger, 0, prometheus.NewRegistry(), clientip.New(nil), nil)
+	rr := httptest.NewRecorder()
+	h.Handle(rr, httptest.NewRequest("GET", "/mypackage", nil))
+
+	if got := rr.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Cache-Control = %q, want %q", got, "no-cache")
+	}
+}
+
+func TestHandler_Handle_Aliases(t *testing.T) {
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &gosvc.Config{
+		Modules: []gosvc.ModuleConfig{{Path: "newname"}},
+		Aliases: []gosvc.AliasConfig{
+			{Path: "oldname", Target: "newname", Redirect: true},
+			{Path: "former", Target: "newname"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(svc, discardLogger, 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
+
+	tests := []struct {
+		name         string
+		target       string
+		wantStatus   int
+		wantLocation string
+		wantContains string
+	}{
+		{
+			name:         "browser redirected",
+			target:       "/oldname/cmd/tool",
+			wantStatus:   http.StatusMovedPermanently,
+			wantLocation: "https://go.gllm.dev/newname/cmd/tool",
+		},
+		{
+			name:         "go command served the old path",
+			target:       "/oldname/cmd/tool?go-get=1",
+			wantStatus:   http.StatusOK,
+			wantContains: `<meta name="go-import" content="go.gllm.dev/oldname git https://github.com/gllm-dev/newname">`,
+		},
+		{
+			name:         "notice without redirect",
+			target:       "/former",
+			wantStatus:   http.StatusOK,
+			wantContains: "This module has moved to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.Handle(rr, httptest.NewRequest("GET", tt.target, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("X-Module-Moved"); got != "go.gllm.dev/newname" {
+				t.Errorf("X-Module-Moved = %q, want %q", got, "go.gllm.dev/newname")
+			}
+			if got := rr.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantContains) {
+				t.Errorf("body missing %q:\n%s", tt.wantContains, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Handle_Statuses(t *testing.T) {
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &gosvc.Config{Modules: []gosvc.ModuleConfig{
+		{Path: "internal", Status: "hidden"},
+		{Path: "legacy", Status: "deprecated", Replacement: "go.gllm.dev/modern"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := prometheus.NewRegistry()
+	h := New(svc, discardLogger, 5*time.Minute, reg, clientip.New(nil), nil)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "hidden for the go command", target: "/internal/pkg?go-get=1", wantStatus: http.StatusOK, wantBody: "go.gllm.dev/internal git"},
+		{name: "hidden for browsers", target: "/internal/pkg", wantStatus: http.StatusNotFound, wantBody: "404 page not found"},
+		{name: "hidden with other parameters", target: "/internal?go-get=10&x=go-get=1", wantStatus: http.StatusNotFound},
+		{name: "deprecated", target: "/legacy?go-get=1", wantStatus: http.StatusOK, wantBody: "Deprecated: use"},
+		{name: "fallback", target: "/other?x=1&go-get=1", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.Handle(rr, httptest.NewRequest("GET", tt.target, nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q:\n%s", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]float64)
+	for _, mf := range families {
+		if mf.GetName() != "vanity_fetches_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			got[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	want := map[string]float64{"active": 1, "deprecated": 1, "archived": 0, "hidden": 1}
+	for status, n := range want {
+		if got[status] != n {
+			t.Errorf("vanity_fetches_total{status=%q} = %v, want %v", status, got[status], n)
+		}
+	}
+}
+
+func TestHandler_Handle_JSON(t *testing.T) {
+	ctx := context.Background()
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(ctx, &gosvc.Config{Modules: []gosvc.ModuleConfig{{Path: "tools"}}}); err != nil {
+		t.Fatal(err)
+	}
+	svc.SetVersions(ctx, map[string]*gosvc.Versions{"go.gllm.dev/tools": gosvc.ParseVersions("go.gllm.dev/tools", []string{"v1.0.0"})})
+	h := New(svc, discardLogger, 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
+
+	tests := []struct {
+		name            string
+		target          string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "browser", target: "/tools/cmd", accept: "text/html,application/xhtml+xml,*/*;q=0.8", wantContentType: "text/html; charset=utf-8", wantBody: "Latest version"},
+		{name: "json", target: "/tools/cmd", accept: "application/json", wantContentType: "application/json", wantBody: `"latest":"v1.0.0"`},
+		{name: "json among others", target: "/tools", accept: "text/plain, Application/JSON; q=0.9", wantContentType: "application/json", wantBody: `"import_path":"go.gllm.dev/tools"`},
+		{name: "go command", target: "/tools?go-get=1", accept: "application/json", wantContentType: "text/html; charset=utf-8", wantBody: `<meta name="go-import"`},
+		{name: "fallback", target: "/other", accept: "application/json", wantContentType: "application/json", wantBody: `"repository":"https://github.com/gllm-dev/other"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.Header.Set("Accept", tt.accept)
+			rr := httptest.NewRecorder()
+			h.Handle(rr, req)
+			if got := rr.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := rr.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q:\n%s", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+
+	// The HTML and JSON forms have their own ETag.
+	req := httptest.NewRequest("GET", "/tools", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("If-None-Match", svc.Page(ctx, "tools").Header.Get("Etag"))
+	rr := httptest.NewRecorder()
+	h.Handle(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("JSON request with the ETag of the HTML page = %d, want 200", rr.Code)
+	}
+}
+
+func TestHandler_Handle_Docs(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "tools", "cli"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tools", "cli", "cli.go"), []byte("// Package cli runs tools.\npackage cli\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(ctx, &gosvc.Config{
+		Modules: []gosvc.ModuleConfig{{Path: "tools"}},
+		Aliases: []gosvc.AliasConfig{{Path: "tools/cli", Target: "tools"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.SetAdvisories(ctx, map[string][]gosvc.Advisory{
+		"go.gllm.dev/tools": {{ID: "GO-2026-0001", URL: "https://pkg.go.dev/vuln/GO-2026-0001", Affected: []gosvc.AffectedRange{{Fixed: "v1.0.1"}}}},
+	})
+	docs := docsvc.New(&docsvc.Config{Dir: dir}, svc, discardLogger)
+	if err := docs.Generate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	h := New(svc, discardLogger, 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), docs)
+
+	tests := []struct {
+		name     string
+		target   string
+		accept   string
+		wantBody string
+	}{
+		{name: "module", target: "/tools", wantBody: `<a href="/tools/cli">go.gllm.dev/tools/cli</a>`},
+		{name: "advisory", target: "/tools", wantBody: `<li><a href="https://pkg.go.dev/vuln/GO-2026-0001">GO-2026-0001</a> (affects before v1.0.1)</li>`},
+		{name: "index", target: "/", wantBody: `<a href="/tools">go.gllm.dev/tools</a>`},
+		{name: "go command", target: "/tools?go-get=1", wantBody: `<meta name="go-import"`},
+		{name: "json", target: "/tools", accept: "application/json", wantBody: `"import_path":"go.gllm.dev/tools"`},
+		{name: "alias", target: "/tools/cli", wantBody: "This module has moved"},
+		{name: "undocumented", target: "/tools/other", wantBody: `<meta name="go-import"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.Header.Set("Accept", tt.accept)
+			rr := httptest.NewRecorder()
+			h.Handle(rr, req)
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q:\n%s", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+
+	page, _ := docs.Page("tools")
+	req := httptest.NewRequest("GET", "/tools/", nil)
+	req.Header.Set("If-None-Match", page.Header.Get("Etag"))
+	rr := httptest.NewRecorder()
+	h.Handle(rr, req)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("request with the ETag of the documentation = %d, want 304", rr.Code)
+	}
+}
+
+func TestHandler_Handle_Access(t *testing.T) {
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &gosvc.Config{
+		Modules: []gosvc.ModuleConfig{
+			{Path: "public"},
+			{Path: "secret", Repository: "https://git.internal/secret", Access: &access.Config{
+				BasicAuth: []access.BasicAuthConfig{{Username: "ci", Password: "s3cret"}},
+			}},
+		},
+		Policies: []gosvc.PolicyConfig{{Prefix: "internal", Config: access.Config{
+			Networks: []string{"10.0.0.0/8"},
+			Bearer:   []access.BearerConfig{{Token: "t0ken"}},
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(svc, discardLogger, 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
+
+	tests := []struct {
+		name       string
+		target     string
+		remoteAddr string
+		header     map[string]string
+		wantStatus int
+	}{
+		{name: "public", target: "/public?go-get=1", wantStatus: http.StatusOK},
+		{name: "module without credentials", target: "/secret/pkg?go-get=1", wantStatus: http.StatusNotFound},
+		{name: "module with wrong credentials", target: "/secret?go-get=1", header: map[string]string{"Authorization": "Basic Y2k6Z3Vlc3M="}, wantStatus: http.StatusNotFound},
+		{name: "module with netrc credentials", target: "/secret?go-get=1", header: map[string]string{"Authorization": "Basic Y2k6czNjcmV0"}, wantStatus: http.StatusOK},
+		{name: "prefix from outside", target: "/internal/anything?go-get=1", wantStatus: http.StatusNotFound},
+		{name: "prefix from allowed network", target: "/internal/anything?go-get=1", remoteAddr: "10.1.2.3:1234", wantStatus: http.StatusOK},
+		{name: "prefix with bearer token", target: "/internal/anything", header: map[string]string{"Authorization": "Bearer t0ken"}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			h.Handle(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusNotFound {
+				if strings.Contains(rr.Body.String(), "git.internal") || rr.Header().Get("Etag") != "" {
+					t.Errorf("404 response reveals the module: %v %q", rr.Header(), rr.Body.String())
+				}
+				if got := rr.Header().Get("Cache-Control"); got != "private, no-store" {
+					t.Errorf("Cache-Control = %q, want private, no-store", got)
+				}
+				if got := rr.Header().Get("Vary"); got != "Authorization" {
+					t.Errorf("Vary = %q, want Authorization", got)
+				}
+				return
+			}
+			wantCache := "public, max-age=300"
+			if tt.name != "public" {
+				wantCache = "private, max-age=300"
+			}
+			if got := rr.Header().Get("Cache-Control"); got != wantCache {
+				t.Errorf("Cache-Control = %q, want %q", got, wantCache)
+			}
+		})
+	}
+}
+
+// headerWriter is a ResponseWriter that keeps headers and discards the body,
+// so benchmarks measure the handler rather than response buffering.
+type headerWriter http.Header
+
+func (w headerWriter) Header() http.Header         { return http.Header(w) }
+func (w headerWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w headerWriter) WriteHeader(int)             {}
+
+func TestHandler_Handle_Allocations(t *testing.T) {
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(context.Background(), &gosvc.Config{Modules: []gosvc.ModuleConfig{{Path: "mypackage"}}}); err != nil {
+		t.Fatal(err)
+	}
+	h := New(svc, discardLogger, time.Hour, prometheus.NewRegistry(), clientip.New(nil), nil)
+
+	req := httptest.NewRequest("GET", "/mypackage/sub?go-get=1", nil)
+	w := headerWriter{}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		h.Handle(w, req)
+	})
+	if allocs != 0 {
+		t.Errorf("Handle() for a registered module allocated %v times, want 0", allocs)
+	}
+	if got := http.Header(w).Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want %q", got, "text/html; charset=utf-8")
+	}
+}
+
 func BenchmarkHandler_Handle(b *testing.B) {
 	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
-	h := New(svc)
+	h := New(svc, discardLogger, 5*time.Minute, prometheus.NewRegistry(), clientip.New(nil), nil)
 
 	paths := []string{
 		"/",
@@ -278,3 +751,41 @@ func BenchmarkHandler_Handle(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkHandler_Handle_Registry(b *testing.B) {
+	modules := make([]gosvc.ModuleConfig, 100000)
+	for i := range modules {
+		modules[i] = gosvc.ModuleConfig{Path: fmt.Sprintf("module%d", i)}
+	}
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(context.Background(), &gosvc.Config{Modules: modules}); err != nil {
+		b.Fatal(err)
+	}
+	h := New(svc, discardLogger, time.Hour, prometheus.NewRegistry(), clientip.New(nil), nil)
+
+	reqs := make([]*http.Request, 1024)
+	for i := range reqs {
+		reqs[i] = httptest.NewRequest("GET", fmt.Sprintf("/module%d/pkg?go-get=1", i*97), nil)
+	}
+
+	b.Run("registered_100k", func(b *testing.B) {
+		w := headerWriter{}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			h.Handle(w, reqs[i%len(reqs)])
+		}
+	})
+
+	b.Run("registered_100k_parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			w := headerWriter{}
+			i := 0
+			for pb.Next() {
+				h.Handle(w, reqs[i%len(reqs)])
+				i++
+			}
+		})
+	})
+}
@@ -3,19 +3,41 @@ package healthzhdl
 import (
 	"encoding/json"
 	"net/http"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/services/upstreamsvc"
 )
 
 // Handler is the HTTP handler for health checks
-type Handler struct{}
+type Handler struct {
+	// upstream checks the repositories of the registered modules.
+	upstream *upstreamsvc.Service
+}
 
-// New creates a new instance of the Handler for health checks
-func New() *Handler {
-	return &Handler{}
+// New creates a new instance of the Handler for health checks.
+// Readiness details include the outcome of the checks of upstream.
+func New(upstream *upstreamsvc.Service) *Handler {
+	return &Handler{upstream: upstream}
 }
 
 // Status represents the health check response structure
 type Status struct {
 	Status string `json:"status"`
+	// Checks are the details of readiness, by name; omitted by the health check.
+	Checks map[string]Check `json:"checks,omitempty"`
+}
+
+// Check is the detail of a readiness check.
+type Check struct {
+	// Status is "ok", "degraded" when problems were found, "pending" before the first
+	// round of checks completed, or "disabled".
+	Status string `json:"status"`
+	// CheckedAt is when the last round of checks started.
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	// Counts are the numbers of modules by status of their last check. The results
+	// themselves, which name the modules and their repositories, are only given to
+	// admins, as the readiness endpoint is public and modules may be restricted.
+	Counts map[upstreamsvc.Status]int `json:"counts,omitempty"`
 }
 
 // Healthz handles the health check endpoint
@@ -27,3 +49,38 @@ func (h *Handler) Healthz(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(status)
 }
+
+// Readyz handles the readiness endpoint. It reports "degraded" when the go command fails
+// to fetch modules from their repositories, but always answers 200 OK: the server still
+// answers correctly, and taking every replica out of service would fail every module.
+func (h *Handler) Readyz(w http.ResponseWriter, _ *http.Request) {
+	status := Status{
+		Status: "ok",
+		Checks: map[string]Check{"upstream": h.upstreamCheck()},
+	}
+	if status.Checks["upstream"].Status == "degraded" {
+		status.Status = "degraded"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+// upstreamCheck returns the detail of the last round of checks of the repositories.
+func (h *Handler) upstreamCheck() Check {
+	if !h.upstream.Enabled() {
+		return Check{Status: "disabled"}
+	}
+	report := h.upstream.Report()
+	if report.CheckedAt.IsZero() {
+		return Check{Status: "pending"}
+	}
+	check := Check{Status: "ok", CheckedAt: &report.CheckedAt, Counts: make(map[upstreamsvc.Status]int)}
+	for _, res := range report.Results {
+		check.Counts[res.Status]++
+		if res.Status.Problem() {
+			check.Status = "degraded"
+		}
+	}
+	return check
+}
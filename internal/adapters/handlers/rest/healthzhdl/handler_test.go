@@ -0,0 +1,130 @@
+package healthzhdl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.gllm.dev/vanity-go/internal/access"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"go.gllm.dev/vanity-go/internal/services/upstreamsvc"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newUpstream returns the checks of the repositories of modules, made every interval.
+func newUpstream(t *testing.T, interval time.Duration, modules ...gosvc.ModuleConfig) *upstreamsvc.Service {
+	t.Helper()
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(context.Background(), &gosvc.Config{Modules: modules}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := upstreamsvc.DefaultConfig()
+	cfg.CheckInterval = interval
+	return upstreamsvc.New(cfg, svc, prometheus.NewRegistry(), discardLogger)
+}
+
+// readyz returns the body of the readiness endpoint of h.
+func readyz(t *testing.T, h *Handler) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	return rec.Body.String()
+}
+
+func TestHandler_Readyz(t *testing.T) {
+	gone := httptest.NewServer(http.NotFoundHandler())
+	defer gone.Close()
+	// Subversion repositories are skipped, so nothing is fetched.
+	legacy := gosvc.ModuleConfig{Path: "legacy", VCS: "svn", Repository: "https://svn.example.com/legacy"}
+
+	tests := []struct {
+		name       string
+		interval   time.Duration
+		modules    []gosvc.ModuleConfig
+		check      bool
+		wantStatus string
+		wantCheck  string
+		wantCounts map[upstreamsvc.Status]int
+	}{
+		{name: "disabled", modules: []gosvc.ModuleConfig{legacy}, wantStatus: "ok", wantCheck: "disabled"},
+		{name: "pending", interval: time.Hour, modules: []gosvc.ModuleConfig{legacy}, wantStatus: "ok", wantCheck: "pending"},
+		{
+			name:       "ok",
+			interval:   time.Hour,
+			modules:    []gosvc.ModuleConfig{legacy},
+			check:      true,
+			wantStatus: "ok",
+			wantCheck:  "ok",
+			wantCounts: map[upstreamsvc.Status]int{upstreamsvc.StatusSkipped: 1},
+		},
+		{
+			name:       "degraded",
+			interval:   time.Hour,
+			modules:    []gosvc.ModuleConfig{legacy, {Path: "gone", Repository: gone.URL + "/gone"}},
+			check:      true,
+			wantStatus: "degraded",
+			wantCheck:  "degraded",
+			wantCounts: map[upstreamsvc.Status]int{upstreamsvc.StatusSkipped: 1, upstreamsvc.StatusUnreachable: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := newUpstream(t, tt.interval, tt.modules...)
+			if tt.check {
+				upstream.CheckAll(context.Background())
+			}
+
+			var status Status
+			if err := json.Unmarshal([]byte(readyz(t, New(upstream))), &status); err != nil {
+				t.Fatal(err)
+			}
+			check := status.Checks["upstream"]
+			if status.Status != tt.wantStatus || check.Status != tt.wantCheck {
+				t.Errorf("status = %q, upstream %q, want %q, upstream %q", status.Status, check.Status, tt.wantStatus, tt.wantCheck)
+			}
+			if (check.CheckedAt != nil) != tt.check {
+				t.Errorf("checked_at = %v, want it set: %v", check.CheckedAt, tt.check)
+			}
+			if len(check.Counts) != len(tt.wantCounts) {
+				t.Errorf("counts = %v, want %v", check.Counts, tt.wantCounts)
+			}
+			for s, n := range tt.wantCounts {
+				if check.Counts[s] != n {
+					t.Errorf("counts = %v, want %v", check.Counts, tt.wantCounts)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_Readyz_Restricted(t *testing.T) {
+	gone := httptest.NewServer(http.NotFoundHandler())
+	defer gone.Close()
+	upstream := newUpstream(t, time.Hour,
+		gosvc.ModuleConfig{Path: "secret", Repository: gone.URL + "/private-secret", Access: &access.Config{Networks: []string{"10.0.0.0/8"}}},
+		gosvc.ModuleConfig{Path: "public", Repository: gone.URL + "/public"},
+	)
+	upstream.CheckAll(context.Background())
+
+	body := readyz(t, New(upstream))
+	if !strings.Contains(body, `"degraded"`) {
+		t.Errorf("body = %s, want the upstream check degraded", body)
+	}
+	for _, leak := range []string{"secret", "public", gone.URL} {
+		if strings.Contains(body, leak) {
+			t.Errorf("body = %s, want no %q", body, leak)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package loghdl
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"go.gllm.dev/vanity-go/internal/audit"
+)
+
+// Handler exposes the logger level so it can be inspected and changed at runtime.
+type Handler struct {
+	level  *slog.LevelVar
+	logger *slog.Logger
+}
+
+// New creates a new Handler controlling the given level variable.
+// The logger is used to record level changes.
+func New(level *slog.LevelVar, logger *slog.Logger) *Handler {
+	return &Handler{
+		level:  level,
+		logger: logger,
+	}
+}
+
+// Level represents the log level request and response body.
+type Level struct {
+	Level string `json:"level"`
+}
+
+// Get returns the current log level.
+func (h *Handler) Get(w http.ResponseWriter, _ *http.Request) {
+	h.write(w, http.StatusOK)
+}
+
+// Set changes the log level to the one given in the JSON request body.
+// Level names are case-insensitive and accept offsets such as "debug+2".
+func (h *Handler) Set(w http.ResponseWriter, r *http.Request) {
+	var body Level
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(body.Level)); err != nil {
+		http.Error(w, "invalid log level", http.StatusBadRequest)
+		return
+	}
+
+	previous := h.level.Level()
+	h.level.Set(level)
+	audit.AddChange(r.Context(), audit.Change{Target: "log.level", Before: previous.String(), After: level.String()})
+	h.logger.InfoContext(r.Context(), "log level changed",
+		slog.String("from", previous.String()),
+		slog.String("to", level.String()),
+	)
+
+	h.write(w, http.StatusOK)
+}
+
+func (h *Handler) write(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(Level{Level: h.level.Level().String()})
+}
@@ -0,0 +1,90 @@
+package loghdl
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestHandler_Get(t *testing.T) {
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	h := New(level, discardLogger)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/log/level", nil)
+	rr := httptest.NewRecorder()
+	h.Get(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("handler returned wrong Content-Type: got %v want application/json", got)
+	}
+	if got, want := strings.TrimSpace(rr.Body.String()), `{"level":"WARN"}`; got != want {
+		t.Errorf("handler returned wrong body: got %v want %v", got, want)
+	}
+}
+
+func TestHandler_Set(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		wantStatusCode int
+		wantLevel      slog.Level
+	}{
+		{
+			name:           "lower case name",
+			body:           `{"level":"debug"}`,
+			wantStatusCode: http.StatusOK,
+			wantLevel:      slog.LevelDebug,
+		},
+		{
+			name:           "upper case name",
+			body:           `{"level":"ERROR"}`,
+			wantStatusCode: http.StatusOK,
+			wantLevel:      slog.LevelError,
+		},
+		{
+			name:           "name with offset",
+			body:           `{"level":"info+2"}`,
+			wantStatusCode: http.StatusOK,
+			wantLevel:      slog.LevelInfo + 2,
+		},
+		{
+			name:           "unknown level",
+			body:           `{"level":"verbose"}`,
+			wantStatusCode: http.StatusBadRequest,
+			wantLevel:      slog.LevelInfo,
+		},
+		{
+			name:           "malformed body",
+			body:           `level=debug`,
+			wantStatusCode: http.StatusBadRequest,
+			wantLevel:      slog.LevelInfo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := new(slog.LevelVar)
+			h := New(level, discardLogger)
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/log/level", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			h.Set(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatusCode)
+			}
+			if got := level.Level(); got != tt.wantLevel {
+				t.Errorf("level = %v, want %v", got, tt.wantLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.gllm.dev/vanity-go/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWithTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	tests := []struct {
+		name        string
+		traceparent string
+		status      int
+		wantTraceID string
+	}{
+		{
+			name:        "continues incoming trace",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			status:      http.StatusOK,
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:   "starts new trace",
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			h := withTracing(propagation.TraceContext{}, next)
+
+			req := httptest.NewRequest(http.MethodGet, "/mypackage", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+
+			if tt.wantTraceID != "" && span.SpanContext.TraceID().String() != tt.wantTraceID {
+				t.Errorf("trace ID = %s, want %s", span.SpanContext.TraceID(), tt.wantTraceID)
+			}
+			if !span.SpanContext.TraceID().IsValid() {
+				t.Error("expected a valid trace ID")
+			}
+
+			var status int64
+			for _, attr := range span.Attributes {
+				if attr.Key == "http.response.status_code" {
+					status = attr.Value.AsInt64()
+				}
+			}
+			if status != int64(tt.status) {
+				t.Errorf("http.response.status_code = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestWithAccessLog_TraceID(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	var buf bytes.Buffer
+	cfg := &logging.Config{Level: slog.LevelInfo, Format: logging.FormatJSON}
+	logger := logging.New(cfg, logging.NewLevel(cfg), &buf)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	h := withTracing(propagation.TraceContext{}, withAccessLog(logger, next))
+
+	req := httptest.NewRequest(http.MethodGet, "/mypackage", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line struct {
+		Msg     string `json:"msg"`
+		Path    string `json:"path"`
+		Status  int    `json:"status"`
+		Bytes   int    `json:"bytes"`
+		TraceID string `json:"trace_id"`
+		SpanID  string `json:"span_id"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("failed to decode access log line %q: %v", buf.String(), err)
+	}
+
+	if line.Msg != "request" || line.Path != "/mypackage" || line.Status != http.StatusOK || line.Bytes != 2 {
+		t.Errorf("unexpected access log line: %s", buf.String())
+	}
+	if line.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace_id = %q, want %q", line.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	}
+	if line.SpanID == "" {
+		t.Error("expected span_id in access log line")
+	}
+}
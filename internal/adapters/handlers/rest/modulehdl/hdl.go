@@ -0,0 +1,141 @@
+package modulehdl
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"go.gllm.dev/vanity-go/internal/services/adminsvc"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// maxBodySize is the size of the largest module configuration accepted.
+const maxBodySize = 1 << 20
+
+// Handler changes the registered modules through the admin API and manages
+// the changes pending approval.
+type Handler struct {
+	admin    *adminsvc.Service
+	identify func(*http.Request) string
+	logger   *slog.Logger
+}
+
+// New creates a new Handler applying changes with admin.
+// identify returns the identity of the authenticated admin making a request;
+// the logger is used to record unexpected failures.
+func New(admin *adminsvc.Service, identify func(*http.Request) string, logger *slog.Logger) *Handler {
+	return &Handler{
+		admin:    admin,
+		identify: identify,
+		logger:   logger,
+	}
+}
+
+// Result is the response to a module change: the module as registered afterwards,
+// the part of the change waiting for approval, if any, and the warnings of the
+// verification of its repository.
+type Result struct {
+	Module   *gosvc.Module    `json:"module,omitempty"`
+	Pending  *adminsvc.Change `json:"pending,omitempty"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+// Put registers or replaces the module whose path follows /admin/modules/, configured
+// by the request body in the JSON or YAML form of a module registry entry.
+// It answers 200 OK when the change applied and 202 Accepted when its repository
+// or VCS part waits for approval.
+func (h *Handler) Put(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	mc, err := gosvc.ParseModuleConfig(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	path := r.PathValue("path")
+	if mc.Path != "" && mc.Path != path {
+		http.Error(w, "module path does not match the URL", http.StatusBadRequest)
+		return
+	}
+	mc.Path = path
+
+	module, change, warnings, err := h.admin.PutModule(r.Context(), h.identify(r), mc)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	status := http.StatusOK
+	if change != nil {
+		status = http.StatusAccepted
+	}
+	h.write(w, status, Result{Module: module, Pending: change, Warnings: warnings})
+}
+
+// Delete removes the module whose path follows /admin/modules/.
+// It answers 204 No Content when the module was removed and 202 Accepted
+// when the removal waits for approval.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	change, err := h.admin.DeleteModule(r.Context(), h.identify(r), r.PathValue("path"))
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	if change == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.write(w, http.StatusAccepted, Result{Pending: change})
+}
+
+// Changes returns the changes pending approval, oldest first.
+func (h *Handler) Changes(w http.ResponseWriter, _ *http.Request) {
+	h.write(w, http.StatusOK, h.admin.Changes())
+}
+
+// Approve applies the pending change whose ID is in the path.
+func (h *Handler) Approve(w http.ResponseWriter, r *http.Request) {
+	change, err := h.admin.Approve(r.Context(), h.identify(r), r.PathValue("id"))
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, change)
+}
+
+// Cancel drops the pending change whose ID is in the path.
+func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
+	change, err := h.admin.Cancel(r.PathValue("id"))
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, change)
+}
+
+// fail answers with the status matching err.
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, adminsvc.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, adminsvc.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, adminsvc.ErrSelfApproval):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, adminsvc.ErrPending), errors.Is(err, adminsvc.ErrCoolingDown), errors.Is(err, adminsvc.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.ErrorContext(r.Context(), "Failed to change module registry", slog.String("error", err.Error()))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) write(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,127 @@
+package modulehdl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.gllm.dev/vanity-go/internal/services/adminsvc"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestHandler routes the admin endpoints to a handler over a registry, kept in memory,
+// with the module tools hosted on GitLab. Admins are identified by the X-Admin test header.
+func newTestHandler(t *testing.T) *http.ServeMux {
+	t.Helper()
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(context.Background(), &gosvc.Config{Modules: []gosvc.ModuleConfig{
+		{Path: "tools", Repository: "https://gitlab.com/gllm-dev/tools"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	admin, err := adminsvc.New(adminsvc.DefaultConfig(), svc, "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(admin, func(r *http.Request) string {
+		return r.Header.Get("X-Admin")
+	}, discardLogger)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /admin/modules/{path...}", h.Put)
+	mux.HandleFunc("DELETE /admin/modules/{path...}", h.Delete)
+	mux.HandleFunc("GET /admin/changes", h.Changes)
+	mux.HandleFunc("POST /admin/changes/{id}/approve", h.Approve)
+	mux.HandleFunc("DELETE /admin/changes/{id}", h.Cancel)
+	return mux
+}
+
+func call(mux *http.ServeMux, method, target, admin, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-Admin", admin)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestHandler_Put(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		body           string
+		wantStatusCode int
+	}{
+		{name: "new module", target: "/admin/modules/app", body: `{"status":"archived"}`, wantStatusCode: http.StatusOK},
+		{name: "nested path", target: "/admin/modules/app/v2", body: `{}`, wantStatusCode: http.StatusOK},
+		{name: "yaml body", target: "/admin/modules/app", body: "status: hidden\n", wantStatusCode: http.StatusOK},
+		{name: "repository change", target: "/admin/modules/tools", body: `{"repository":"https://github.com/other/tools"}`, wantStatusCode: http.StatusAccepted},
+		{name: "unknown field", target: "/admin/modules/app", body: `{"repo":"x"}`, wantStatusCode: http.StatusBadRequest},
+		{name: "path mismatch", target: "/admin/modules/app", body: `{"path":"other"}`, wantStatusCode: http.StatusBadRequest},
+		{name: "invalid module", target: "/admin/modules/app", body: `{"vcs":"cvs"}`, wantStatusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := newTestHandler(t)
+			rr := call(mux, http.MethodPut, tt.target, "alice", tt.body)
+			if rr.Code != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", rr.Code, tt.wantStatusCode, rr.Body)
+			}
+		})
+	}
+}
+
+func TestHandler_Approval(t *testing.T) {
+	mux := newTestHandler(t)
+
+	rr := call(mux, http.MethodPut, "/admin/modules/tools", "alice", `{"repository":"https://github.com/other/tools"}`)
+	var result Result
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Pending == nil {
+		t.Fatalf("PUT response = %+v, want a pending change", result)
+	}
+	approve := "/admin/changes/" + result.Pending.ID + "/approve"
+
+	rr = call(mux, http.MethodGet, "/admin/changes", "bob", "")
+	var changes []adminsvc.Change
+	if err := json.NewDecoder(rr.Body).Decode(&changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].RequestedBy != "alice" {
+		t.Errorf("GET /admin/changes = %+v, want the pending change", changes)
+	}
+
+	if rr := call(mux, http.MethodPost, approve, "alice", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("self-approval status = %v, want %v", rr.Code, http.StatusForbidden)
+	}
+	if rr := call(mux, http.MethodPost, approve, "bob", ""); rr.Code != http.StatusOK {
+		t.Errorf("approval status = %v, want %v: %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	if rr := call(mux, http.MethodPost, approve, "bob", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("second approval status = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+
+	rr = call(mux, http.MethodDelete, "/admin/modules/tools", "alice", "")
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("DELETE status = %v, want %v", rr.Code, http.StatusAccepted)
+	}
+	result = Result{}
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if rr := call(mux, http.MethodDelete, "/admin/changes/"+result.Pending.ID, "alice", ""); rr.Code != http.StatusOK {
+		t.Errorf("cancel status = %v, want %v", rr.Code, http.StatusOK)
+	}
+	if rr := call(mux, http.MethodDelete, "/admin/modules/missing", "alice", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("DELETE unknown module status = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
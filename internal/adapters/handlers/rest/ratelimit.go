@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/gohdl"
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/ratelimit"
+)
+
+// Client classes with separate rate limiting budgets.
+const (
+	classGoTool  = "go"
+	classBrowser = "browser"
+	classAdmin   = "admin"
+)
+
+// rateLimiter enforces per-client request budgets, one per client class.
+type rateLimiter struct {
+	resolver *clientip.Resolver
+	classes  map[string]*classLimiter
+}
+
+// classLimiter is the budget of a client class, with its request counters resolved
+// once so that checking a request does not allocate.
+type classLimiter struct {
+	limiter          *ratelimit.Limiter[netip.Addr]
+	allowed, limited prometheus.Counter
+}
+
+// newRateLimiter creates the limiters for every client class and registers
+// their configuration and state as metrics in reg.
+func newRateLimiter(cfg *ratelimit.Config, resolver *clientip.Resolver, reg prometheus.Registerer) *rateLimiter {
+	limits := map[string]ratelimit.Limit{
+		classGoTool:  cfg.GoTool,
+		classBrowser: cfg.Browser,
+		classAdmin:   cfg.Admin,
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vanity_ratelimit_requests_total",
+		Help: "Requests checked by the rate limiter, by client class and result.",
+	}, []string{"class", "result"})
+	reg.MustRegister(requests)
+
+	rl := &rateLimiter{
+		resolver: resolver,
+		classes:  make(map[string]*classLimiter, len(limits)),
+	}
+	for class, limit := range limits {
+		limiter := ratelimit.NewLimiter[netip.Addr](limit, cfg.MaxClients)
+		rl.classes[class] = &classLimiter{
+			limiter: limiter,
+			allowed: requests.WithLabelValues(class, "allowed"),
+			limited: requests.WithLabelValues(class, "limited"),
+		}
+
+		labels := prometheus.Labels{"class": class}
+		reg.MustRegister(
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name:        "vanity_ratelimit_rate",
+				Help:        "Configured sustained request rate per client, in requests per second.",
+				ConstLabels: labels,
+			}, func() float64 { return limit.Rate }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name:        "vanity_ratelimit_burst",
+				Help:        "Configured request burst per client.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(limit.Burst) }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name:        "vanity_ratelimit_clients",
+				Help:        "Clients currently tracked by the rate limiter.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(limiter.Len()) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name:        "vanity_ratelimit_evictions_total",
+				Help:        "Clients forgotten to keep the rate limiter within its capacity.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(limiter.Evictions()) }),
+		)
+	}
+
+	return rl
+}
+
+// classify returns the budget a request is charged to, or "" if it is exempt.
+// It does not allocate.
+func classify(r *http.Request) string {
+	switch {
+	case r.URL.Path == "/healthz", r.URL.Path == "/readyz":
+		return ""
+	case r.URL.Path == "/metrics", strings.HasPrefix(r.URL.Path, "/admin/"):
+		return classAdmin
+	case gohdl.IsGoGet(r.URL.RawQuery):
+		return classGoTool
+	default:
+		return classBrowser
+	}
+}
+
+// middleware rejects requests exceeding the client's budget with 429 Too Many Requests
+// and a Retry-After header telling the client when to try again. Allowed requests of
+// clients already tracked are checked without allocating.
+func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class := classify(r)
+		if class == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		c := rl.classes[class]
+		allowed, wait := c.limiter.Allow(rl.resolver.Resolve(r))
+		if !allowed {
+			c.limited.Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+
+		c.allowed.Inc()
+		next.ServeHTTP(w, r)
+	})
+}
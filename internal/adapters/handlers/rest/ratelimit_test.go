@@ -0,0 +1,173 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/ratelimit"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{target: "/healthz", want: ""},
+		{target: "/readyz", want: ""},
+		{target: "/metrics", want: classAdmin},
+		{target: "/admin/log/level", want: classAdmin},
+		{target: "/mypackage?go-get=1", want: classGoTool},
+		{target: "/mypackage", want: classBrowser},
+		{target: "/mypackage?go-get=0", want: classBrowser},
+		{target: "/mypackage?tab=doc&go-get=1", want: classGoTool},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if got := classify(req); got != tt.want {
+				t.Errorf("classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Middleware(t *testing.T) {
+	cfg := &ratelimit.Config{
+		Enabled:    true,
+		GoTool:     ratelimit.Limit{Rate: 1, Burst: 2},
+		Browser:    ratelimit.Limit{Rate: 0.5, Burst: 1},
+		Admin:      ratelimit.Limit{Rate: 1, Burst: 1},
+		MaxClients: 100,
+	}
+	reg := prometheus.NewRegistry()
+	rl := newRateLimiter(cfg, clientip.New(nil), reg)
+
+	h := rl.middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(target, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// The go tool budget allows a burst of two requests.
+	for i := 0; i < 2; i++ {
+		if rr := do("/pkg?go-get=1", "192.0.2.1:1000"); rr.Code != http.StatusOK {
+			t.Fatalf("go tool request %d: got %d, want %d", i+1, rr.Code, http.StatusOK)
+		}
+	}
+	rr := do("/pkg?go-get=1", "192.0.2.1:1001")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("go tool request beyond burst: got %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want %q", got, "1")
+	}
+
+	// Browsers have a separate budget.
+	if rr := do("/pkg", "192.0.2.1:1002"); rr.Code != http.StatusOK {
+		t.Errorf("browser request: got %d, want %d", rr.Code, http.StatusOK)
+	}
+	rr = do("/pkg", "192.0.2.1:1003")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("browser request beyond burst: got %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want %q", got, "2")
+	}
+
+	// Other clients are unaffected, and health checks are never limited.
+	if rr := do("/pkg?go-get=1", "192.0.2.2:1000"); rr.Code != http.StatusOK {
+		t.Errorf("other client: got %d, want %d", rr.Code, http.StatusOK)
+	}
+	for i := 0; i < 5; i++ {
+		if rr := do("/healthz", "192.0.2.1:1000"); rr.Code != http.StatusOK {
+			t.Errorf("health check %d: got %d, want %d", i+1, rr.Code, http.StatusOK)
+		}
+	}
+
+	expected := `
+# HELP vanity_ratelimit_requests_total Requests checked by the rate limiter, by client class and result.
+# TYPE vanity_ratelimit_requests_total counter
+vanity_ratelimit_requests_total{class="admin",result="allowed"} 0
+vanity_ratelimit_requests_total{class="admin",result="limited"} 0
+vanity_ratelimit_requests_total{class="browser",result="allowed"} 1
+vanity_ratelimit_requests_total{class="browser",result="limited"} 1
+vanity_ratelimit_requests_total{class="go",result="allowed"} 3
+vanity_ratelimit_requests_total{class="go",result="limited"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "vanity_ratelimit_requests_total"); err != nil {
+		t.Error(err)
+	}
+
+	expected = `
+# HELP vanity_ratelimit_clients Clients currently tracked by the rate limiter.
+# TYPE vanity_ratelimit_clients gauge
+vanity_ratelimit_clients{class="admin"} 0
+vanity_ratelimit_clients{class="browser"} 1
+vanity_ratelimit_clients{class="go"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "vanity_ratelimit_clients"); err != nil {
+		t.Error(err)
+	}
+}
+
+// unlimitedConfig returns a configuration whose budgets are never exhausted.
+func unlimitedConfig() *ratelimit.Config {
+	cfg := ratelimit.DefaultConfig()
+	unlimited := ratelimit.Limit{Rate: 1e9, Burst: 1e9}
+	cfg.GoTool, cfg.Browser, cfg.Admin = unlimited, unlimited, unlimited
+	return cfg
+}
+
+func TestRateLimiter_Middleware_Allocations(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	rl := newRateLimiter(unlimitedConfig(), clientip.New(proxies), prometheus.NewRegistry())
+	h := rl.middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	// Clients behind trusted proxies are resolved without allocating either.
+	req := httptest.NewRequest(http.MethodGet, "/pkg?go-get=1", nil)
+	req.RemoteAddr = "10.0.0.1:1000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7, 10.0.0.2")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if allocs := testing.AllocsPerRun(100, func() { h.ServeHTTP(rr, req) }); allocs != 0 {
+		t.Errorf("middleware behind proxies allocated %v times, want 0", allocs)
+	}
+
+	for _, target := range []string{"/pkg?go-get=1", "/pkg", "/admin/changes"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		allocs := testing.AllocsPerRun(100, func() {
+			h.ServeHTTP(rr, req)
+		})
+		if allocs != 0 {
+			t.Errorf("middleware for %s allocated %v times, want 0", target, allocs)
+		}
+	}
+}
+
+func BenchmarkRateLimiter_Middleware(b *testing.B) {
+	rl := newRateLimiter(unlimitedConfig(), clientip.New(nil), prometheus.NewRegistry())
+	h := rl.middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/pkg?go-get=1", nil)
+	rr := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(rr, req)
+	}
+}
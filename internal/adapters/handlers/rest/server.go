@@ -4,11 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/audithdl"
 	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/gohdl"
 	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/healthzhdl"
+	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/loghdl"
+	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/modulehdl"
+	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/upstreamhdl"
+	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest/versionhdl"
+	"go.gllm.dev/vanity-go/internal/audit"
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/ratelimit"
+	"go.gllm.dev/vanity-go/internal/services/adminsvc"
+	"go.gllm.dev/vanity-go/internal/services/docsvc"
 	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"go.gllm.dev/vanity-go/internal/services/upstreamsvc"
 	"log/slog"
 	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
 )
 
 // Server represents the HTTP server for serving Go vanity import paths.
@@ -19,47 +34,155 @@ type Server struct {
 	config *Config
 	// svc is the service that provides the logic for handling requests.
 	svc *gosvc.Service
+	// logger is the structured logger used by the server and its handlers.
+	logger *slog.Logger
+	// level controls the verbosity of logger and is exposed through the admin endpoints.
+	level *slog.LevelVar
+	// metrics is the registry exposed on the /metrics endpoint.
+	metrics *prometheus.Registry
+	// limiter enforces per-client request budgets; nil when rate limiting is disabled.
+	limiter *rateLimiter
+	// clients resolves client addresses for rate limiting and access policies.
+	clients *clientip.Resolver
+	// audit records the admin calls; nil when auditing is disabled.
+	audit *audit.Log
+	// admin changes the registered modules through the admin endpoints.
+	admin *adminsvc.Service
+	// upstream checks the repositories of the registered modules.
+	upstream *upstreamsvc.Service
+	// docs renders the documentation of the registered modules; nil when it is disabled.
+	docs *docsvc.Service
 }
 
 // New creates a new Server instance with the provided configuration and service.
 func New(
 	cfg *Config,
 	svc *gosvc.Service,
+	logger *slog.Logger,
+	level *slog.LevelVar,
+	rlCfg *ratelimit.Config,
+	metrics *prometheus.Registry,
+	auditLog *audit.Log,
+	admin *adminsvc.Service,
+	upstream *upstreamsvc.Service,
+	docs *docsvc.Service,
 ) *Server {
+	clients := clientip.New(cfg.TrustedProxies)
+	var limiter *rateLimiter
+	if rlCfg.Enabled {
+		limiter = newRateLimiter(rlCfg, clients, metrics)
+	}
+
 	return &Server{
-		server: new(http.Server),
-		config: cfg,
-		svc:    svc,
+		server:   new(http.Server),
+		config:   cfg,
+		svc:      svc,
+		logger:   logger,
+		level:    level,
+		metrics:  metrics,
+		limiter:  limiter,
+		clients:  clients,
+		audit:    auditLog,
+		admin:    admin,
+		upstream: upstream,
+		docs:     docs,
 	}
 }
 
 // Start starts the HTTP server and listens for incoming requests on the configured port.
 func (s *Server) Start(ctx context.Context) error {
-	goHdl := gohdl.New(s.svc)
-	hlz := healthzhdl.New()
-	mux := http.NewServeMux()
-	mux.HandleFunc("/healthz", hlz.Healthz)
-	mux.HandleFunc("/", goHdl.Handle)
-
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", s.config.Port),
-		Handler:      mux,
+		Handler:      withTracing(otel.GetTextMapPropagator(), withAccessLog(s.logger, s.routes())),
 		ReadTimeout:  s.config.ReadTimeout,
 		WriteTimeout: s.config.WriteTimeout,
 		IdleTimeout:  s.config.IdleTimeout,
+		ErrorLog:     slog.NewLogLogger(s.logger.Handler(), slog.LevelError),
 	}
 
-	slog.InfoContext(ctx, "Starting HTTP server", slog.Int("port", s.config.Port))
-	err := s.server.ListenAndServe()
+	var err error
+	if s.config.TLSCertFile != "" {
+		s.server.TLSConfig, err = newTLSConfig(s.config, s.logger)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to start HTTPS server", slog.String("error", err.Error()))
+			return err
+		}
+		s.logger.InfoContext(ctx, "Starting HTTPS server", slog.Int("port", s.config.Port), slog.Bool("client_certificates", s.config.ClientCAFile != ""))
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		s.logger.InfoContext(ctx, "Starting HTTP server", slog.Int("port", s.config.Port))
+		err = s.server.ListenAndServe()
+	}
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
-		slog.ErrorContext(ctx, "failed to start HTTP server", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "failed to start HTTP server", slog.String("error", err.Error()))
 		return err
 	}
 
-	slog.InfoContext(ctx, "HTTP server closed")
+	s.logger.InfoContext(ctx, "HTTP server closed")
 	return nil
 }
 
+// routes returns the handler of every endpoint, rate limited when enabled.
+func (s *Server) routes() http.Handler {
+	goHdl := gohdl.New(s.svc, s.logger, s.config.CacheMaxAge, s.metrics, s.clients, s.docs)
+	hlz := healthzhdl.New(s.upstream)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", hlz.Healthz)
+	mux.HandleFunc("/readyz", hlz.Readyz)
+	mux.Handle("/metrics", promhttp.HandlerFor(s.metrics, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/", goHdl.Handle)
+
+	if s.config.AdminToken != nil || s.config.AdminTokens != nil {
+		logHdl := loghdl.New(s.level, s.logger)
+		mux.HandleFunc("GET /admin/log/level", s.requireAdmin(logHdl.Get))
+		mux.HandleFunc("PUT /admin/log/level", s.requireAdmin(logHdl.Set))
+		moduleHdl := modulehdl.New(s.admin, adminIdentity, s.logger)
+		mux.HandleFunc("PUT /admin/modules/{path...}", s.requireRequester(moduleHdl.Put))
+		mux.HandleFunc("DELETE /admin/modules/{path...}", s.requireRequester(moduleHdl.Delete))
+		mux.HandleFunc("GET /admin/changes", s.requireAdmin(moduleHdl.Changes))
+		mux.HandleFunc("POST /admin/changes/{id}/approve", s.requireAdmin(requireNamedAdmin(moduleHdl.Approve)))
+		mux.HandleFunc("DELETE /admin/changes/{id}", s.requireAdmin(moduleHdl.Cancel))
+		versionHdl := versionhdl.New(s.admin, adminIdentity, s.logger)
+		mux.HandleFunc("GET /admin/versions", s.requireAdmin(versionHdl.List))
+		mux.HandleFunc("GET /admin/versions/diff", s.requireAdmin(versionHdl.Diff))
+		mux.HandleFunc("POST /admin/versions/{version}/rollback", s.requireRequester(versionHdl.Rollback))
+		upstreamHdl := upstreamhdl.New(s.upstream)
+		mux.HandleFunc("GET /admin/upstream", s.requireAdmin(upstreamHdl.Report))
+		mux.HandleFunc("POST /admin/upstream/check", s.requireAdmin(upstreamHdl.Check))
+		if s.audit != nil {
+			auditHdl := audithdl.New(s.audit, s.logger)
+			mux.HandleFunc("GET /admin/audit", s.requireAdmin(auditHdl.List))
+		}
+	}
+
+	var handler http.Handler = mux
+	if s.limiter != nil {
+		handler = s.limiter.middleware(handler)
+	}
+	return handler
+}
+
+// requireAdmin wraps the handler of an admin endpoint so that it requires an admin
+// token and, when auditing is enabled, is recorded in the audit log.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	h := requireAdmin(adminTokens{shared: s.config.AdminToken, named: s.config.AdminTokens}, s.logger, next)
+	if s.audit == nil {
+		return h
+	}
+	return withAudit(s.audit, s.clients, s.logger, h)
+}
+
+// requireRequester wraps the handler of an admin endpoint that may request changes
+// waiting for approval, like requireAdmin. Once named admin tokens are configured,
+// only they may request changes: otherwise an admin also knowing the shared token
+// could request a change with it and approve it with their own.
+func (s *Server) requireRequester(next http.HandlerFunc) http.HandlerFunc {
+	if s.config.AdminTokens == nil {
+		return s.requireAdmin(next)
+	}
+	return s.requireAdmin(requireNamedAdmin(next))
+}
+
 // Stop gracefully shuts down the HTTP server with the provided context.
 func (s *Server) Stop(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
@@ -0,0 +1,160 @@
+package rest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// tlsFiles holds the server certificate and the client CAs, re-reading their files
+// when they change so that renewed certificates and rotated CAs take effect on the
+// next handshake without a restart.
+type tlsFiles struct {
+	certFile, keyFile, clientCAFile string
+	logger                          *slog.Logger
+	// base is the configuration every handshake starts from, without certificates
+	// or client CAs.
+	base *tls.Config
+
+	mu sync.Mutex
+	// infos describe the files as they were when last read, to detect changes;
+	// a missing file has no entry.
+	infos map[string]os.FileInfo
+	// config is base with the current server certificate and client CAs.
+	config *tls.Config
+}
+
+// newTLSConfig returns the TLS configuration of the server described by cfg.
+// The files are read immediately so that invalid ones are reported at startup;
+// afterwards a file that fails to load is logged and the previous one kept.
+//
+// Handshakes get a clone of a single base configuration, offering HTTP/2, made once
+// per reload of the files. It sets no session ticket keys, so the keys of the server
+// configuration are used and sessions resume across handshakes.
+func newTLSConfig(cfg *Config, logger *slog.Logger) (*tls.Config, error) {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if cfg.ClientCAFile != "" {
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+		if cfg.ClientAuth == ClientAuthOptional {
+			base.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	files := &tlsFiles{
+		certFile:     cfg.TLSCertFile,
+		keyFile:      cfg.TLSKeyFile,
+		clientCAFile: cfg.ClientCAFile,
+		logger:       logger,
+		base:         base,
+	}
+	if err := files.reload(); err != nil {
+		return nil, err
+	}
+
+	config := base.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return files.current(), nil
+	}
+	return config, nil
+}
+
+// current returns the configuration of a handshake, reloading the files first if one changed.
+func (f *tlsFiles) current() *tls.Config {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if infos := f.stat(); f.changed(infos) {
+		if err := f.load(); err != nil {
+			f.logger.Error("Failed to reload TLS files, keeping the previous ones", slog.String("error", err.Error()))
+			// Do not retry on every handshake until the files change again.
+			f.infos = infos
+		}
+	}
+	return f.config
+}
+
+// reload reads every file.
+func (f *tlsFiles) reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load()
+}
+
+// paths returns the configured files.
+func (f *tlsFiles) paths() []string {
+	paths := []string{f.certFile, f.keyFile}
+	if f.clientCAFile != "" {
+		paths = append(paths, f.clientCAFile)
+	}
+	return paths
+}
+
+// stat describes the files that exist.
+func (f *tlsFiles) stat() map[string]os.FileInfo {
+	infos := make(map[string]os.FileInfo, 3)
+	for _, path := range f.paths() {
+		if info, err := os.Stat(path); err == nil {
+			infos[path] = info
+		}
+	}
+	return infos
+}
+
+// changed reports whether, according to their current infos, files appeared,
+// disappeared, were replaced or were modified since they were last read.
+func (f *tlsFiles) changed(infos map[string]os.FileInfo) bool {
+	for _, path := range f.paths() {
+		old, info := f.infos[path], infos[path]
+		if (old == nil) != (info == nil) {
+			return true
+		}
+		if info != nil && (!os.SameFile(old, info) || !old.ModTime().Equal(info.ModTime()) || old.Size() != info.Size()) {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads every file; on error nothing is replaced.
+func (f *tlsFiles) load() error {
+	infos := f.stat()
+	var errs []error
+	for _, path := range f.paths() {
+		if _, ok := infos[path]; !ok {
+			_, err := os.Stat(path)
+			errs = append(errs, fmt.Errorf("failed to read TLS file: %w", err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if f.clientCAFile != "" {
+		pem, err := os.ReadFile(f.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("client CA file %s holds no PEM certificate", f.clientCAFile)
+		}
+	}
+
+	config := f.base.Clone()
+	config.Certificates = []tls.Certificate{cert}
+	config.ClientCAs = clientCAs
+	f.infos, f.config = infos, config
+	return nil
+}
@@ -0,0 +1,279 @@
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate with its key, signed by parent or self-signed.
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	if isCA {
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der})
+}
+
+func (c *testCert) keyPEM(t *testing.T) []byte {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+// writeTestFile writes data to path and moves its modification time forward,
+// so that a rewrite within the timestamp resolution is still seen as a change.
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(time.Duration(len(data)) * time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startTLSServer starts a server with the TLS configuration of cfg answering 200 to every request.
+func startTLSServer(t *testing.T, cfg *Config) *httptest.Server {
+	t.Helper()
+	tlsConfig, err := newTLSConfig(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = tlsConfig
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// get requests url over TLS, trusting roots and presenting client when it is not nil.
+// The certificate is presented even when the server does not list its CA as acceptable.
+func get(url string, roots *x509.CertPool, client *testCert) error {
+	transport := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	if client != nil {
+		transport.TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert := client.tlsCertificate()
+			return &cert, nil
+		}
+	}
+	defer transport.CloseIdleConnections()
+	resp, err := (&http.Client{Transport: transport}).Get(url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestNewTLSConfig_ClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCert(t, "server CA", nil, true)
+	server := newTestCert(t, "127.0.0.1", serverCA, false)
+	clientCA := newTestCert(t, "client CA", nil, true)
+	client := newTestCert(t, "ci", clientCA, false)
+	otherCA := newTestCert(t, "other CA", nil, true)
+	other := newTestCert(t, "intruder", otherCA, false)
+
+	cfg := &Config{
+		TLSCertFile:  filepath.Join(dir, "server.pem"),
+		TLSKeyFile:   filepath.Join(dir, "server-key.pem"),
+		ClientCAFile: filepath.Join(dir, "client-ca.pem"),
+		ClientAuth:   ClientAuthRequire,
+	}
+	writeTestFile(t, cfg.TLSCertFile, server.certPEM())
+	writeTestFile(t, cfg.TLSKeyFile, server.keyPEM(t))
+	writeTestFile(t, cfg.ClientCAFile, clientCA.certPEM())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.cert)
+	srv := startTLSServer(t, cfg)
+
+	if err := get(srv.URL, roots, client); err != nil {
+		t.Errorf("client signed by the client CA: %v", err)
+	}
+	if err := get(srv.URL, roots, nil); err == nil {
+		t.Error("client without certificate should be refused")
+	}
+	if err := get(srv.URL, roots, other); err == nil {
+		t.Error("client signed by another CA should be refused")
+	}
+
+	// Rotating the CA file takes effect on the next handshake.
+	writeTestFile(t, cfg.ClientCAFile, append(clientCA.certPEM(), otherCA.certPEM()...))
+	if err := get(srv.URL, roots, other); err != nil {
+		t.Errorf("client signed by a CA added to the file: %v", err)
+	}
+
+	// An invalid CA file keeps the previous CAs.
+	writeTestFile(t, cfg.ClientCAFile, []byte("not a certificate"))
+	if err := get(srv.URL, roots, client); err != nil {
+		t.Errorf("previous CAs should be kept after an invalid update: %v", err)
+	}
+}
+
+func TestNewTLSConfig_OptionalClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCert(t, "server CA", nil, true)
+	server := newTestCert(t, "127.0.0.1", serverCA, false)
+	clientCA := newTestCert(t, "client CA", nil, true)
+	otherCA := newTestCert(t, "other CA", nil, true)
+	other := newTestCert(t, "intruder", otherCA, false)
+
+	cfg := &Config{
+		TLSCertFile:  filepath.Join(dir, "server.pem"),
+		TLSKeyFile:   filepath.Join(dir, "server-key.pem"),
+		ClientCAFile: filepath.Join(dir, "client-ca.pem"),
+		ClientAuth:   ClientAuthOptional,
+	}
+	writeTestFile(t, cfg.TLSCertFile, server.certPEM())
+	writeTestFile(t, cfg.TLSKeyFile, server.keyPEM(t))
+	writeTestFile(t, cfg.ClientCAFile, clientCA.certPEM())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.cert)
+	srv := startTLSServer(t, cfg)
+
+	if err := get(srv.URL, roots, nil); err != nil {
+		t.Errorf("client without certificate: %v", err)
+	}
+	if err := get(srv.URL, roots, other); err == nil {
+		t.Error("a presented certificate must still be signed by a client CA")
+	}
+}
+
+func TestNewTLSConfig_HTTP2AndResumption(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCert(t, "server CA", nil, true)
+	server := newTestCert(t, "127.0.0.1", serverCA, false)
+	clientCA := newTestCert(t, "client CA", nil, true)
+	client := newTestCert(t, "ci", clientCA, false)
+
+	cfg := &Config{
+		TLSCertFile:  filepath.Join(dir, "server.pem"),
+		TLSKeyFile:   filepath.Join(dir, "server-key.pem"),
+		ClientCAFile: filepath.Join(dir, "client-ca.pem"),
+		ClientAuth:   ClientAuthRequire,
+	}
+	writeTestFile(t, cfg.TLSCertFile, server.certPEM())
+	writeTestFile(t, cfg.TLSKeyFile, server.keyPEM(t))
+	writeTestFile(t, cfg.ClientCAFile, clientCA.certPEM())
+
+	tlsConfig, err := newTLSConfig(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = tlsConfig
+	srv.EnableHTTP2 = true
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.cert)
+	clientConfig := &tls.Config{
+		RootCAs:            roots,
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert := client.tlsCertificate()
+			return &cert, nil
+		},
+	}
+	for i := range 2 {
+		transport := &http.Transport{TLSClientConfig: clientConfig, ForceAttemptHTTP2: true}
+		resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		transport.CloseIdleConnections()
+		if resp.ProtoMajor != 2 {
+			t.Errorf("request %d used %s, want HTTP/2", i, resp.Proto)
+		}
+		if resumed := resp.TLS.DidResume; resumed != (i == 1) {
+			t.Errorf("request %d resumed a session: %t", i, resumed)
+		}
+	}
+}
+
+func TestNewTLSConfig_InvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "CA", nil, true)
+	server := newTestCert(t, "127.0.0.1", ca, false)
+	certFile, keyFile := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")
+	writeTestFile(t, certFile, server.certPEM())
+	writeTestFile(t, keyFile, server.keyPEM(t))
+	badCA := filepath.Join(dir, "bad-ca.pem")
+	writeTestFile(t, badCA, []byte("garbage"))
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, cfg := range []*Config{
+		{TLSCertFile: filepath.Join(dir, "missing.pem"), TLSKeyFile: keyFile},
+		{TLSCertFile: keyFile, TLSKeyFile: certFile},
+		{TLSCertFile: certFile, TLSKeyFile: keyFile, ClientCAFile: badCA},
+	} {
+		if _, err := newTLSConfig(cfg, logger); err == nil {
+			t.Errorf("newTLSConfig(%+v) should fail", cfg)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package upstreamhdl
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.gllm.dev/vanity-go/internal/services/upstreamsvc"
+)
+
+// Handler exposes the checks of the repositories of the registered modules.
+type Handler struct {
+	upstream *upstreamsvc.Service
+}
+
+// New creates a new Handler over the checks made by upstream.
+func New(upstream *upstreamsvc.Service) *Handler {
+	return &Handler{upstream: upstream}
+}
+
+// Report returns the outcome of the last round of checks, with the problems only
+// when the problems query parameter is set.
+func (h *Handler) Report(w http.ResponseWriter, r *http.Request) {
+	report := h.upstream.Report()
+	if r.URL.Query().Has("problems") {
+		report.Results = report.Problems()
+	}
+	h.write(w, report)
+}
+
+// Check checks every repository now and returns the outcome.
+func (h *Handler) Check(w http.ResponseWriter, r *http.Request) {
+	h.write(w, h.upstream.CheckAll(r.Context()))
+}
+
+func (h *Handler) write(w http.ResponseWriter, report upstreamsvc.Report) {
+	if report.Results == nil {
+		report.Results = []upstreamsvc.Result{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,62 @@
+package upstreamhdl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"go.gllm.dev/vanity-go/internal/services/upstreamsvc"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestHandler(t *testing.T) {
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	// Subversion repositories are skipped, so nothing is fetched.
+	if err := svc.Load(context.Background(), &gosvc.Config{Modules: []gosvc.ModuleConfig{
+		{Path: "legacy", VCS: "svn", Repository: "https://svn.example.com/legacy"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	h := New(upstreamsvc.New(upstreamsvc.DefaultConfig(), svc, prometheus.NewRegistry(), discardLogger))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/upstream", h.Report)
+	mux.HandleFunc("POST /admin/upstream/check", h.Check)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		wantLen     int
+		wantChecked bool
+	}{
+		{name: "before the first check", method: http.MethodGet, target: "/admin/upstream", wantLen: 0},
+		{name: "check", method: http.MethodPost, target: "/admin/upstream/check", wantLen: 1, wantChecked: true},
+		{name: "report", method: http.MethodGet, target: "/admin/upstream", wantLen: 1, wantChecked: true},
+		{name: "problems", method: http.MethodGet, target: "/admin/upstream?problems", wantLen: 0, wantChecked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			var report upstreamsvc.Report
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Results) != tt.wantLen || report.CheckedAt.IsZero() == tt.wantChecked {
+				t.Errorf("report = %+v, want %d results, checked %v", report, tt.wantLen, tt.wantChecked)
+			}
+		})
+	}
+}
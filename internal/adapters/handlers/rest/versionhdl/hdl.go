@@ -0,0 +1,108 @@
+package versionhdl
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"go.gllm.dev/vanity-go/internal/services/adminsvc"
+)
+
+// Handler exposes the versions of the module registry: their history, the import paths
+// two of them register differently, and rollbacks to a previous one.
+type Handler struct {
+	admin    *adminsvc.Service
+	identify func(*http.Request) string
+	logger   *slog.Logger
+}
+
+// New creates a new Handler over the history kept by admin.
+// identify returns the identity of the authenticated admin making a request;
+// the logger is used to record unexpected failures.
+func New(admin *adminsvc.Service, identify func(*http.Request) string, logger *slog.Logger) *Handler {
+	return &Handler{
+		admin:    admin,
+		identify: identify,
+		logger:   logger,
+	}
+}
+
+// List returns the kept versions, oldest first, without their registry.
+func (h *Handler) List(w http.ResponseWriter, _ *http.Request) {
+	h.write(w, h.admin.Versions())
+}
+
+// Diff returns the import paths registered differently by the versions given by the
+// from and to query parameters, to defaulting to the latest version.
+func (h *Handler) Diff(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	from, err := strconv.Atoi(params.Get("from"))
+	if err != nil {
+		http.Error(w, "invalid from", http.StatusBadRequest)
+		return
+	}
+	to := 0
+	if v := params.Get("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "invalid to", http.StatusBadRequest)
+			return
+		}
+	} else if versions := h.admin.Versions(); len(versions) > 0 {
+		to = versions[len(versions)-1].Number
+	}
+
+	changes, err := h.admin.Diff(r.Context(), from, to)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	if changes == nil {
+		changes = []adminsvc.PathChange{}
+	}
+	h.write(w, changes)
+}
+
+// Rollback applies the registry of the version in the path again and returns the version
+// this adds, with 202 Accepted when changes of repository or VCS wait for approval.
+func (h *Handler) Rollback(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil {
+		http.Error(w, "invalid version", http.StatusBadRequest)
+		return
+	}
+	res, err := h.admin.Rollback(r.Context(), h.identify(r), n)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	if len(res.Pending) > 0 {
+		h.writeStatus(w, http.StatusAccepted, res)
+		return
+	}
+	h.write(w, res)
+}
+
+// fail answers with the status matching err.
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, adminsvc.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, adminsvc.ErrInvalid), errors.Is(err, adminsvc.ErrPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.ErrorContext(r.Context(), "Failed to process registry versions", slog.String("error", err.Error()))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) write(w http.ResponseWriter, v any) {
+	h.writeStatus(w, http.StatusOK, v)
+}
+
+func (h *Handler) writeStatus(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
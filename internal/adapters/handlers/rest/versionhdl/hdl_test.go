@@ -0,0 +1,106 @@
+package versionhdl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.gllm.dev/vanity-go/internal/services/adminsvc"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestHandler routes the version endpoints to a handler over a registry, kept in memory,
+// whose history holds two versions: tools hosted on GitLab, then on GitHub.
+func newTestHandler(t *testing.T) *http.ServeMux {
+	t.Helper()
+	ctx := context.Background()
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(ctx, &gosvc.Config{Modules: []gosvc.ModuleConfig{
+		{Path: "tools", Repository: "https://gitlab.com/gllm-dev/tools"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	admin, err := adminsvc.New(adminsvc.DefaultConfig(), svc, "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, change, _, err := admin.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "tools"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.Approve(ctx, "bob", change.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	h := New(admin, func(*http.Request) string { return "carol" }, discardLogger)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/versions", h.List)
+	mux.HandleFunc("GET /admin/versions/diff", h.Diff)
+	mux.HandleFunc("POST /admin/versions/{version}/rollback", h.Rollback)
+	return mux
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		wantStatusCode int
+		wantLen        int
+	}{
+		{name: "list", method: http.MethodGet, target: "/admin/versions", wantStatusCode: http.StatusOK, wantLen: 2},
+		{name: "diff to latest", method: http.MethodGet, target: "/admin/versions/diff?from=1", wantStatusCode: http.StatusOK, wantLen: 1},
+		{name: "diff same version", method: http.MethodGet, target: "/admin/versions/diff?from=2&to=2", wantStatusCode: http.StatusOK, wantLen: 0},
+		{name: "diff without from", method: http.MethodGet, target: "/admin/versions/diff", wantStatusCode: http.StatusBadRequest},
+		{name: "diff unknown version", method: http.MethodGet, target: "/admin/versions/diff?from=1&to=5", wantStatusCode: http.StatusNotFound},
+		{name: "rollback", method: http.MethodPost, target: "/admin/versions/1/rollback", wantStatusCode: http.StatusAccepted},
+		{name: "rollback to latest", method: http.MethodPost, target: "/admin/versions/2/rollback", wantStatusCode: http.StatusOK},
+		{name: "rollback unknown version", method: http.MethodPost, target: "/admin/versions/5/rollback", wantStatusCode: http.StatusNotFound},
+		{name: "rollback invalid version", method: http.MethodPost, target: "/admin/versions/x/rollback", wantStatusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := newTestHandler(t)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.target, nil))
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, tt.wantStatusCode, rr.Body)
+			}
+			if tt.method != http.MethodGet || rr.Code != http.StatusOK {
+				return
+			}
+			var got []json.RawMessage
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("handler returned %d entries, want %d: %s", len(got), tt.wantLen, got)
+			}
+		})
+	}
+}
+
+func TestHandler_Rollback(t *testing.T) {
+	mux := newTestHandler(t)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/versions/1/rollback", nil))
+
+	// Moving tools back to GitLab waits for another admin, like an edit.
+	var res adminsvc.RollbackResult
+	if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusAccepted || len(res.Pending) != 1 || res.Pending[0].After.Repository != "https://gitlab.com/gllm-dev/tools" || res.Pending[0].RequestedBy != "carol" {
+		t.Errorf("rollback response = %d %+v, want the repository change pending", rr.Code, res)
+	}
+	if res.Number != 2 {
+		t.Errorf("rollback version = %d, want 2 as nothing else changed", res.Number)
+	}
+}
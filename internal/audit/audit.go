@@ -0,0 +1,231 @@
+// Package audit keeps an append-only record of who changed what on the server:
+// admin calls and module registry reloads, each with the values it changed before
+// and after. Events are written as JSON lines, one per event, so the log can be
+// shipped and searched with ordinary tools as well as queried through Log.Query.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Config holds the configuration of the audit log.
+type Config struct {
+	// File is the path of the audit log. Auditing is disabled when it is empty.
+	File string
+}
+
+// DefaultConfig returns the audit configuration used when nothing is overridden.
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+// Validate reports every invalid value of the configuration. The file must be an absolute
+// path, so that it does not depend on the directory the server is started from, and must
+// be writable, so that a wrong path is reported with the other settings instead of by Open.
+func (c *Config) Validate() error {
+	if c.File == "" {
+		return nil
+	}
+	var errs []error
+	if !filepath.IsAbs(c.File) {
+		errs = append(errs, fmt.Errorf("file %q must be an absolute path", c.File))
+	}
+	if err := writable(c.File); err != nil {
+		errs = append(errs, fmt.Errorf("file %q is not writable: %w", c.File, err))
+	}
+	return errors.Join(errs...)
+}
+
+// writable reports why the file at path cannot be appended to: an existing file is
+// opened for appending, otherwise a file is created and removed in its directory.
+func writable(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		f, err = os.CreateTemp(filepath.Dir(path), ".audit-")
+		if err == nil {
+			defer os.Remove(f.Name())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Event is an audited action.
+type Event struct {
+	// Time is when the action happened.
+	Time time.Time `json:"time"`
+	// Actor is the authenticated identity that performed the action.
+	Actor string `json:"actor"`
+	// Source is where the action came from: the client address of an admin call,
+	// or what triggered a reload (e.g., "SIGHUP").
+	Source string `json:"source"`
+	// Action names the action (e.g., "PUT /admin/log/level" or "reload").
+	Action string `json:"action"`
+	// Status is the HTTP status code an admin call was answered with.
+	Status int `json:"status,omitempty"`
+	// Error is why the action failed, if it did.
+	Error string `json:"error,omitempty"`
+	// Changes are the values the action changed.
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Change is a value changed by an action.
+type Change struct {
+	// Target names the changed value: an import path or a setting (e.g., "log.level").
+	Target string `json:"target"`
+	// Before is the value before the action; nil when it was created.
+	Before any `json:"before,omitempty"`
+	// After is the value after the action; nil when it was removed.
+	After any `json:"after,omitempty"`
+}
+
+// Log is an append-only audit log file.
+type Log struct {
+	// path is the file the log is written to.
+	path string
+
+	mu sync.Mutex
+	// file is the log opened for appending.
+	file *os.File
+}
+
+// Open opens the audit log at path for appending, creating it if needed.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{path: path, file: f}, nil
+}
+
+// Record appends e to the log and flushes it to disk. A zero Time is set to now.
+func (l *Log) Record(e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Query selects events of the log. Empty fields match every event.
+type Query struct {
+	// Since and Until bound the time of the events, inclusively.
+	Since, Until time.Time
+	// Actor, Action and Target match events of that actor, action, or changing that target.
+	Actor, Action, Target string
+	// Limit is the maximum number of events returned, the most recent ones; 0 means no limit.
+	Limit int
+}
+
+// matches reports whether e is selected by q.
+func (q Query) matches(e Event) bool {
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Time.After(q.Until) {
+		return false
+	}
+	if q.Actor != "" && e.Actor != q.Actor {
+		return false
+	}
+	if q.Action != "" && e.Action != q.Action {
+		return false
+	}
+	if q.Target != "" {
+		for _, c := range e.Changes {
+			if c.Target == q.Target {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// maxLine is the size of the longest event Query reads.
+const maxLine = 16 << 20
+
+// Query returns the events of the log selected by q, oldest first.
+func (l *Log) Query(q Query) ([]Event, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxLine)
+	for line := 1; scanner.Scan(); line++ {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("invalid audit log line %d: %w", line, err)
+		}
+		if !q.matches(e) {
+			continue
+		}
+		events = append(events, e)
+		if q.Limit > 0 && len(events) > q.Limit {
+			events = events[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return events, nil
+}
+
+// contextKey is the key of the event being recorded in a request context.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying e, so that the handlers serving a request
+// can add the changes they make to the event recorded for it.
+func NewContext(ctx context.Context, e *Event) context.Context {
+	return context.WithValue(ctx, contextKey{}, e)
+}
+
+// AddChange adds c to the event carried by ctx. It does nothing when ctx carries none,
+// which is the case when auditing is disabled.
+func AddChange(ctx context.Context, c Change) {
+	if e, ok := ctx.Value(contextKey{}).(*Event); ok {
+		e.Changes = append(e.Changes, c)
+	}
+}
+
+// SetActor sets the actor of the event carried by ctx, if any.
+func SetActor(ctx context.Context, actor string) {
+	if e, ok := ctx.Value(contextKey{}).(*Event); ok {
+		e.Actor = actor
+	}
+}
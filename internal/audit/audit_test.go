@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.log")
+	if err := os.WriteFile(existing, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{name: "disabled"},
+		{name: "new file", file: filepath.Join(dir, "audit.log")},
+		{name: "existing file", file: existing},
+		{name: "relative path", file: "audit.log", wantErr: "must be an absolute path"},
+		{name: "missing directory", file: filepath.Join(dir, "missing", "audit.log"), wantErr: "is not writable"},
+		{name: "directory", file: dir, wantErr: "is not writable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Config{File: tt.file}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Validate() left %d files in the directory, want only the existing one", len(entries))
+	}
+}
+
+func TestLog_RecordQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := []Event{
+		{Time: start, Actor: "system", Source: "SIGHUP", Action: "reload", Changes: []Change{
+			{Target: "go.gllm.dev/tools", Before: map[string]any{"repository": "https://github.com/gllm-dev/tools"}},
+		}},
+		{Time: start.Add(time.Minute), Actor: "admin", Source: "10.0.0.1", Action: "PUT /admin/log/level", Status: 200, Changes: []Change{
+			{Target: "log.level", Before: "INFO", After: "DEBUG"},
+		}},
+		{Time: start.Add(2 * time.Minute), Actor: "alice", Source: "10.0.0.2", Action: "PUT /admin/log/level", Status: 200, Changes: []Change{
+			{Target: "log.level", Before: "DEBUG", After: "INFO"},
+		}},
+	}
+	for _, e := range events {
+		if err := log.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      Query
+		wantActors []string
+	}{
+		{name: "all", query: Query{}, wantActors: []string{"system", "admin", "alice"}},
+		{name: "actor", query: Query{Actor: "alice"}, wantActors: []string{"alice"}},
+		{name: "action", query: Query{Action: "reload"}, wantActors: []string{"system"}},
+		{name: "target", query: Query{Target: "log.level"}, wantActors: []string{"admin", "alice"}},
+		{name: "since", query: Query{Since: start.Add(time.Minute)}, wantActors: []string{"admin", "alice"}},
+		{name: "until", query: Query{Until: start.Add(time.Minute)}, wantActors: []string{"system", "admin"}},
+		{name: "limit keeps the most recent", query: Query{Limit: 2}, wantActors: []string{"admin", "alice"}},
+		{name: "nothing", query: Query{Actor: "bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := log.Query(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actors []string
+			for _, e := range got {
+				actors = append(actors, e.Actor)
+			}
+			if strings.Join(actors, ",") != strings.Join(tt.wantActors, ",") {
+				t.Errorf("Query() actors = %v, want %v", actors, tt.wantActors)
+			}
+		})
+	}
+
+	got, err := log.Query(Query{Actor: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := got[0].Changes[0]; c.Before != "INFO" || c.After != "DEBUG" || got[0].Status != 200 || got[0].Source != "10.0.0.1" {
+		t.Errorf("Query() event = %+v, want the recorded one", got[0])
+	}
+}
+
+func TestLog_Record_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for _, actor := range []string{"first", "second"} {
+		log, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := log.Record(Event{Actor: actor}); err != nil {
+			t.Fatal(err)
+		}
+		if err := log.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"actor":"first"`) || !strings.Contains(lines[1], `"actor":"second"`) {
+		t.Errorf("audit log = %q, want one line per event in order", data)
+	}
+	if !strings.Contains(lines[0], `"time":"`) {
+		t.Errorf("audit log line %q has no time", lines[0])
+	}
+}
+
+func TestLog_Query_InvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte("{\"actor\":\"admin\"}\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	log, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	if _, err := log.Query(Query{}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Query() error = %v, want the invalid line reported", err)
+	}
+}
+
+func TestAddChange(t *testing.T) {
+	// Without an event in the context, changes are dropped.
+	AddChange(context.Background(), Change{Target: "log.level"})
+
+	e := &Event{}
+	ctx := NewContext(context.Background(), e)
+	AddChange(ctx, Change{Target: "log.level", Before: "INFO", After: "DEBUG"})
+	if len(e.Changes) != 1 || e.Changes[0].Target != "log.level" {
+		t.Errorf("event changes = %+v, want the added change", e.Changes)
+	}
+}
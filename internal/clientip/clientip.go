@@ -0,0 +1,110 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Resolver determines the address of the client that made a request,
+// looking through the X-Forwarded-For header set by trusted reverse proxies.
+type Resolver struct {
+	// trusted are the networks of the proxies allowed to set X-Forwarded-For.
+	trusted []netip.Prefix
+}
+
+// New creates a Resolver trusting the X-Forwarded-For header only when it is set
+// by a peer inside one of the trusted networks. With no trusted networks the
+// header is ignored and the peer address is always used.
+func New(trusted []netip.Prefix) *Resolver {
+	return &Resolver{trusted: trusted}
+}
+
+// Resolve returns the client address of r.
+//
+// The X-Forwarded-For chain is walked from right to left, skipping addresses of
+// trusted proxies, and the first untrusted address is the client. Addresses to
+// its left were supplied by the client itself and cannot be trusted.
+// It returns the zero Addr if the peer address cannot be parsed.
+func (res *Resolver) Resolve(r *http.Request) netip.Addr {
+	addr := peerAddr(r.RemoteAddr)
+	if !addr.IsValid() || !res.isTrusted(addr) {
+		return addr
+	}
+
+	// The header is walked in place, without splitting it, so resolving does not allocate.
+	hops := r.Header.Values("X-Forwarded-For")
+	for i := len(hops) - 1; i >= 0; i-- {
+		for rest, more := hops[i], true; more; {
+			part := rest
+			if j := strings.LastIndexByte(rest, ','); j >= 0 {
+				rest, part = rest[:j], rest[j+1:]
+			} else {
+				more = false
+			}
+			hop, err := netip.ParseAddr(strings.TrimSpace(part))
+			if err != nil {
+				return addr
+			}
+			addr = hop.Unmap()
+			if !res.isTrusted(addr) {
+				return addr
+			}
+		}
+	}
+
+	return addr
+}
+
+// isTrusted reports whether addr belongs to a trusted proxy network.
+func (res *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range res.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerAddr parses the host part of a "host:port" remote address.
+func peerAddr(remoteAddr string) netip.Addr {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// ParsePrefixes parses a comma-separated list of CIDRs or single addresses.
+// A single address is treated as a network containing only that host.
+func ParsePrefixes(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if strings.Contains(field, "/") {
+			prefix, err := netip.ParsePrefix(field)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q", field)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,156 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	trusted, err := ParsePrefixes("10.0.0.0/8, 192.0.2.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		trusted      bool
+		remoteAddr   string
+		forwardedFor []string
+		wantClientIP string
+	}{
+		{
+			name:         "direct client",
+			trusted:      true,
+			remoteAddr:   "203.0.113.7:51234",
+			wantClientIP: "203.0.113.7",
+		},
+		{
+			name:         "header from untrusted peer is ignored",
+			trusted:      true,
+			remoteAddr:   "203.0.113.7:51234",
+			forwardedFor: []string{"198.51.100.1"},
+			wantClientIP: "203.0.113.7",
+		},
+		{
+			name:         "header ignored without trusted proxies",
+			trusted:      false,
+			remoteAddr:   "10.0.0.2:443",
+			forwardedFor: []string{"198.51.100.1"},
+			wantClientIP: "10.0.0.2",
+		},
+		{
+			name:         "single trusted proxy",
+			trusted:      true,
+			remoteAddr:   "10.0.0.2:443",
+			forwardedFor: []string{"198.51.100.1"},
+			wantClientIP: "198.51.100.1",
+		},
+		{
+			name:         "spoofed entries left of the client are ignored",
+			trusted:      true,
+			remoteAddr:   "10.0.0.2:443",
+			forwardedFor: []string{"1.2.3.4, 198.51.100.1, 192.0.2.10"},
+			wantClientIP: "198.51.100.1",
+		},
+		{
+			name:         "multiple headers",
+			trusted:      true,
+			remoteAddr:   "10.0.0.2:443",
+			forwardedFor: []string{"1.2.3.4", "198.51.100.1", "10.1.2.3"},
+			wantClientIP: "198.51.100.1",
+		},
+		{
+			name:         "malformed hop stops the walk",
+			trusted:      true,
+			remoteAddr:   "10.0.0.2:443",
+			forwardedFor: []string{"198.51.100.1, garbage"},
+			wantClientIP: "10.0.0.2",
+		},
+		{
+			name:         "only trusted hops",
+			trusted:      true,
+			remoteAddr:   "10.0.0.2:443",
+			forwardedFor: []string{"10.0.0.3"},
+			wantClientIP: "10.0.0.3",
+		},
+		{
+			name:         "IPv6 client",
+			trusted:      true,
+			remoteAddr:   "[2001:db8::1]:443",
+			wantClientIP: "2001:db8::1",
+		},
+		{
+			name:         "IPv4-mapped IPv6 peer",
+			trusted:      true,
+			remoteAddr:   "[::ffff:10.0.0.2]:443",
+			forwardedFor: []string{"198.51.100.1"},
+			wantClientIP: "198.51.100.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := New(nil)
+			if tt.trusted {
+				res = New(trusted)
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, hop := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", hop)
+			}
+
+			if got := res.Resolve(req).String(); got != tt.wantClientIP {
+				t.Errorf("Resolve() = %v, want %v", got, tt.wantClientIP)
+			}
+		})
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+		{
+			name:  "mixed",
+			input: "10.1.2.3/8, 192.0.2.1,2001:db8::/32",
+			want:  []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::/32"},
+		},
+		{
+			name:    "invalid address",
+			input:   "10.0.0.0/8,not-an-ip",
+			wantErr: true,
+		},
+		{
+			name:    "invalid prefix length",
+			input:   "10.0.0.0/33",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrefixes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePrefixes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParsePrefixes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].String() != tt.want[i] {
+					t.Errorf("prefix %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
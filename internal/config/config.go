@@ -0,0 +1,356 @@
+// Package config assembles the configuration of vanity-go from layered sources.
+//
+// Every setting is resolved from, in increasing order of precedence:
+//
+//  1. its built-in default,
+//  2. the configuration file given by -config-file or VANITY_CONFIG_FILE,
+//  3. its environment variable,
+//  4. its command-line flag.
+//
+// All invalid values are collected and reported together.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"go.gllm.dev/vanity-go/internal/adapters/handlers/rest"
+	"go.gllm.dev/vanity-go/internal/audit"
+	"go.gllm.dev/vanity-go/internal/errjoin"
+	"go.gllm.dev/vanity-go/internal/logging"
+	"go.gllm.dev/vanity-go/internal/ratelimit"
+	"go.gllm.dev/vanity-go/internal/secret"
+	"go.gllm.dev/vanity-go/internal/services/adminsvc"
+	"go.gllm.dev/vanity-go/internal/services/docsvc"
+	"go.gllm.dev/vanity-go/internal/services/upstreamsvc"
+	"go.gllm.dev/vanity-go/internal/services/vulnsvc"
+	"go.gllm.dev/vanity-go/internal/telemetry"
+)
+
+// Config is the configuration of vanity-go.
+type Config struct {
+	// Domain is the vanity domain (e.g., "go.gllm.dev").
+	Domain string
+	// Repository is the base repository URL (e.g., "https://github.com/gllm-dev").
+	Repository string
+	// Registry is the path of the module registry file; empty when none is configured.
+	Registry string
+	// Server configures the HTTP server.
+	Server *rest.Config
+	// Log configures the application logger.
+	Log *logging.Config
+	// Tracing configures distributed tracing.
+	Tracing *telemetry.Config
+	// RateLimit configures per-client rate limiting.
+	RateLimit *ratelimit.Config
+	// Admin configures changes of the module registry made through the admin API.
+	Admin *adminsvc.Config
+	// Audit configures the audit log of admin calls and registry reloads.
+	Audit *audit.Config
+	// Upstream configures the checks of the repositories of the registered modules.
+	Upstream *upstreamsvc.Config
+	// Vuln configures the known vulnerabilities shown on the pages of the modules.
+	Vuln *vulnsvc.Config
+	// Docs configures the documentation rendered for the modules from local checkouts.
+	Docs *docsvc.Config
+
+	// values are the effective values of every setting, in declaration order.
+	values []Value
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() *Config {
+	return &Config{
+		Server:    rest.DefaultConfig(),
+		Log:       logging.DefaultConfig(),
+		Tracing:   telemetry.DefaultConfig(),
+		RateLimit: ratelimit.DefaultConfig(),
+		Admin:     adminsvc.DefaultConfig(),
+		Audit:     audit.DefaultConfig(),
+		Upstream:  upstreamsvc.DefaultConfig(),
+		Vuln:      vulnsvc.DefaultConfig(),
+		Docs:      docsvc.DefaultConfig(),
+	}
+}
+
+// Values returns the effective value of every setting and where it comes from.
+// Secret values are redacted.
+func (c *Config) Values() []Value {
+	return c.values
+}
+
+// Source is a configuration layer.
+type Source int
+
+const (
+	// SourceDefault is the built-in default.
+	SourceDefault Source = iota
+	// SourceFile is the configuration file.
+	SourceFile
+	// SourceEnv is an environment variable.
+	SourceEnv
+	// SourceFlag is a command-line flag.
+	SourceFlag
+)
+
+// String returns the name of the layer.
+func (s Source) String() string {
+	switch s {
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceFlag:
+		return "flag"
+	default:
+		return "default"
+	}
+}
+
+// Value is the effective value of a setting.
+type Value struct {
+	// Key identifies the setting; it is its dotted path in the configuration file (e.g., "server.port").
+	Key string
+	// Value is the effective value, formatted as it would be written in the configuration file.
+	Value string
+	// Source is the layer the value comes from.
+	Source Source
+	// Origin names where in the layer the value was found: the file path, the environment
+	// variable or the flag. It is empty for defaults.
+	Origin string
+}
+
+// configFileEnv is the environment variable naming the configuration file.
+const configFileEnv = "VANITY_CONFIG_FILE"
+
+// Loader loads the configuration of a command.
+type Loader struct {
+	// configFile is the value of the -config-file flag.
+	configFile string
+	// flags are the values of the registered setting flags and their aliases, keyed by flag name.
+	flags map[string]*flagValue
+	// fileFlags are the values of the registered flags naming secret files, keyed by setting key.
+	fileFlags map[string]*flagValue
+}
+
+// NewLoader registers the -config-file flag and a flag for each of the given setting keys
+// on fs, or for every setting when no key is given. Secrets are never given on the
+// command line, only the file holding them, as process arguments are visible to other users.
+// The flags must be parsed before calling Load.
+func NewLoader(fs *flag.FlagSet, keys ...string) *Loader {
+	l := &Loader{flags: make(map[string]*flagValue), fileFlags: make(map[string]*flagValue)}
+	fs.StringVar(&l.configFile, "config-file", "", "configuration file; defaults to $"+configFileEnv)
+
+	defaults := Default()
+	for _, s := range settings {
+		if len(keys) > 0 && !contains(keys, s.key) {
+			continue
+		}
+		if s.flag != "" {
+			v := &flagValue{value: s.binding.get(defaults), isBool: s.binding.isBool}
+			fs.Var(v, s.flag, s.usage)
+			l.flags[s.flag] = v
+			for _, alias := range s.flagAliases {
+				a := &flagValue{value: v.value, isBool: v.isBool}
+				fs.Var(a, alias, "deprecated: use -"+s.flag)
+				l.flags[alias] = a
+			}
+		}
+		if s.fileFlag != "" {
+			v := &flagValue{}
+			fs.Var(v, s.fileFlag, s.usage)
+			l.fileFlags[s.key] = v
+		}
+	}
+	return l
+}
+
+// Load resolves every setting from its default, the configuration file, the environment
+// looked up with lookupEnv and the parsed flags, then validates the result.
+// Every invalid value is reported, joined with errors.Join; the configuration
+// is returned even then so that it can be inspected.
+func (l *Loader) Load(lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	var errs []error
+
+	path := l.configFile
+	if path == "" {
+		path, _ = lookupEnv(configFileEnv)
+	}
+	var file map[string]string
+	if path != "" {
+		var err error
+		file, err = readFile(path)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, s := range settings {
+		v := Value{Key: s.key, Value: s.binding.get(cfg), Source: SourceDefault}
+
+		c, ok, err := l.find(s, file, path, lookupEnv)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if ok {
+			v.Source, v.Origin = c.source, c.origin
+			set, label := s.binding.set, s.key
+			if c.file {
+				set, label = s.binding.setFile, s.key+"_file"
+			}
+			if err := set(cfg, c.raw); err != nil {
+				shown := c.raw
+				if s.secret && !c.file {
+					shown = secret.Redacted
+				}
+				errs = append(errs, fmt.Errorf("invalid %s %q from %s %s: %w", label, shown, c.source, c.origin, err))
+				// Show the rejected value rather than the default that remains in effect.
+				v.Value = shown
+			} else {
+				v.Value = s.binding.get(cfg)
+			}
+		}
+
+		if s.secret && v.Value != "" {
+			v.Value = secret.Redacted
+		}
+		cfg.values = append(cfg.values, v)
+	}
+
+	errs = append(errs, cfg.validate()...)
+	return cfg, errors.Join(errs...)
+}
+
+// candidate is a value of a setting found in a layer.
+type candidate struct {
+	// raw is the value, or the path of the file holding a secret.
+	raw string
+	// file reports whether raw is the path of the file holding a secret.
+	file bool
+	// source is the layer the value was found in.
+	source Source
+	// origin is where in the layer: the file path, the environment variable or the flag.
+	origin string
+	// name is the name the setting was given under, such as "server.admin_token_file".
+	name string
+}
+
+// find returns the value of s from the layer of highest precedence that sets it.
+// A secret set both inline and as a file in the same layer is an error.
+func (l *Loader) find(s setting, file map[string]string, path string, lookupEnv func(string) (string, bool)) (candidate, bool, error) {
+	var found candidate
+	var ok bool
+	var errs []error
+	pick := func(layer []candidate) {
+		switch len(layer) {
+		case 0:
+		case 1:
+			found, ok = layer[0], true
+		default:
+			errs = append(errs, fmt.Errorf("%s and %s are mutually exclusive", layer[0].name, layer[1].name))
+		}
+	}
+
+	var layer []candidate
+	if r, exists := file[s.key]; exists {
+		layer = append(layer, candidate{raw: r, source: SourceFile, origin: path, name: s.key})
+	}
+	if r, exists := file[s.key+"_file"]; exists && s.secret {
+		layer = append(layer, candidate{raw: r, file: true, source: SourceFile, origin: path, name: s.key + "_file"})
+	}
+	pick(layer)
+
+	layer = nil
+	for _, env := range append([]string{s.env}, s.aliases...) {
+		if r, exists := lookupEnv(env); exists {
+			layer = append(layer, candidate{raw: r, source: SourceEnv, origin: env, name: env})
+			break
+		}
+	}
+	if r, exists := lookupEnv(s.env + "_FILE"); exists && s.secret {
+		layer = append(layer, candidate{raw: r, file: true, source: SourceEnv, origin: s.env + "_FILE", name: s.env + "_FILE"})
+	}
+	pick(layer)
+
+	layer = nil
+	for _, name := range append([]string{s.flag}, s.flagAliases...) {
+		if f, exists := l.flags[name]; exists && f.set {
+			layer = append(layer, candidate{raw: f.value, source: SourceFlag, origin: "-" + name, name: "-" + name})
+			break
+		}
+	}
+	if f, exists := l.fileFlags[s.key]; exists && f.set {
+		layer = append(layer, candidate{raw: f.value, file: true, source: SourceFlag, origin: "-" + s.fileFlag, name: "-" + s.fileFlag})
+	}
+	pick(layer)
+
+	return found, ok, errors.Join(errs...)
+}
+
+// validate reports every invalid value of cfg.
+func (c *Config) validate() []error {
+	var errs []error
+	if c.Domain == "" {
+		errs = append(errs, errors.New("domain is required"))
+	}
+	if c.Repository == "" {
+		errs = append(errs, errors.New("repository is required"))
+	}
+
+	sections := []struct {
+		name string
+		err  error
+	}{
+		{name: "server", err: c.Server.Validate()},
+		{name: "log", err: c.Log.Validate()},
+		{name: "tracing", err: c.Tracing.Validate()},
+		{name: "rate_limit", err: c.RateLimit.Validate()},
+		{name: "admin", err: c.Admin.Validate()},
+		{name: "audit", err: c.Audit.Validate()},
+		{name: "upstream", err: c.Upstream.Validate()},
+		{name: "vuln", err: c.Vuln.Validate()},
+		{name: "docs", err: c.Docs.Validate()},
+	}
+	for _, section := range sections {
+		for _, err := range errjoin.Split(section.err) {
+			errs = append(errs, fmt.Errorf("%s: %w", section.name, err))
+		}
+	}
+	return errs
+}
+
+// contains reports whether keys contains key.
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue is the command-line flag of a setting. It remembers whether it was set,
+// so that only explicit flags override the other layers.
+type flagValue struct {
+	value  string
+	isBool bool
+	set    bool
+}
+
+// String returns the flag value.
+func (v *flagValue) String() string {
+	return v.value
+}
+
+// Set records an explicit flag value.
+func (v *flagValue) Set(s string) error {
+	v.value, v.set = s, true
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be given without a value, as in -rate-limit.
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
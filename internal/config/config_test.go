@@ -0,0 +1,335 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/secret"
+)
+
+// env returns a lookup function backed by vars.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vanity.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// load parses args with a loader for every setting and loads the configuration.
+func load(t *testing.T, args []string, vars map[string]string) (*Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	loader := NewLoader(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return loader.Load(env(vars))
+}
+
+// lookup returns the effective value of key.
+func lookup(t *testing.T, cfg *Config, key string) Value {
+	t.Helper()
+	for _, v := range cfg.Values() {
+		if v.Key == key {
+			return v
+		}
+	}
+	t.Fatalf("no value for %s", key)
+	return Value{}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := load(t, nil, map[string]string{
+		"VANITY_DOMAIN":     "go.gllm.dev",
+		"VANITY_REPOSITORY": "https://github.com/gllm-dev",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.Port != 8080 || cfg.Server.CacheMaxAge != 5*time.Minute || cfg.Log.Format != "text" || !cfg.RateLimit.Enabled {
+		t.Errorf("unexpected defaults: %+v %+v %+v", cfg.Server, cfg.Log, cfg.RateLimit)
+	}
+	if v := lookup(t, cfg, "server.port"); v.Value != "8080" || v.Source != SourceDefault || v.Origin != "" {
+		t.Errorf("server.port = %+v", v)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, `
+domain: file.dev
+repository: https://github.com/file
+server:
+  port: 1000
+  read_timeout: 1s
+  trusted_proxies: [10.0.0.0/8, 192.168.0.1]
+log:
+  level: warn
+rate_limit:
+  go:
+    burst: 7
+`)
+	cfg, err := load(t, []string{"-config-file", path, "-port", "3000"}, map[string]string{
+		"SERVER_PORT":         "2000",
+		"SERVER_READ_TIMEOUT": "2s",
+		"LOG_LEVEL":           "debug",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key        string
+		wantValue  string
+		wantSource Source
+		wantOrigin string
+	}{
+		{key: "domain", wantValue: "file.dev", wantSource: SourceFile, wantOrigin: path},
+		{key: "server.port", wantValue: "3000", wantSource: SourceFlag, wantOrigin: "-port"},
+		{key: "server.read_timeout", wantValue: "2s", wantSource: SourceEnv, wantOrigin: "SERVER_READ_TIMEOUT"},
+		{key: "server.trusted_proxies", wantValue: "10.0.0.0/8,192.168.0.1/32", wantSource: SourceFile, wantOrigin: path},
+		{key: "log.level", wantValue: "debug", wantSource: SourceEnv, wantOrigin: "LOG_LEVEL"},
+		{key: "rate_limit.go.burst", wantValue: "7", wantSource: SourceFile, wantOrigin: path},
+		{key: "rate_limit.go.rate", wantValue: "20", wantSource: SourceDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			v := lookup(t, cfg, tt.key)
+			if v.Value != tt.wantValue || v.Source != tt.wantSource || v.Origin != tt.wantOrigin {
+				t.Errorf("got %+v, want value %q from %s %s", v, tt.wantValue, tt.wantSource, tt.wantOrigin)
+			}
+		})
+	}
+
+	if cfg.Server.Port != 3000 || cfg.Server.ReadTimeout != 2*time.Second || cfg.RateLimit.GoTool.Burst != 7 {
+		t.Errorf("typed values not applied: %+v %+v", cfg.Server, cfg.RateLimit)
+	}
+}
+
+func TestLoad_PortAlias(t *testing.T) {
+	vars := map[string]string{"VANITY_DOMAIN": "go.gllm.dev", "VANITY_REPOSITORY": "https://github.com/gllm-dev", "PORT": "9000"}
+	cfg, err := load(t, nil, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 9000 {
+		t.Errorf("PORT should set the port, got %d", cfg.Server.Port)
+	}
+
+	vars["SERVER_PORT"] = "9001"
+	cfg, err = load(t, nil, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 9001 {
+		t.Errorf("SERVER_PORT should take precedence over PORT, got %d", cfg.Server.Port)
+	}
+}
+
+func TestLoad_RegistryAliases(t *testing.T) {
+	base := map[string]string{"VANITY_DOMAIN": "go.gllm.dev", "VANITY_REPOSITORY": "https://github.com/gllm-dev"}
+	with := func(env map[string]string) map[string]string {
+		vars := maps.Clone(base)
+		maps.Copy(vars, env)
+		return vars
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		wantValue  string
+		wantOrigin string
+	}{
+		{name: "env", vars: with(map[string]string{"VANITY_REGISTRY": "new.yaml"}), wantValue: "new.yaml", wantOrigin: "VANITY_REGISTRY"},
+		{name: "deprecated env", vars: with(map[string]string{"VANITY_CONFIG": "old.yaml"}), wantValue: "old.yaml", wantOrigin: "VANITY_CONFIG"},
+		{name: "env precedence", vars: with(map[string]string{"VANITY_REGISTRY": "new.yaml", "VANITY_CONFIG": "old.yaml"}), wantValue: "new.yaml", wantOrigin: "VANITY_REGISTRY"},
+		{name: "flag", args: []string{"-registry", "new.yaml"}, vars: base, wantValue: "new.yaml", wantOrigin: "-registry"},
+		{name: "deprecated flag", args: []string{"-config", "old.yaml"}, vars: base, wantValue: "old.yaml", wantOrigin: "-config"},
+		{name: "flag precedence", args: []string{"-config", "old.yaml", "-registry", "new.yaml"}, vars: base, wantValue: "new.yaml", wantOrigin: "-registry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, tt.args, tt.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v := lookup(t, cfg, "registry"); v.Value != tt.wantValue || v.Origin != tt.wantOrigin {
+				t.Errorf("got %+v, want %q from %s", v, tt.wantValue, tt.wantOrigin)
+			}
+		})
+	}
+}
+
+func TestLoad_BoolFlag(t *testing.T) {
+	cfg, _ := load(t, []string{"-rate-limit=false"}, nil)
+	if cfg.RateLimit.Enabled {
+		t.Error("-rate-limit=false should disable rate limiting")
+	}
+	cfg, _ = load(t, []string{"-rate-limit"}, map[string]string{"RATE_LIMIT_ENABLED": "false"})
+	if !cfg.RateLimit.Enabled {
+		t.Error("-rate-limit should enable rate limiting")
+	}
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	cfg, err := load(t, []string{"-cache-max-age", "-1m"}, map[string]string{
+		"SERVER_PORT":        "http",
+		"LOG_FORMAT":         "xml",
+		"RATE_LIMIT_GO_RATE": "0",
+		"TLS_CLIENT_CA_FILE": "ca.pem",
+		"TLS_CLIENT_AUTH":    "sometimes",
+		"AUDIT_LOG_FILE":     "audit.log",
+	})
+	if err == nil {
+		t.Fatal("Load() expected error")
+	}
+	for _, want := range []string{
+		`invalid server.port "http" from env SERVER_PORT`,
+		"domain is required",
+		"repository is required",
+		"server: cache max age must not be negative",
+		`log: invalid format "xml"`,
+		"rate_limit: go rate and burst must be positive",
+		"server: client CA file requires a TLS certificate",
+		`server: invalid client auth "sometimes"`,
+		`audit: file "audit.log" must be an absolute path`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v\nwant it to contain %q", err, want)
+		}
+	}
+	if v := lookup(t, cfg, "server.port"); v.Value != "http" {
+		t.Errorf("rejected values should be shown as given, got %+v", v)
+	}
+}
+
+func TestLoad_RedactsSecrets(t *testing.T) {
+	cfg, _ := load(t, nil, map[string]string{"ADMIN_TOKEN": "s3cret"})
+	if got, _ := cfg.Server.AdminToken.Value(); got != "s3cret" {
+		t.Errorf("AdminToken = %q", got)
+	}
+	if v := lookup(t, cfg, "server.admin_token"); v.Value != secret.Redacted {
+		t.Errorf("server.admin_token = %q, want it redacted", v.Value)
+	}
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	token := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(token, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		wantOrigin string
+		wantErr    string
+	}{
+		{
+			name:       "env",
+			vars:       map[string]string{"ADMIN_TOKEN_FILE": token},
+			wantOrigin: "ADMIN_TOKEN_FILE",
+		},
+		{
+			name:       "flag",
+			args:       []string{"-admin-token-file", token},
+			vars:       map[string]string{"ADMIN_TOKEN": "inline"},
+			wantOrigin: "-admin-token-file",
+		},
+		{
+			name:       "file",
+			vars:       map[string]string{"VANITY_CONFIG_FILE": writeFile(t, "server:\n  admin_token_file: "+token+"\n")},
+			wantOrigin: "",
+		},
+		{
+			name:    "both in one layer",
+			vars:    map[string]string{"ADMIN_TOKEN": "inline", "ADMIN_TOKEN_FILE": token},
+			wantErr: "ADMIN_TOKEN and ADMIN_TOKEN_FILE are mutually exclusive",
+		},
+		{
+			name:    "missing file",
+			vars:    map[string]string{"ADMIN_TOKEN_FILE": token + ".missing"},
+			wantErr: "invalid server.admin_token_file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, tt.args, tt.vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if got, err := cfg.Server.AdminToken.Value(); err != nil || got != "from-file" {
+				t.Fatalf("AdminToken = %q, %v; want the file content", got, err)
+			}
+			v := lookup(t, cfg, "server.admin_token")
+			if v.Value != secret.Redacted || (tt.wantOrigin != "" && v.Origin != tt.wantOrigin) {
+				t.Errorf("server.admin_token = %+v, want it redacted from %s", v, tt.wantOrigin)
+			}
+		})
+	}
+}
+
+func TestLoad_SecretNotInErrors(t *testing.T) {
+	_, err := load(t, nil, map[string]string{"ADMIN_TOKEN": "s3cret", "ADMIN_TOKEN_FILE": "/nonexistent"})
+	if err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("Load() error = %v, want an error without the secret", err)
+	}
+}
+
+func TestLoad_File(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown setting", content: "server:\n  prot: 80\n", wantErr: "unknown settings server.prot"},
+		{name: "malformed", content: "server: [\n", wantErr: "failed to parse configuration file"},
+		{name: "nested list", content: "server:\n  trusted_proxies:\n    - cidr: 10.0.0.0/8\n", wantErr: "list items must be values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(t, nil, map[string]string{"VANITY_CONFIG_FILE": writeFile(t, tt.content)})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := load(t, nil, map[string]string{"VANITY_CONFIG_FILE": filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("Load() should fail for a missing configuration file")
+	}
+}
+
+func TestNewLoader_Keys(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	NewLoader(fs, "domain", "registry")
+	for _, name := range []string{"config-file", "domain", "config"} {
+		if fs.Lookup(name) == nil {
+			t.Errorf("flag -%s should be registered", name)
+		}
+	}
+	if fs.Lookup("port") != nil {
+		t.Error("flag -port should not be registered")
+	}
+}
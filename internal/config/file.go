@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads a YAML configuration file and flattens it into setting keys.
+// Nested mappings become dotted keys and lists become comma-separated values,
+// so the file uses the same text representation as the environment.
+//
+// Example:
+//
+//	domain: go.gllm.dev
+//	repository: https://github.com/gllm-dev
+//	server:
+//	  port: 8080
+//	  trusted_proxies: [10.0.0.0/8]
+//	rate_limit:
+//	  go:
+//	    rate: 20
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", doc, values); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	var unknown []string
+	for key := range values {
+		if !known(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("invalid configuration file %s: unknown settings %s", path, strings.Join(unknown, ", "))
+	}
+
+	return values, nil
+}
+
+// flatten adds the scalar values of m to values under their dotted keys, prefixed with prefix.
+func flatten(prefix string, m map[string]any, values map[string]string) error {
+	for k, v := range m {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flatten(key+".", v, values); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				if _, ok := item.(map[string]any); ok {
+					return fmt.Errorf("%s: list items must be values", key)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// known reports whether key is a setting.
+func known(key string) bool {
+	for _, s := range settings {
+		if s.key == key || (s.secret && s.key+"_file" == key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"log/slog"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/clientip"
+	"go.gllm.dev/vanity-go/internal/secret"
+	"go.gllm.dev/vanity-go/internal/telemetry"
+)
+
+// setting declares a configuration value and how each layer names it.
+type setting struct {
+	// key is the dotted path of the setting in the configuration file.
+	key string
+	// env is the environment variable of the setting.
+	env string
+	// aliases are older environment variables still honoured, after env.
+	aliases []string
+	// flag is the command-line flag of the setting, without dash; empty for none.
+	flag string
+	// flagAliases are older command-line flags still honoured, after flag.
+	flagAliases []string
+	// usage describes the setting in the flag help.
+	usage string
+	// secret hides the value from Config.Values. Secrets can also be read from a file
+	// named by the <key>_file file key, the <env>_FILE environment variable or fileFlag.
+	secret bool
+	// fileFlag is the command-line flag naming the file of a secret; empty for none.
+	fileFlag string
+	// binding parses and formats the value.
+	binding binding
+}
+
+// binding connects a setting to its field in Config.
+type binding struct {
+	// set parses s into the field.
+	set func(c *Config, s string) error
+	// get formats the field.
+	get func(c *Config) string
+	// setFile reads the field from the file at path; only secrets have it.
+	setFile func(c *Config, path string) error
+	// isBool marks boolean settings, whose flag needs no value.
+	isBool bool
+}
+
+// settings are all the configuration settings, in the order they are listed by Config.Values.
+var settings = []setting{
+	{key: "domain", env: "VANITY_DOMAIN", flag: "domain", usage: "vanity domain", binding: stringValue(func(c *Config) *string { return &c.Domain })},
+	{key: "repository", env: "VANITY_REPOSITORY", flag: "repository", usage: "base repository URL", binding: stringValue(func(c *Config) *string { return &c.Repository })},
+	{key: "registry", env: "VANITY_REGISTRY", aliases: []string{"VANITY_CONFIG"}, flag: "registry", flagAliases: []string{"config"}, usage: "module registry file", binding: stringValue(func(c *Config) *string { return &c.Registry })},
+
+	{key: "server.port", env: "SERVER_PORT", aliases: []string{"PORT"}, flag: "port", usage: "port the server listens on", binding: intValue(func(c *Config) *int { return &c.Server.Port })},
+	{key: "server.read_timeout", env: "SERVER_READ_TIMEOUT", flag: "read-timeout", usage: "maximum duration for reading a request", binding: durationValue(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{key: "server.write_timeout", env: "SERVER_WRITE_TIMEOUT", flag: "write-timeout", usage: "maximum duration for writing a response", binding: durationValue(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{key: "server.idle_timeout", env: "SERVER_IDLE_TIMEOUT", flag: "idle-timeout", usage: "maximum duration to wait for the next request on a keep-alive connection", binding: durationValue(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{key: "server.cache_max_age", env: "CACHE_MAX_AGE", flag: "cache-max-age", usage: "how long caches may reuse a vanity page, 0 to always revalidate", binding: durationValue(func(c *Config) *time.Duration { return &c.Server.CacheMaxAge })},
+	{key: "server.admin_token", env: "ADMIN_TOKEN", secret: true, fileFlag: "admin-token-file", usage: "file holding the bearer token enabling the admin endpoints", binding: secretValue(func(c *Config) **secret.Secret { return &c.Server.AdminToken })},
+	{key: "server.admin_tokens", env: "ADMIN_TOKENS", secret: true, fileFlag: "admin-tokens-file", usage: "file holding name:token lines, one bearer token per admin", binding: secretValue(func(c *Config) **secret.Secret { return &c.Server.AdminTokens })},
+	{key: "server.tls_cert_file", env: "TLS_CERT_FILE", flag: "tls-cert-file", usage: "PEM file of the server certificate, enabling HTTPS", binding: stringValue(func(c *Config) *string { return &c.Server.TLSCertFile })},
+	{key: "server.tls_key_file", env: "TLS_KEY_FILE", flag: "tls-key-file", usage: "PEM file of the server certificate key", binding: stringValue(func(c *Config) *string { return &c.Server.TLSKeyFile })},
+	{key: "server.tls_client_ca_file", env: "TLS_CLIENT_CA_FILE", flag: "tls-client-ca-file", usage: "PEM file of the CAs signing client certificates, enabling mutual TLS", binding: stringValue(func(c *Config) *string { return &c.Server.ClientCAFile })},
+	{key: "server.tls_client_auth", env: "TLS_CLIENT_AUTH", flag: "tls-client-auth", usage: "client certificates: require or optional", binding: lowerValue(func(c *Config) *string { return &c.Server.ClientAuth })},
+	{key: "server.trusted_proxies", env: "TRUSTED_PROXIES", flag: "trusted-proxies", usage: "comma-separated CIDRs of reverse proxies whose X-Forwarded-For is trusted", binding: prefixesValue(func(c *Config) *[]netip.Prefix { return &c.Server.TrustedProxies })},
+
+	{key: "log.level", env: "LOG_LEVEL", flag: "log-level", usage: "minimum log level: debug, info, warn or error", binding: levelValue(func(c *Config) *slog.Level { return &c.Log.Level })},
+	{key: "log.format", env: "LOG_FORMAT", flag: "log-format", usage: "log output format: text or json", binding: lowerValue(func(c *Config) *string { return &c.Log.Format })},
+
+	{key: "tracing.exporter", env: "OTEL_TRACES_EXPORTER", flag: "tracing-exporter", usage: "trace exporter: otlp, stdout or none", binding: exporterValue(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{key: "tracing.endpoint", env: "OTEL_EXPORTER_OTLP_ENDPOINT", flag: "tracing-endpoint", usage: "OTLP/HTTP collector URL", binding: stringValue(func(c *Config) *string { return &c.Tracing.Endpoint })},
+	{key: "tracing.service_name", env: "OTEL_SERVICE_NAME", flag: "tracing-service-name", usage: "service name reported in spans", binding: stringValue(func(c *Config) *string { return &c.Tracing.ServiceName })},
+
+	{key: "rate_limit.enabled", env: "RATE_LIMIT_ENABLED", flag: "rate-limit", usage: "enable per-client rate limiting", binding: boolValue(func(c *Config) *bool { return &c.RateLimit.Enabled })},
+	{key: "rate_limit.go.rate", env: "RATE_LIMIT_GO_RATE", flag: "rate-limit-go-rate", usage: "requests per second for the go tool", binding: floatValue(func(c *Config) *float64 { return &c.RateLimit.GoTool.Rate })},
+	{key: "rate_limit.go.burst", env: "RATE_LIMIT_GO_BURST", flag: "rate-limit-go-burst", usage: "burst for the go tool", binding: intValue(func(c *Config) *int { return &c.RateLimit.GoTool.Burst })},
+	{key: "rate_limit.browser.rate", env: "RATE_LIMIT_BROWSER_RATE", flag: "rate-limit-browser-rate", usage: "requests per second for other clients", binding: floatValue(func(c *Config) *float64 { return &c.RateLimit.Browser.Rate })},
+	{key: "rate_limit.browser.burst", env: "RATE_LIMIT_BROWSER_BURST", flag: "rate-limit-browser-burst", usage: "burst for other clients", binding: intValue(func(c *Config) *int { return &c.RateLimit.Browser.Burst })},
+	{key: "rate_limit.admin.rate", env: "RATE_LIMIT_ADMIN_RATE", flag: "rate-limit-admin-rate", usage: "requests per second for the admin and metrics endpoints", binding: floatValue(func(c *Config) *float64 { return &c.RateLimit.Admin.Rate })},
+	{key: "rate_limit.admin.burst", env: "RATE_LIMIT_ADMIN_BURST", flag: "rate-limit-admin-burst", usage: "burst for the admin and metrics endpoints", binding: intValue(func(c *Config) *int { return &c.RateLimit.Admin.Burst })},
+	{key: "rate_limit.max_clients", env: "RATE_LIMIT_MAX_CLIENTS", flag: "rate-limit-max-clients", usage: "maximum number of clients tracked per budget", binding: intValue(func(c *Config) *int { return &c.RateLimit.MaxClients })},
+
+	{key: "admin.approval_cooldown", env: "ADMIN_APPROVAL_COOLDOWN", flag: "admin-approval-cooldown", usage: "how long repository changes wait before they can be approved", binding: durationValue(func(c *Config) *time.Duration { return &c.Admin.ApprovalCooldown })},
+	{key: "admin.history_file", env: "ADMIN_HISTORY_FILE", flag: "admin-history-file", usage: "file the versions of the module registry are kept in", binding: stringValue(func(c *Config) *string { return &c.Admin.HistoryFile })},
+	{key: "admin.history_retention", env: "ADMIN_HISTORY_RETENTION", flag: "admin-history-retention", usage: "number of module registry versions kept", binding: intValue(func(c *Config) *int { return &c.Admin.HistoryRetention })},
+	{key: "audit.file", env: "AUDIT_LOG_FILE", flag: "audit-log-file", usage: "file the audit log of admin calls and registry reloads is appended to", binding: stringValue(func(c *Config) *string { return &c.Audit.File })},
+	{key: "upstream.check_interval", env: "UPSTREAM_CHECK_INTERVAL", flag: "upstream-check-interval", usage: "how often the repositories of the modules are checked; 0 disables the checks", binding: durationValue(func(c *Config) *time.Duration { return &c.Upstream.CheckInterval })},
+	{key: "upstream.check_timeout", env: "UPSTREAM_CHECK_TIMEOUT", flag: "upstream-check-timeout", usage: "maximum duration of the check of a repository", binding: durationValue(func(c *Config) *time.Duration { return &c.Upstream.CheckTimeout })},
+	{key: "upstream.clone_dir", env: "UPSTREAM_CLONE_DIR", flag: "upstream-clone-dir", usage: "directory the repositories are cloned in to read their go.mod", binding: stringValue(func(c *Config) *string { return &c.Upstream.CloneDir })},
+	{key: "upstream.verify_go_mod", env: "UPSTREAM_VERIFY_GO_MOD", flag: "upstream-verify-go-mod", usage: "what to do when the go.mod of a newly registered module declares another path: off, warn or reject", binding: lowerValue(func(c *Config) *string { return &c.Upstream.VerifyGoMod })},
+	{key: "upstream.versions_ttl", env: "UPSTREAM_VERSIONS_TTL", flag: "upstream-versions-ttl", usage: "how long the versions listed from the tags of a repository are kept; 0 disables the listing", binding: durationValue(func(c *Config) *time.Duration { return &c.Upstream.VersionsTTL })},
+	{key: "upstream.proxy", env: "UPSTREAM_PROXY", flag: "upstream-proxy", usage: "URL of a module proxy the go.mod files of the latest versions of the modules are read from", binding: stringValue(func(c *Config) *string { return &c.Upstream.Proxy })},
+	{key: "vuln.source", env: "VULN_SOURCE", flag: "vuln-source", usage: "directory or URL of the OSV vulnerability entries shown on the pages of the modules; empty disables them", binding: stringValue(func(c *Config) *string { return &c.Vuln.Source })},
+	{key: "vuln.refresh_interval", env: "VULN_REFRESH_INTERVAL", flag: "vuln-refresh-interval", usage: "how often the vulnerability entries are read again; 0 reads them only at startup", binding: durationValue(func(c *Config) *time.Duration { return &c.Vuln.RefreshInterval })},
+	{key: "vuln.timeout", env: "VULN_TIMEOUT", flag: "vuln-timeout", usage: "maximum duration of a reading of the vulnerability entries", binding: durationValue(func(c *Config) *time.Duration { return &c.Vuln.Timeout })},
+	{key: "docs.dir", env: "DOCS_DIR", flag: "docs-dir", usage: "directory holding a checkout of each module, at its path under the domain, whose documentation is shown to browsers; empty disables it", binding: stringValue(func(c *Config) *string { return &c.Docs.Dir })},
+}
+
+// value binds a field of type T using parse and format.
+func value[T any](field func(*Config) *T, parse func(string) (T, error), format func(T) string) binding {
+	return binding{
+		set: func(c *Config, s string) error {
+			v, err := parse(s)
+			if err != nil {
+				return err
+			}
+			*field(c) = v
+			return nil
+		},
+		get: func(c *Config) string {
+			return format(*field(c))
+		},
+	}
+}
+
+// stringValue binds a string field as is.
+func stringValue(field func(*Config) *string) binding {
+	return value(field, func(s string) (string, error) { return s, nil }, func(s string) string { return s })
+}
+
+// lowerValue binds a case-insensitive string field.
+func lowerValue(field func(*Config) *string) binding {
+	return value(field, func(s string) (string, error) { return strings.ToLower(s), nil }, func(s string) string { return s })
+}
+
+// exporterValue binds the trace exporter, accepting "console", the name used
+// by the OpenTelemetry specification for the stdout exporter.
+func exporterValue(field func(*Config) *string) binding {
+	return value(field, func(s string) (string, error) {
+		s = strings.ToLower(s)
+		if s == "console" {
+			s = telemetry.ExporterStdout
+		}
+		return s, nil
+	}, func(s string) string { return s })
+}
+
+// intValue binds an integer field.
+func intValue(field func(*Config) *int) binding {
+	return value(field, strconv.Atoi, strconv.Itoa)
+}
+
+// floatValue binds a floating-point field.
+func floatValue(field func(*Config) *float64) binding {
+	return value(field, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }, func(f float64) string {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	})
+}
+
+// boolValue binds a boolean field, accepting the values of strconv.ParseBool.
+func boolValue(field func(*Config) *bool) binding {
+	b := value(field, strconv.ParseBool, strconv.FormatBool)
+	b.isBool = true
+	return b
+}
+
+// durationValue binds a duration field, accepting the values of time.ParseDuration.
+func durationValue(field func(*Config) *time.Duration) binding {
+	return value(field, time.ParseDuration, time.Duration.String)
+}
+
+// levelValue binds a log level field, accepting the level names of slog.
+func levelValue(field func(*Config) *slog.Level) binding {
+	return value(field, func(s string) (slog.Level, error) {
+		var level slog.Level
+		err := level.UnmarshalText([]byte(s))
+		return level, err
+	}, func(level slog.Level) string { return strings.ToLower(level.String()) })
+}
+
+// secretValue binds a secret, given inline or as the path of the file holding it.
+func secretValue(field func(*Config) **secret.Secret) binding {
+	return binding{
+		set: func(c *Config, s string) error {
+			*field(c) = nil
+			if s != "" {
+				*field(c) = secret.New(s)
+			}
+			return nil
+		},
+		setFile: func(c *Config, path string) error {
+			s, err := secret.FromFile(path)
+			if err != nil {
+				return err
+			}
+			*field(c) = s
+			return nil
+		},
+		get: func(c *Config) string {
+			if *field(c) == nil {
+				return ""
+			}
+			return secret.Redacted
+		},
+	}
+}
+
+// prefixesValue binds a list of networks written as comma-separated CIDRs or addresses.
+func prefixesValue(field func(*Config) *[]netip.Prefix) binding {
+	return value(field, clientip.ParsePrefixes, func(prefixes []netip.Prefix) string {
+		s := make([]string, len(prefixes))
+		for i, p := range prefixes {
+			s[i] = p.String()
+		}
+		return strings.Join(s, ",")
+	})
+}
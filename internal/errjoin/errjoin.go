@@ -0,0 +1,14 @@
+// Package errjoin splits errors joined with errors.Join, so that each one can be
+// reported on its own, as validation errors are.
+package errjoin
+
+// Split returns the errors joined in err with errors.Join, err itself, or nothing when err is nil.
+func Split(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
@@ -0,0 +1,35 @@
+package errjoin
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	a, b := errors.New("a"), errors.New("b")
+	tests := []struct {
+		name string
+		err  error
+		want []error
+	}{
+		{name: "nil", err: nil, want: nil},
+		{name: "single", err: a, want: []error{a}},
+		{name: "joined", err: errors.Join(a, b), want: []error{a, b}},
+		{name: "wrapped joined", err: fmt.Errorf("context: %w", errors.Join(a, b)), want: []error{fmt.Errorf("context: %w", errors.Join(a, b))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(tt.err)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Split() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Error() != tt.want[i].Error() {
+					t.Errorf("Split()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
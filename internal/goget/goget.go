@@ -0,0 +1,262 @@
+// Package goget checks the answers of a vanity server the way the go command reads them
+// when it resolves a custom import path (see "go help importpath"). The rules mirror
+// cmd/go in module mode: only go-import meta tags in the <head> count, their prefix must
+// be the import path or one of its parents, exactly one of them may match, and a prefix
+// other than the import path itself must be confirmed by the page of the prefix.
+package goget
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MetaImport is a go-import meta tag: content="prefix vcs repo-root [subdir]".
+type MetaImport struct {
+	Prefix   string `json:"prefix"`
+	VCS      string `json:"vcs"`
+	RepoRoot string `json:"repo_root"`
+	SubDir   string `json:"subdir,omitempty"`
+}
+
+// String returns the content of the meta tag.
+func (m MetaImport) String() string {
+	s := m.Prefix + " " + m.VCS + " " + m.RepoRoot
+	if m.SubDir != "" {
+		s += " " + m.SubDir
+	}
+	return s
+}
+
+// vcsKinds are the version control systems the go command can fetch from.
+var vcsKinds = map[string]bool{
+	"bzr":    true,
+	"fossil": true,
+	"git":    true,
+	"hg":     true,
+	"mod":    true,
+	"svn":    true,
+}
+
+// ParseMetaImports returns the go-import meta tags of the HTML in r that the go command
+// uses, mod entries first, and describes those it ignores: tags outside the <head> and
+// tags without three or four fields.
+func ParseMetaImports(r io.Reader) (imports []MetaImport, ignored []string, err error) {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = charsetReader
+	d.Strict = false
+	inHead := true
+	for {
+		t, tokenErr := d.RawToken()
+		if tokenErr != nil {
+			if tokenErr != io.EOF && len(imports) == 0 {
+				err = tokenErr
+			}
+			break
+		}
+		if e, ok := t.(xml.StartElement); ok && strings.EqualFold(e.Name.Local, "body") {
+			inHead = false
+		}
+		if e, ok := t.(xml.EndElement); ok && strings.EqualFold(e.Name.Local, "head") {
+			inHead = false
+		}
+		e, ok := t.(xml.StartElement)
+		if !ok || !strings.EqualFold(e.Name.Local, "meta") || attrValue(e.Attr, "name") != "go-import" {
+			continue
+		}
+		content := attrValue(e.Attr, "content")
+		f := strings.Fields(content)
+		switch {
+		case !inHead:
+			ignored = append(ignored, fmt.Sprintf("go-import meta tag %q is outside the <head>", content))
+		case len(f) != 3 && len(f) != 4:
+			ignored = append(ignored, fmt.Sprintf("go-import meta tag %q does not have 3 or 4 fields", content))
+		default:
+			mi := MetaImport{Prefix: f[0], VCS: f[1], RepoRoot: f[2]}
+			if len(f) == 4 {
+				mi.SubDir = f[3]
+			}
+			imports = append(imports, mi)
+		}
+	}
+
+	// Like the go command in module mode, mod entries supersede the others of their prefix.
+	var list []MetaImport
+	mods := make(map[string]bool)
+	for _, m := range imports {
+		if m.VCS == "mod" {
+			mods[m.Prefix] = true
+			list = append(list, m)
+		}
+	}
+	for _, m := range imports {
+		if m.VCS != "mod" && !mods[m.Prefix] {
+			list = append(list, m)
+		}
+	}
+	return list, ignored, err
+}
+
+// charsetReader accepts the charsets the go command decodes: UTF-8 and ASCII.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "ascii":
+		return input, nil
+	default:
+		return nil, fmt.Errorf("can't decode XML document using charset %q", charset)
+	}
+}
+
+// attrValue returns the value of the attribute name, compared case-insensitively.
+func attrValue(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Match returns the meta import the go command selects for importPath: the only one
+// whose prefix is importPath or one of its parents, mod entries taking precedence.
+func Match(imports []MetaImport, importPath string) (MetaImport, error) {
+	match := -1
+	var mismatches []string
+	for i, im := range imports {
+		if !hasPathPrefix(importPath, im.Prefix) {
+			mismatches = append(mismatches, fmt.Sprintf("meta tag %s did not match import path %s", im.Prefix, importPath))
+			continue
+		}
+		if match >= 0 {
+			if imports[match].VCS == "mod" && im.VCS != "mod" {
+				break
+			}
+			return MetaImport{}, fmt.Errorf("multiple meta tags match import path %q", importPath)
+		}
+		match = i
+	}
+	if match < 0 {
+		if len(mismatches) == 0 {
+			return MetaImport{}, errors.New("no go-import meta tag")
+		}
+		return MetaImport{}, errors.New(strings.Join(mismatches, ", "))
+	}
+	return imports[match], nil
+}
+
+// hasPathPrefix reports whether path is prefix or a path below it.
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+// Checker fetches import paths from a vanity server and reports how the go command
+// would fail to resolve them.
+type Checker struct {
+	// BaseURL is where the server is reached (e.g., "https://staging.example.com"); import
+	// paths under Domain are fetched from the same path under it, so a staging server
+	// answers for the production domain.
+	BaseURL *url.URL
+	// Domain is the vanity domain of the import paths.
+	Domain string
+	// Client fetches the pages.
+	Client *http.Client
+}
+
+// Result is the outcome of checking an import path.
+type Result struct {
+	// ImportPath is the checked import path.
+	ImportPath string `json:"import_path"`
+	// URL is the page fetched for it.
+	URL string `json:"url"`
+	// Import is the go-import meta tag the go command selects; nil when there is none.
+	Import *MetaImport `json:"go_import,omitempty"`
+	// Violations are the reasons the go command rejects the answer, or ignores part of it.
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Check fetches importPath as the go command does and reports every violation found.
+func (c *Checker) Check(ctx context.Context, importPath string) Result {
+	res := Result{ImportPath: importPath}
+	u, err := c.url(importPath)
+	if err != nil {
+		res.Violations = append(res.Violations, err.Error())
+		return res
+	}
+	res.URL = u
+
+	imports, ignored, err := c.fetch(ctx, u)
+	res.Violations = append(res.Violations, ignored...)
+	if err != nil {
+		res.Violations = append(res.Violations, err.Error())
+		return res
+	}
+	mi, err := Match(imports, importPath)
+	if err != nil {
+		res.Violations = append(res.Violations, err.Error())
+		return res
+	}
+	res.Import = &mi
+
+	// The go command confirms a parent prefix with the page of the prefix, so that
+	// a path cannot claim the root of another module.
+	if mi.Prefix != importPath {
+		if rootURL, err := c.url(mi.Prefix); err != nil {
+			res.Violations = append(res.Violations, fmt.Sprintf("prefix %s: %v", mi.Prefix, err))
+		} else if rootImports, _, err := c.fetch(ctx, rootURL); err != nil {
+			res.Violations = append(res.Violations, fmt.Sprintf("prefix %s: %v", mi.Prefix, err))
+		} else if root, err := Match(rootImports, importPath); err != nil || root != mi {
+			res.Violations = append(res.Violations, fmt.Sprintf("%s and %s disagree about go-import for %s", u, rootURL, mi.Prefix))
+		}
+	}
+
+	if mi.SubDir != "" && (mi.SubDir[0] == '/' || mi.SubDir[0] == '-') {
+		res.Violations = append(res.Violations, fmt.Sprintf("invalid subdirectory %q", mi.SubDir))
+	}
+	if repo, err := url.Parse(mi.RepoRoot); err != nil || repo.Scheme == "" || repo.Scheme == "file" {
+		res.Violations = append(res.Violations, fmt.Sprintf("invalid repo root %q", mi.RepoRoot))
+	}
+	if !vcsKinds[mi.VCS] {
+		res.Violations = append(res.Violations, fmt.Sprintf("unknown vcs %q", mi.VCS))
+	}
+	return res
+}
+
+// url returns the URL of the page the server answers importPath with.
+func (c *Checker) url(importPath string) (string, error) {
+	path, ok := strings.CutPrefix(importPath, c.Domain)
+	if !ok || (path != "" && path[0] != '/') {
+		return "", fmt.Errorf("import path %q is not under the vanity domain %q", importPath, c.Domain)
+	}
+	return strings.TrimSuffix(c.BaseURL.String(), "/") + path + "?go-get=1", nil
+}
+
+// fetch returns the go-import meta tags of the page at u, with the tags the go command ignores.
+// Like the go command, it fails on an unsuccessful status only when the page has no go-import.
+func (c *Checker) fetch(ctx context.Context, u string) ([]MetaImport, []string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	imports, ignored, err := ParseMetaImports(resp.Body)
+	if len(imports) == 0 && resp.StatusCode != http.StatusOK {
+		return nil, ignored, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	if err != nil {
+		return nil, ignored, fmt.Errorf("parsing %s: %w", u, err)
+	}
+	if len(imports) == 0 {
+		return nil, ignored, fmt.Errorf("no go-import meta tag found in %s", u)
+	}
+	return imports, ignored, nil
+}
@@ -0,0 +1,166 @@
+package goget
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMetaImports(t *testing.T) {
+	tests := []struct {
+		name        string
+		html        string
+		want        []MetaImport
+		wantIgnored []string
+	}{
+		{
+			name: "head",
+			html: `<html><head><meta name="go-import" content="example.com/a git https://github.com/a/a"></head></html>`,
+			want: []MetaImport{{Prefix: "example.com/a", VCS: "git", RepoRoot: "https://github.com/a/a"}},
+		},
+		{
+			name: "subdirectory",
+			html: `<meta name="go-import" content="example.com/a git https://github.com/a/mono a">`,
+			want: []MetaImport{{Prefix: "example.com/a", VCS: "git", RepoRoot: "https://github.com/a/mono", SubDir: "a"}},
+		},
+		{
+			name:        "body",
+			html:        `<html><head></head><body><meta name="go-import" content="example.com/a git https://github.com/a/a"></body></html>`,
+			wantIgnored: []string{"is outside the <head>"},
+		},
+		{
+			name:        "wrong field count",
+			html:        `<head><meta name="go-import" content="example.com/a git"></head>`,
+			wantIgnored: []string{"does not have 3 or 4 fields"},
+		},
+		{
+			name: "mod first",
+			html: `<head>
+<meta name="go-import" content="example.com/a git https://github.com/a/a">
+<meta name="go-import" content="example.com/a mod https://proxy.example.com">
+<meta name="go-import" content="example.com/b git https://github.com/a/b">
+</head>`,
+			want: []MetaImport{
+				{Prefix: "example.com/a", VCS: "mod", RepoRoot: "https://proxy.example.com"},
+				{Prefix: "example.com/b", VCS: "git", RepoRoot: "https://github.com/a/b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ignored, err := ParseMetaImports(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMetaImports() = %+v, want %+v", got, tt.want)
+			}
+			if len(ignored) != len(tt.wantIgnored) {
+				t.Fatalf("ParseMetaImports() ignored = %q, want %d", ignored, len(tt.wantIgnored))
+			}
+			for i, want := range tt.wantIgnored {
+				if !strings.Contains(ignored[i], want) {
+					t.Errorf("ignored[%d] = %q, want it to contain %q", i, ignored[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	a := MetaImport{Prefix: "example.com/a", VCS: "git", RepoRoot: "https://github.com/a/a"}
+	ab := MetaImport{Prefix: "example.com/a/b", VCS: "git", RepoRoot: "https://github.com/a/b"}
+	mod := MetaImport{Prefix: "example.com/a", VCS: "mod", RepoRoot: "https://proxy.example.com"}
+
+	tests := []struct {
+		name       string
+		imports    []MetaImport
+		importPath string
+		want       MetaImport
+		wantErr    string
+	}{
+		{name: "exact", imports: []MetaImport{a}, importPath: "example.com/a", want: a},
+		{name: "package", imports: []MetaImport{a}, importPath: "example.com/a/cmd", want: a},
+		{name: "not a path prefix", imports: []MetaImport{a}, importPath: "example.com/ab", wantErr: "meta tag example.com/a did not match import path example.com/ab"},
+		{name: "multiple", imports: []MetaImport{a, ab}, importPath: "example.com/a/b", wantErr: "multiple meta tags match"},
+		{name: "mod precedes", imports: []MetaImport{mod, a}, importPath: "example.com/a", want: mod},
+		{name: "none", importPath: "example.com/a", wantErr: "no go-import meta tag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(tt.imports, tt.importPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Match() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Match() = %+v, %v; want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	pages := map[string]string{
+		"/ok":        `<head><meta name="go-import" content="example.com/ok git https://github.com/a/ok"></head>`,
+		"/mismatch":  `<head><meta name="go-import" content="example.com/other git https://github.com/a/other"></head>`,
+		"/twice":     `<head><meta name="go-import" content="example.com/twice git https://github.com/a/x"><meta name="go-import" content="example.com/twice git https://github.com/a/y"></head>`,
+		"/claims":    `<head><meta name="go-import" content="example.com git https://github.com/evil/root"></head>`,
+		"":           `<head><meta name="go-import" content="example.com git https://github.com/a/root"></head>`,
+		"/badrepo":   `<head><meta name="go-import" content="example.com/badrepo cvs file:///srv/repo"></head>`,
+		"/inbody":    `<head></head><body><meta name="go-import" content="example.com/inbody git https://github.com/a/b"></body>`,
+		"/ok/nested": `<head><meta name="go-import" content="example.com/ok git https://github.com/a/ok"></head>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "go-get=1" {
+			http.Error(w, "missing go-get", http.StatusBadRequest)
+			return
+		}
+		page, ok := pages[strings.TrimSuffix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(page))
+	}))
+	defer srv.Close()
+	base, _ := url.Parse(srv.URL)
+	c := &Checker{BaseURL: base, Domain: "example.com", Client: srv.Client()}
+
+	tests := []struct {
+		importPath string
+		wantErr    []string
+	}{
+		{importPath: "example.com/ok"},
+		{importPath: "example.com/ok/nested"},
+		{importPath: "example.com/mismatch", wantErr: []string{"did not match import path"}},
+		{importPath: "example.com/twice", wantErr: []string{"multiple meta tags match"}},
+		{importPath: "example.com/claims", wantErr: []string{"disagree about go-import for example.com"}},
+		{importPath: "example.com/badrepo", wantErr: []string{`invalid repo root "file:///srv/repo"`, `unknown vcs "cvs"`}},
+		{importPath: "example.com/inbody", wantErr: []string{"outside the <head>", "no go-import meta tag found"}},
+		{importPath: "example.com/missing", wantErr: []string{"404 Not Found"}},
+		{importPath: "other.com/ok", wantErr: []string{"not under the vanity domain"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.importPath, func(t *testing.T) {
+			res := c.Check(context.Background(), tt.importPath)
+			if len(res.Violations) != len(tt.wantErr) {
+				t.Fatalf("Check() violations = %q, want %d", res.Violations, len(tt.wantErr))
+			}
+			for i, want := range tt.wantErr {
+				if !strings.Contains(res.Violations[i], want) {
+					t.Errorf("violation %d = %q, want it to contain %q", i, res.Violations[i], want)
+				}
+			}
+		})
+	}
+}
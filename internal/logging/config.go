@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// Config holds the configuration for the application logger.
+type Config struct {
+	// Level is the minimum level of the records that are emitted at startup.
+	Level slog.Level
+	// Format is the output format of the log records, either "text" or "json".
+	Format string
+}
+
+const (
+	// FormatText renders log records as logfmt-style key=value pairs.
+	FormatText = "text"
+	// FormatJSON renders log records as one JSON object per line.
+	FormatJSON = "json"
+)
+
+const (
+	// Default values for the logger configuration.
+	// These can be overridden through the config package.
+
+	// defaultLevel is the default minimum log level.
+	defaultLevel = slog.LevelInfo
+	// defaultFormat is the default log output format.
+	defaultFormat = FormatText
+)
+
+// DefaultConfig returns the logger configuration used when nothing is overridden.
+func DefaultConfig() *Config {
+	return &Config{
+		Level:  defaultLevel,
+		Format: defaultFormat,
+	}
+}
+
+// Validate reports every invalid value of the configuration.
+func (c *Config) Validate() error {
+	if c.Format != FormatText && c.Format != FormatJSON {
+		return fmt.Errorf("invalid format %q: must be %q or %q", c.Format, FormatText, FormatJSON)
+	}
+	return nil
+}
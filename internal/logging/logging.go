@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+)
+
+// NewLevel creates the level variable shared by every handler of the logger.
+// Changing its value adjusts the verbosity of the running server without a restart.
+func NewLevel(cfg *Config) *slog.LevelVar {
+	level := new(slog.LevelVar)
+	level.Set(cfg.Level)
+	return level
+}
+
+// New creates a structured logger writing to w in the configured format.
+// The minimum level is read from level on every record, so updates to it
+// take effect immediately. Records logged with a context carrying a span
+// include its trace and span IDs.
+func New(cfg *Config, level *slog.LevelVar, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch cfg.Format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	return slog.New(traceHandler{Handler: handler})
+}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceHandler decorates records logged with a context carrying a span
+// with its trace and span IDs, so log lines can be correlated with traces.
+type traceHandler struct {
+	slog.Handler
+}
+
+// Handle adds the trace_id and span_id attributes when ctx holds a valid span context.
+func (h traceHandler) Handle(ctx context.Context, record slog.Record) error {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if spanCtx.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanCtx.TraceID().String()),
+			slog.String("span_id", spanCtx.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a traceHandler whose wrapped handler has the given attributes.
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a traceHandler whose wrapped handler has the given group.
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,216 @@
+// Package osv reads vulnerability entries in the Open Source Vulnerability (OSV) format,
+// from a directory of OSV JSON files or from a database in the layout of the Go
+// vulnerability database, mirrored in a local directory or served over HTTP.
+package osv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EcosystemGo is the ecosystem of the packages of Go modules.
+const EcosystemGo = "Go"
+
+// Entry is an OSV vulnerability entry. Only the fields used by vanity-go are decoded.
+type Entry struct {
+	// ID identifies the entry (e.g., "GO-2024-0001").
+	ID string `json:"id"`
+	// Modified is when the entry was last changed.
+	Modified time.Time `json:"modified"`
+	// Withdrawn is when the entry was withdrawn, if it was.
+	Withdrawn *time.Time `json:"withdrawn,omitempty"`
+	// Aliases are other identifiers of the vulnerability (e.g., "CVE-2024-1234").
+	Aliases []string `json:"aliases,omitempty"`
+	// Summary is a one-line description of the vulnerability.
+	Summary string `json:"summary,omitempty"`
+	// Details is a longer description of the vulnerability.
+	Details string `json:"details,omitempty"`
+	// Affected are the packages the vulnerability affects.
+	Affected []Affected `json:"affected"`
+	// DatabaseSpecific holds the fields specific to the database of the entry.
+	DatabaseSpecific *DatabaseSpecific `json:"database_specific,omitempty"`
+}
+
+// Affected is a package affected by a vulnerability.
+type Affected struct {
+	// Package is the affected package; for Go, its name is the module path.
+	Package Package `json:"package"`
+	// Ranges are the ranges of affected versions.
+	Ranges []Range `json:"ranges,omitempty"`
+}
+
+// Package identifies a package in an ecosystem.
+type Package struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+}
+
+// Range is a range of affected versions, described by the events introducing and
+// fixing the vulnerability.
+type Range struct {
+	// Type is how versions are ordered; Go modules use "SEMVER".
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Event introduces or fixes the vulnerability at a version, given without the "v" prefix
+// of Go versions; the version "0" introduces it from the beginning. LastAffected ends a
+// range at the last affected version, included, when no fixed version is known.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// DatabaseSpecific holds the fields of the Go vulnerability database.
+type DatabaseSpecific struct {
+	// URL is the page describing the entry.
+	URL string `json:"url,omitempty"`
+}
+
+// moduleIndex is an entry of index/modules.json in the Go vulnerability database.
+type moduleIndex struct {
+	Path  string `json:"path"`
+	Vulns []struct {
+		ID string `json:"id"`
+	} `json:"vulns"`
+}
+
+// maxFileSize is the size of the largest file read from a database.
+const maxFileSize = 64 << 20
+
+// Load returns the entries of source affecting the Go modules whose path match reports,
+// except withdrawn ones, ordered by ID. source is the URL of a database in the layout of
+// the Go vulnerability database (e.g., "https://vuln.go.dev"), or a directory holding
+// either such a database or OSV JSON files, at any depth. Only the entries listed for
+// the matching modules in the index/modules.json of a database are read.
+func Load(ctx context.Context, client *http.Client, source string, match func(modulePath string) bool) ([]Entry, error) {
+	var read func(name string) ([]byte, error)
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		read = func(name string) ([]byte, error) {
+			return fetch(ctx, client, strings.TrimSuffix(source, "/")+"/"+name)
+		}
+	} else {
+		if _, err := os.Stat(filepath.Join(source, "index", "modules.json")); errors.Is(err, fs.ErrNotExist) {
+			return readDir(source, match)
+		}
+		read = func(name string) ([]byte, error) {
+			return os.ReadFile(filepath.Join(source, filepath.FromSlash(name)))
+		}
+	}
+	return readDB(read, match)
+}
+
+// readDB reads the entries affecting the modules matched by match from a database in
+// the layout of the Go vulnerability database, whose files are read by read.
+func readDB(read func(name string) ([]byte, error), match func(string) bool) ([]Entry, error) {
+	data, err := read("index/modules.json")
+	if err != nil {
+		return nil, err
+	}
+	var index []moduleIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("index/modules.json: %w", err)
+	}
+
+	var ids []string
+	seen := make(map[string]struct{})
+	for _, m := range index {
+		if !match(m.Path) {
+			continue
+		}
+		for _, v := range m.Vulns {
+			if _, dup := seen[v.ID]; !dup {
+				seen[v.ID] = struct{}{}
+				ids = append(ids, v.ID)
+			}
+		}
+	}
+	slices.Sort(ids)
+
+	var entries []Entry
+	for _, id := range ids {
+		if strings.ContainsAny(id, `/\`) {
+			return nil, fmt.Errorf("index/modules.json: invalid ID %q", id)
+		}
+		name := "ID/" + id + ".json"
+		data, err := read(name)
+		if err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if e.Withdrawn == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// readDir reads the entries affecting the modules matched by match from the OSV JSON
+// files found in dir and its subdirectories. Other JSON files are skipped.
+func readDir(dir string, match func(string) bool) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil || e.ID == "" {
+			// Not an OSV entry.
+			return nil
+		}
+		if e.Withdrawn != nil {
+			return nil
+		}
+		if slices.ContainsFunc(e.Affected, func(a Affected) bool { return a.Package.Ecosystem == EcosystemGo && match(a.Package.Name) }) {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.ID, b.ID) })
+	return entries, nil
+}
+
+// fetch returns the body of a GET request of u.
+func fetch(ctx context.Context, client *http.Client, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", u, maxFileSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,96 @@
+package osv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const (
+	entryApp       = `{"id":"GO-2026-0001","modified":"2026-01-02T00:00:00Z","aliases":["CVE-2026-1234"],"summary":"Panic in app","affected":[{"package":{"name":"go.gllm.dev/app","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"0"},{"fixed":"1.2.3"}]}]}]}`
+	entryWithdrawn = `{"id":"GO-2026-0002","modified":"2026-01-02T00:00:00Z","withdrawn":"2026-01-03T00:00:00Z","affected":[{"package":{"name":"go.gllm.dev/app","ecosystem":"Go"}}]}`
+	entryOther     = `{"id":"GO-2026-0003","modified":"2026-01-02T00:00:00Z","affected":[{"package":{"name":"example.com/other","ecosystem":"Go"}}]}`
+	entryTools     = `{"id":"GHSA-xxxx-yyyy-zzzz","modified":"2026-01-02T00:00:00Z","affected":[{"package":{"name":"go.gllm.dev/tools","ecosystem":"Go"}},{"package":{"name":"go.gllm.dev/tools","ecosystem":"npm"}}]}`
+	index          = `[{"path":"go.gllm.dev/app","vulns":[{"id":"GO-2026-0001"},{"id":"GO-2026-0002"}]},{"path":"example.com/other","vulns":[{"id":"GO-2026-0003"}]}]`
+)
+
+// writeFiles writes files, keyed by their slash-separated names, in dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	match := func(modulePath string) bool { return strings.HasPrefix(modulePath, "go.gllm.dev/") }
+
+	entries := t.TempDir()
+	writeFiles(t, entries, map[string]string{
+		"GO-2026-0001.json":             entryApp,
+		"GO-2026-0002.json":             entryWithdrawn,
+		"2026/GO-2026-0003.json":        entryOther,
+		"ghsa/GHSA-xxxx-yyyy-zzzz.json": entryTools,
+		"package.json":                  `{"name":"not an entry"}`,
+		"README.md":                     "entries",
+	})
+
+	db := map[string]string{
+		"index/modules.json":   index,
+		"ID/GO-2026-0001.json": entryApp,
+		"ID/GO-2026-0002.json": entryWithdrawn,
+		"ID/GO-2026-0003.json": entryOther,
+	}
+	dbDir := t.TempDir()
+	writeFiles(t, dbDir, db)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := db[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		source  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "directory of entries", source: entries, want: []string{"GHSA-xxxx-yyyy-zzzz", "GO-2026-0001"}},
+		{name: "database directory", source: dbDir, want: []string{"GO-2026-0001"}},
+		{name: "database URL", source: srv.URL + "/", want: []string{"GO-2026-0001"}},
+		{name: "missing database", source: srv.URL + "/missing", wantErr: true},
+		{name: "missing directory", source: filepath.Join(entries, "missing"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(context.Background(), http.DefaultClient, tt.source, match)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, e := range got {
+				ids = append(ids, e.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("Load() = %q, want %q", ids, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Limit is the token bucket budget of a class of clients.
+type Limit struct {
+	// Rate is the number of requests per second a client is allowed on average.
+	Rate float64
+	// Burst is the maximum number of requests a client can make at once.
+	Burst int
+}
+
+// Config holds the configuration for per-client rate limiting.
+type Config struct {
+	// Enabled turns rate limiting on.
+	Enabled bool
+	// GoTool is the budget for requests made by the go command (?go-get=1).
+	GoTool Limit
+	// Browser is the budget for every other request to a vanity path.
+	Browser Limit
+	// Admin is the budget for the admin and metrics endpoints.
+	Admin Limit
+	// MaxClients is the maximum number of clients tracked per budget.
+	// The least recently seen client is forgotten when the limit is reached.
+	MaxClients int
+}
+
+const (
+	// Default values for the rate limiting configuration.
+	// These can be overridden through the config package.
+
+	// defaultGoToolRate is the default request rate for the go command.
+	defaultGoToolRate = 20
+	// defaultGoToolBurst is the default burst for the go command.
+	defaultGoToolBurst = 100
+	// defaultBrowserRate is the default request rate for browsers.
+	defaultBrowserRate = 5
+	// defaultBrowserBurst is the default burst for browsers.
+	defaultBrowserBurst = 20
+	// defaultAdminRate is the default request rate for the admin endpoints.
+	defaultAdminRate = 1
+	// defaultAdminBurst is the default burst for the admin endpoints.
+	defaultAdminBurst = 10
+	// defaultMaxClients is the default number of clients tracked per budget.
+	defaultMaxClients = 10000
+)
+
+// DefaultConfig returns the rate limiting configuration used when nothing is overridden.
+func DefaultConfig() *Config {
+	return &Config{
+		Enabled:    true,
+		GoTool:     Limit{Rate: defaultGoToolRate, Burst: defaultGoToolBurst},
+		Browser:    Limit{Rate: defaultBrowserRate, Burst: defaultBrowserBurst},
+		Admin:      Limit{Rate: defaultAdminRate, Burst: defaultAdminBurst},
+		MaxClients: defaultMaxClients,
+	}
+}
+
+// Validate reports every invalid value of the configuration.
+func (c *Config) Validate() error {
+	var errs []error
+
+	limits := []struct {
+		name  string
+		limit Limit
+	}{
+		{name: "go", limit: c.GoTool},
+		{name: "browser", limit: c.Browser},
+		{name: "admin", limit: c.Admin},
+	}
+	for _, l := range limits {
+		if l.limit.Rate <= 0 || l.limit.Burst <= 0 {
+			errs = append(errs, fmt.Errorf("%s rate and burst must be positive", l.name))
+		}
+	}
+
+	if c.MaxClients <= 0 {
+		errs = append(errs, fmt.Errorf("max clients must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
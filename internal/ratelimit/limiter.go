@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a set of token buckets keyed by client, such as its address.
+// Each bucket holds up to burst tokens and is refilled at rate tokens per second.
+// At most maxKeys buckets are kept; when full, the least recently used bucket is evicted,
+// which bounds memory regardless of how many distinct clients are seen.
+type Limiter[K comparable] struct {
+	rate    float64
+	burst   float64
+	maxKeys int
+	// now returns the current time; it is replaced in tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[K]*list.Element
+	// lru orders the buckets from most to least recently used.
+	lru       *list.List
+	evictions uint64
+}
+
+// bucket is the state of a single client's token bucket.
+type bucket[K comparable] struct {
+	key    K
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a Limiter allowing limit.Rate requests per second with bursts
+// of up to limit.Burst requests, tracking at most maxKeys clients.
+func NewLimiter[K comparable](limit Limit, maxKeys int) *Limiter[K] {
+	return &Limiter[K]{
+		rate:    limit.Rate,
+		burst:   float64(limit.Burst),
+		maxKeys: maxKeys,
+		now:     time.Now,
+		buckets: make(map[K]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Allow reports whether a request from key may proceed, consuming one token if so.
+// When the request is rejected it also returns how long the client should wait
+// before a token becomes available.
+func (l *Limiter[K]) Allow(key K) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b := l.get(key, now)
+
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// get returns the bucket for key, creating it full and evicting the least
+// recently used bucket if the limiter is at capacity.
+func (l *Limiter[K]) get(key K, now time.Time) *bucket[K] {
+	if elem, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(elem)
+		return elem.Value.(*bucket[K])
+	}
+
+	if l.lru.Len() >= l.maxKeys {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.buckets, oldest.Value.(*bucket[K]).key)
+		l.evictions++
+	}
+
+	b := &bucket[K]{key: key, tokens: l.burst, last: now}
+	l.buckets[key] = l.lru.PushFront(b)
+	return b
+}
+
+// Len returns the number of clients currently tracked.
+func (l *Limiter[K]) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lru.Len()
+}
+
+// Evictions returns the number of buckets evicted to stay within capacity.
+func (l *Limiter[K]) Evictions() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.evictions
+}
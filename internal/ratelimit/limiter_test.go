@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter(limit Limit, maxKeys int) (*Limiter[string], *time.Time) {
+	now := time.Unix(0, 0)
+	l := NewLimiter[string](limit, maxKeys)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, now := newTestLimiter(Limit{Rate: 2, Burst: 3}, 10)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("client"); !ok {
+			t.Fatalf("request %d within burst was rejected", i+1)
+		}
+	}
+
+	ok, wait := l.Allow("client")
+	if ok {
+		t.Fatal("request beyond burst was allowed")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("wait = %v, want %v", wait, 500*time.Millisecond)
+	}
+
+	if ok, _ := l.Allow("other"); !ok {
+		t.Error("other client should have its own budget")
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("client"); !ok {
+		t.Error("request after refill was rejected")
+	}
+	if ok, _ := l.Allow("client"); ok {
+		t.Error("refill should only grant one token")
+	}
+
+	*now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("client"); !ok {
+			t.Fatalf("request %d after full refill was rejected", i+1)
+		}
+	}
+	if ok, _ := l.Allow("client"); ok {
+		t.Error("refill should be capped at burst")
+	}
+}
+
+func TestLimiter_Eviction(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Rate: 1, Burst: 1}, 2)
+
+	l.Allow("a")
+	l.Allow("b")
+	l.Allow("a")
+	l.Allow("c")
+
+	if got := l.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+	if got := l.Evictions(); got != 1 {
+		t.Errorf("Evictions() = %d, want 1", got)
+	}
+
+	// "a" was used more recently than "b", so its bucket was kept and is still empty.
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("recently used client should keep its state")
+	}
+	// "b" was the least recently used client, so it was evicted and starts with a full bucket.
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("evicted client should start with a full bucket")
+	}
+}
+
+func BenchmarkLimiter_Allow(b *testing.B) {
+	l := NewLimiter[string](Limit{Rate: 1e9, Burst: 1e9}, 10000)
+	keys := []string{"192.0.2.1", "192.0.2.2", "2001:db8::1"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Allow(keys[i%len(keys)])
+	}
+}
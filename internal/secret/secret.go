@@ -0,0 +1,103 @@
+// Package secret holds credentials such as tokens and keys, given inline or read from a file.
+//
+// File-backed secrets follow the *_FILE convention of Docker and Kubernetes secrets:
+// the file is re-read whenever it changes, so a mounted secret can be rotated
+// without restarting the server. Secrets never print their value; formatting or
+// logging one yields Redacted.
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the value of a secret wherever it would be shown.
+const Redacted = "<redacted>"
+
+// Secret is a credential given inline or read from a file.
+type Secret struct {
+	// path is the file the secret is read from; empty for inline secrets.
+	path string
+
+	mu sync.Mutex
+	// value is the current value of the secret.
+	value string
+	// info describes the file value was read from, to detect rotation.
+	info os.FileInfo
+}
+
+// New returns an inline secret.
+func New(value string) *Secret {
+	return &Secret{value: value}
+}
+
+// FromFile returns a secret read from the file at path.
+// The file is read immediately so that a missing or empty file is reported at startup.
+func FromFile(path string) (*Secret, error) {
+	s := &Secret{path: path}
+	if _, err := s.Value(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Path returns the file the secret is read from, or an empty string for inline secrets.
+func (s *Secret) Path() string {
+	return s.path
+}
+
+// Value returns the current value of the secret. File-backed secrets are re-read
+// when the file has been replaced or modified since the last call.
+// Trailing newlines are removed, as editors and `kubectl create secret` often add one.
+func (s *Secret) Value() (string, error) {
+	if s.path == "" {
+		return s.value, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	if s.info != nil && os.SameFile(s.info, info) && s.info.ModTime().Equal(info.ModTime()) && s.info.Size() == info.Size() {
+		return s.value, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", errors.New("secret file " + s.path + " is empty")
+	}
+
+	s.value, s.info = value, info
+	return value, nil
+}
+
+// String returns Redacted, so that secrets never end up in formatted output.
+func (s *Secret) String() string {
+	return Redacted
+}
+
+// GoString returns Redacted for the %#v verb.
+func (s *Secret) GoString() string {
+	return Redacted
+}
+
+// LogValue returns Redacted, so that secrets never end up in log records.
+func (s *Secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+// MarshalText returns Redacted, so that secrets never end up in encoded configuration.
+func (s *Secret) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
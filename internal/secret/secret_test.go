@@ -0,0 +1,92 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	s := New("s3cret")
+	got, err := s.Value()
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Value() = %q, %v", got, err)
+	}
+	if s.Path() != "" {
+		t.Errorf("Path() = %q, want empty", s.Path())
+	}
+}
+
+func TestFromFile_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := FromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Value(); got != "first" {
+		t.Fatalf("Value() = %q, want %q", got, "first")
+	}
+
+	// Replace the file the way Kubernetes does: write a new file and rename it over the old one.
+	next := path + ".new"
+	if err := os.WriteFile(next, []byte("second\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(next, time.Now(), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(next, path); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Value(); got != "second" {
+		t.Errorf("Value() after rotation = %q, want %q", got, "second")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Value(); err == nil {
+		t.Error("Value() should fail once the file is gone")
+	}
+}
+
+func TestFromFile_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{empty, filepath.Join(dir, "missing")} {
+		if _, err := FromFile(path); err == nil {
+			t.Errorf("FromFile(%q) should fail", path)
+		}
+	}
+}
+
+func TestSecret_Redacted(t *testing.T) {
+	s := New("s3cret")
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("config", slog.Any("token", s))
+	data, err := json.Marshal(struct{ Token *Secret }{s})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, out := range []string{fmt.Sprint(s), fmt.Sprintf("%v %+v %#v %s", s, s, s, s), logs.String(), string(data)} {
+		if strings.Contains(out, "s3cret") || !strings.Contains(out, "redacted") {
+			t.Errorf("secret leaked or not redacted: %s", out)
+		}
+	}
+}
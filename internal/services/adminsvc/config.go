@@ -0,0 +1,49 @@
+package adminsvc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Config holds the configuration of changes made to the module registry through the admin API.
+type Config struct {
+	// ApprovalCooldown is how long a change of repository or VCS waits after it was
+	// requested before it can be approved, leaving time to notice and cancel it.
+	ApprovalCooldown time.Duration
+	// HistoryFile is the file the applied versions of the module registry are kept in.
+	// Without it, they are kept in memory and lost on restart.
+	HistoryFile string
+	// HistoryRetention is the number of versions kept.
+	HistoryRetention int
+}
+
+const (
+	// Default values for the admin configuration.
+	// These can be overridden through the config package.
+
+	// defaultApprovalCooldown is the default cool-down of target changes: none.
+	defaultApprovalCooldown = 0
+	// defaultHistoryRetention is the default number of registry versions kept.
+	defaultHistoryRetention = 100
+)
+
+// DefaultConfig returns the admin configuration used when nothing is overridden.
+func DefaultConfig() *Config {
+	return &Config{
+		ApprovalCooldown: defaultApprovalCooldown,
+		HistoryRetention: defaultHistoryRetention,
+	}
+}
+
+// Validate reports every invalid value of the configuration.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.ApprovalCooldown < 0 {
+		errs = append(errs, fmt.Errorf("approval cooldown must not be negative"))
+	}
+	if c.HistoryRetention < 1 {
+		errs = append(errs, fmt.Errorf("history retention must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
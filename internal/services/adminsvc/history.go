@@ -0,0 +1,186 @@
+package adminsvc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is an applied state of the module registry.
+type Version struct {
+	// Number identifies the version; numbers increase with every state applied.
+	Number int `yaml:"version" json:"version"`
+	// Time is when the state was applied.
+	Time time.Time `yaml:"time" json:"time"`
+	// Author is the admin who applied the state, or "system" for reloads.
+	Author string `yaml:"author" json:"author"`
+	// Source is what applied the state (e.g., "startup", "SIGHUP", "admin" or "rollback to version 3").
+	Source string `yaml:"source" json:"source"`
+	// Registry is the module registry of the version.
+	Registry *gosvc.Config `yaml:"registry" json:"-"`
+}
+
+// History is the list of applied versions of the module registry, oldest first.
+// It is kept in a file when one is given, as a stream of YAML documents, one per
+// version; the file holds the module registry of each, so it is created readable
+// only by its owner in case the registry holds inline credentials.
+type History struct {
+	// path is the history file; empty when the history is kept in memory.
+	path string
+	// retention is the number of versions kept.
+	retention int
+	// versions are the kept versions, oldest first.
+	versions []Version
+}
+
+// OpenHistory reads the history kept in the file at path, which need not exist yet,
+// keeping the last retention versions. Without a path, the history is kept in memory.
+func OpenHistory(path string, retention int) (*History, error) {
+	h := &History{path: path, retention: retention}
+	if path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry history: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var v Version
+		err := dec.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse registry history %s: %w", path, err)
+		}
+		h.versions = append(h.versions, v)
+	}
+	return h, nil
+}
+
+// Versions returns the kept versions, oldest first.
+func (h *History) Versions() []Version {
+	return append([]Version(nil), h.versions...)
+}
+
+// Version returns the version numbered n.
+func (h *History) Version(n int) (Version, error) {
+	for _, v := range h.versions {
+		if v.Number == n {
+			return v, nil
+		}
+	}
+	return Version{}, fmt.Errorf("version %d: %w", n, ErrNotFound)
+}
+
+// Latest returns the last version, if any.
+func (h *History) Latest() (Version, bool) {
+	if len(h.versions) == 0 {
+		return Version{}, false
+	}
+	return h.versions[len(h.versions)-1], true
+}
+
+// add numbers v as the next version, appends it to the history, dropping the
+// oldest versions beyond the retention, and returns it. Nothing is added when the
+// registry of v is the one of the last version, which is returned instead.
+func (h *History) add(v Version) (Version, error) {
+	latest, ok := h.Latest()
+	if ok && sameRegistry(latest.Registry, v.Registry) {
+		return latest, nil
+	}
+	v.Number = latest.Number + 1
+
+	versions := append(h.Versions(), v)
+	if len(versions) > h.retention {
+		versions = versions[len(versions)-h.retention:]
+	}
+	if err := h.write(versions); err != nil {
+		return Version{}, err
+	}
+	h.versions = versions
+	return v, nil
+}
+
+// write replaces the history file with versions.
+func (h *History) write(versions []Version) error {
+	if h.path == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, v := range versions {
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("failed to encode registry history: %w", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode registry history: %w", err)
+	}
+	if err := writeFile(h.path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write registry history: %w", err)
+	}
+	return nil
+}
+
+// sameRegistry reports whether a and b register the same modules, aliases and policies.
+func sameRegistry(a, b *gosvc.Config) bool {
+	var bufA, bufB bytes.Buffer
+	if gosvc.WriteConfig(&bufA, a) != nil || gosvc.WriteConfig(&bufB, b) != nil {
+		return false
+	}
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
+
+// PathChange is an import path registered differently by two versions of the module registry.
+type PathChange struct {
+	// ImportPath is the import path of the module or alias.
+	ImportPath string `json:"import_path"`
+	// Before is the module or alias registered at the path by the first version; nil when there was none.
+	Before *gosvc.Module `json:"before,omitempty"`
+	// After is the module or alias registered at the path by the second version; nil when there is none.
+	After *gosvc.Module `json:"after,omitempty"`
+	// Retargeted reports whether the path resolves to another repository or VCS,
+	// so that builds fetch their code from elsewhere.
+	Retargeted bool `json:"retargeted"`
+}
+
+// DiffVersions returns the import paths registered differently by the versions from and to,
+// ordered by import path. svc provides the vanity domain and repository base URL the
+// registries are resolved with.
+func DiffVersions(ctx context.Context, svc *gosvc.Service, from, to Version) ([]PathChange, error) {
+	before, err := svc.Preview(ctx, from.Registry)
+	if err != nil {
+		return nil, fmt.Errorf("%w: version %d: %w", ErrInvalid, from.Number, err)
+	}
+	after, err := svc.Preview(ctx, to.Registry)
+	if err != nil {
+		return nil, fmt.Errorf("%w: version %d: %w", ErrInvalid, to.Number, err)
+	}
+
+	var changes []PathChange
+	for _, c := range gosvc.Diff(before.Registered(), after.Registered()) {
+		path := strings.TrimPrefix(strings.TrimPrefix(c.ImportPath, svc.Domain()), "/")
+		changes = append(changes, PathChange{
+			ImportPath: c.ImportPath,
+			Before:     c.Before,
+			After:      c.After,
+			Retargeted: !sameTarget(before.Resolve(ctx, path), after.Resolve(ctx, path)),
+		})
+	}
+	return changes, nil
+}
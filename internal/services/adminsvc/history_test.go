@@ -0,0 +1,207 @@
+package adminsvc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+func TestService_History(t *testing.T) {
+	ctx := context.Background()
+	s, _, path := newTestService(t, 0)
+
+	if _, _, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "app", Status: "deprecated"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(registry+"  - path: new\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(ctx, "SIGHUP"); err != nil {
+		t.Fatal(err)
+	}
+	// Reloading an unchanged file adds no version.
+	if err := s.Reload(ctx, "SIGHUP"); err != nil {
+		t.Fatal(err)
+	}
+
+	versions := s.Versions()
+	if len(versions) != 3 {
+		t.Fatalf("Versions() = %+v, want 3 versions", versions)
+	}
+	want := []struct{ author, source string }{{"system", "startup"}, {"alice", "admin"}, {"system", "SIGHUP"}}
+	for i, v := range versions {
+		if v.Number != i+1 || v.Author != want[i].author || v.Source != want[i].source {
+			t.Errorf("version %d = %d by %q from %q, want %d by %q from %q", i, v.Number, v.Author, v.Source, i+1, want[i].author, want[i].source)
+		}
+	}
+}
+
+func TestService_Diff(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTestService(t, 0)
+
+	if _, _, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "app", Status: "archived"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DeleteModule(ctx, "alice", "app"); err != nil {
+		t.Fatal(err)
+	}
+	change, err := s.DeleteModule(ctx, "alice", "tools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(ctx, "bob", change.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := s.Diff(ctx, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Diff() = %+v, want app and tools", changes)
+	}
+	// app is at its default repository, so removing it does not change where it resolves.
+	if c := changes[0]; c.ImportPath != "go.gllm.dev/app" || c.Before == nil || c.After != nil || c.Retargeted {
+		t.Errorf("Diff() app = %+v, want removed without retargeting", c)
+	}
+	if c := changes[1]; c.ImportPath != "go.gllm.dev/tools" || !c.Retargeted {
+		t.Errorf("Diff() tools = %+v, want retargeted", c)
+	}
+
+	if _, err := s.Diff(ctx, 1, 9); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Diff() unknown version error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_Rollback(t *testing.T) {
+	ctx := context.Background()
+	s, _, path := newTestService(t, 0)
+
+	_, change, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "tools", Repository: "https://github.com/other/tools", Status: "archived"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(ctx, "bob", change.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "later"}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.Rollback(ctx, "carol", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Number != 5 || res.Author != "carol" || res.Source != "rollback to version 1" {
+		t.Errorf("Rollback() = %+v, want version 5 by carol", res.Version)
+	}
+	// The status rolls back at once; the repository waits for approval, like an edit.
+	if m := s.svc.Resolve(ctx, "tools"); m.Repository != "https://github.com/other/tools" || m.Status.OrActive() != gosvc.StatusActive {
+		t.Errorf("tools after rollback = %+v, want the repository kept and the status rolled back", m)
+	}
+	// later resolves to its default repository either way, so its removal applies at once.
+	if s.registered("later") != nil {
+		t.Error("later is still registered after the rollback")
+	}
+	if len(res.Pending) != 1 || res.Pending[0].Path != "tools" || res.Pending[0].RequestedBy != "carol" || res.Pending[0].After.Repository != "https://gitlab.com/gllm-dev/tools" {
+		t.Fatalf("Rollback() pending = %+v, want the repository change of tools", res.Pending)
+	}
+	if _, err := s.Approve(ctx, "carol", res.Pending[0].ID); !errors.Is(err, ErrSelfApproval) {
+		t.Errorf("Approve() by the admin rolling back error = %v, want ErrSelfApproval", err)
+	}
+	if _, err := s.Approve(ctx, "dave", res.Pending[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.svc.Resolve(ctx, "tools").Repository; got != "https://gitlab.com/gllm-dev/tools" {
+		t.Errorf("repository after approval = %q", got)
+	}
+	cfg, err := gosvc.ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Modules) != 2 || cfg.Modules[1].Repository != "https://gitlab.com/gllm-dev/tools" {
+		t.Errorf("registry file modules = %+v, want the rollback written", cfg.Modules)
+	}
+
+	if _, err := s.Rollback(ctx, "carol", 9); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Rollback() unknown version error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_Rollback_Verify(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTestService(t, 0)
+
+	_, change, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "tools", Repository: "https://github.com/evil/tools"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(ctx, "bob", change.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, change, _, err = s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "tools", Repository: "https://gitlab.com/gllm-dev/tools"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(ctx, "bob", change.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rolling back to a repository the verification now rejects is refused.
+	s.verifier = &fakeVerifier{reject: "https://github.com/evil"}
+	if _, err := s.Rollback(ctx, "carol", 2); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Rollback() to a rejected repository error = %v, want ErrInvalid", err)
+	}
+	if len(s.Changes()) != 0 {
+		t.Errorf("Changes() = %+v, want none", s.Changes())
+	}
+	// A rollback retargeting a path with a pending change is refused.
+	s.verifier = nil
+	if _, _, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "tools", Repository: "https://github.com/other/tools"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rollback(ctx, "carol", 2); !errors.Is(err, ErrPending) {
+		t.Errorf("Rollback() over a pending change error = %v, want ErrPending", err)
+	}
+}
+
+func TestHistory_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.yaml")
+	h, err := OpenHistory(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"a", "b", "b", "c"} {
+		if _, err := h.add(Version{Author: "alice", Registry: &gosvc.Config{Modules: []gosvc.ModuleConfig{{Path: p}}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("history file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	reopened, err := OpenHistory(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := reopened.Versions()
+	if len(versions) != 2 || versions[0].Number != 2 || versions[1].Number != 3 {
+		t.Fatalf("reopened versions = %+v, want versions 2 and 3 kept", versions)
+	}
+	if got := versions[1].Registry.Modules[0].Path; got != "c" {
+		t.Errorf("version 3 registry path = %q, want c", got)
+	}
+	if _, err := reopened.Version(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Version(1) error = %v, want ErrNotFound", err)
+	}
+}
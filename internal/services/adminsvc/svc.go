@@ -0,0 +1,627 @@
+// Package adminsvc changes the module registry at runtime: reloads of the registry file
+// and edits of modules made through the admin API.
+//
+// Pointing an import path at another repository silently redirects every build that
+// depends on it, so edits that change the repository or VCS a path resolves to do not
+// apply immediately: they wait, as pending changes, for another admin to approve them.
+// Other edits apply at once. Rollbacks to former versions follow the same rule for each
+// path whose repository or VCS they change.
+//
+// Every state the registry is put in, by a reload or an edit, is kept as a numbered
+// version, which can be compared with another and rolled back to.
+package adminsvc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/audit"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// Errors reported by the service; they are wrapped with details.
+var (
+	// ErrInvalid is returned when an edit would make the module registry invalid.
+	ErrInvalid = errors.New("invalid module registry")
+	// ErrNotFound is returned for unknown modules and pending changes.
+	ErrNotFound = errors.New("not found")
+	// ErrPending is returned when a change of the same module is already pending.
+	ErrPending = errors.New("a change of the module is already pending")
+	// ErrSelfApproval is returned when an admin approves a change they requested.
+	ErrSelfApproval = errors.New("changes must be approved by another admin")
+	// ErrCoolingDown is returned when a change is approved before its cool-down has elapsed.
+	ErrCoolingDown = errors.New("change is cooling down")
+	// ErrConflict is returned when a path resolves elsewhere than when the change was requested.
+	ErrConflict = errors.New("module changed since the change was requested")
+)
+
+// Verifier verifies the repositories of modules before they are registered.
+type Verifier interface {
+	// Verify returns warnings about the repositories of modules, and an error when
+	// the modules must not be registered.
+	Verify(ctx context.Context, modules []gosvc.Module) ([]string, error)
+}
+
+// Change is a pending change of the repository or VCS a module path resolves to.
+type Change struct {
+	// ID identifies the change.
+	ID string `json:"id"`
+	// Path is the module root relative to the vanity domain (e.g., "vanity-go").
+	Path string `json:"path"`
+	// Remove reports whether the module is removed rather than changed.
+	Remove bool `json:"remove,omitempty"`
+	// Before is how the path resolved when the change was requested.
+	Before gosvc.Module `json:"before"`
+	// After is how the path resolves once the change is applied.
+	After gosvc.Module `json:"after"`
+	// RequestedBy is the admin who requested the change.
+	RequestedBy string `json:"requested_by"`
+	// RequestedAt is when the change was requested.
+	RequestedAt time.Time `json:"requested_at"`
+	// ApprovableAt is when the cool-down of the change ends and it can be approved.
+	ApprovableAt time.Time `json:"approvable_at"`
+
+	// module is the requested module configuration; unused when Remove or alias is set.
+	module gosvc.ModuleConfig
+	// alias is the requested alias configuration, when a rollback registers an alias at Path.
+	alias *gosvc.AliasConfig
+	// seq orders the changes by request.
+	seq int
+}
+
+// Service applies changes to the registered modules of a gosvc.Service and writes
+// them to the module registry file, so that they survive reloads and restarts.
+type Service struct {
+	// svc is the service whose modules are changed.
+	svc *gosvc.Service
+	// path is the module registry file; empty when none is configured.
+	path string
+	// audit records reloads; nil when auditing is disabled.
+	audit *audit.Log
+	// cooldown is how long target changes wait before they can be approved.
+	cooldown time.Duration
+	// history are the applied versions of the registry.
+	history *History
+	// verifier verifies the modules about to be registered; nil when they are not verified.
+	verifier Verifier
+	// now returns the current time.
+	now func() time.Time
+
+	mu sync.Mutex
+	// pending are the changes waiting for approval, by ID.
+	pending map[string]*Change
+	// lastID is the number of the last change requested.
+	lastID int
+}
+
+// New creates a Service changing the modules of svc, loaded from the registry file at path.
+// Without a path, changes are kept in memory. log and verifier may be nil.
+// The registry loaded in svc is added to the history as the "startup" version, unless it
+// is the last version kept. It is not verified here, so that a rejection never stops the
+// server: the server verifies it in the background, see upstreamsvc.Service.VerifyRegistered,
+// while verifier verifies the modules the changes applied afterwards register or retarget.
+func New(cfg *Config, svc *gosvc.Service, path string, log *audit.Log, verifier Verifier) (*Service, error) {
+	history, err := OpenHistory(cfg.HistoryFile, cfg.HistoryRetention)
+	if err != nil {
+		return nil, err
+	}
+	s := &Service{
+		svc:      svc,
+		path:     path,
+		audit:    log,
+		cooldown: cfg.ApprovalCooldown,
+		history:  history,
+		verifier: verifier,
+		now:      time.Now,
+		pending:  make(map[string]*Change),
+	}
+	if _, err := s.record("system", "startup"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the module registry file and atomically replaces the registered modules.
+// It does nothing when no registry file is configured. The reload is recorded in the
+// audit log, if any, with source naming what triggered it and the modules it changed.
+func (s *Service) Reload(ctx context.Context, source string) error {
+	if s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := s.svc.Registered()
+	cfg, err := gosvc.ReadConfig(s.path)
+	if err == nil {
+		_, err = s.verify(ctx, cfg)
+	}
+	if err == nil {
+		err = s.svc.Load(ctx, cfg)
+	}
+	if err == nil {
+		_, err = s.record("system", source)
+	}
+	if s.audit == nil {
+		return err
+	}
+
+	event := audit.Event{Actor: "system", Source: source, Action: "reload", Changes: auditChanges(gosvc.Diff(before, s.svc.Registered()))}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	if auditErr := s.audit.Record(event); auditErr != nil {
+		return errors.Join(err, auditErr)
+	}
+	return err
+}
+
+// PutModule registers mc, or replaces the module registered under its path, on behalf of actor.
+// When this changes the repository or VCS the path resolves to, that part of the edit
+// becomes a pending change, which is returned, and the rest applies immediately.
+// It returns the module as registered afterwards, if any, and the warnings of the
+// verification of its repository.
+func (s *Service) PutModule(ctx context.Context, actor string, mc gosvc.ModuleConfig) (*gosvc.Module, *Change, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg := s.svc.Config()
+	i := moduleIndex(cfg, mc.Path)
+	var current gosvc.ModuleConfig
+	if i < 0 {
+		cfg.Modules = append(cfg.Modules, mc)
+	} else {
+		current, cfg.Modules[i] = cfg.Modules[i], mc
+	}
+	change, err := s.target(ctx, cfg, mc.Path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	warnings, err := s.verify(ctx, cfg)
+	if err != nil {
+		return nil, nil, warnings, err
+	}
+	if change == nil {
+		if err := s.apply(ctx, actor, "admin", cfg); err != nil {
+			return nil, nil, nil, err
+		}
+		return s.registered(mc.Path), nil, warnings, nil
+	}
+
+	if err := s.checkPending(mc.Path); err != nil {
+		return nil, nil, nil, err
+	}
+	// A new module is wholly pending; an existing one gets its other fields now.
+	if i >= 0 {
+		immediate := mc
+		immediate.Repository, immediate.VCS = current.Repository, current.VCS
+		cfg.Modules[i] = immediate
+		if err := s.apply(ctx, actor, "admin", cfg); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return s.registered(mc.Path), s.request(actor, change, mc), warnings, nil
+}
+
+// DeleteModule removes the module registered under path on behalf of actor. When the path
+// then resolves to another repository or VCS, the removal is returned as a pending change.
+func (s *Service) DeleteModule(ctx context.Context, actor, path string) (*Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg := s.svc.Config()
+	i := moduleIndex(cfg, path)
+	if i < 0 {
+		return nil, fmt.Errorf("module %q: %w", path, ErrNotFound)
+	}
+	cfg.Modules = slices.Delete(cfg.Modules, i, i+1)
+	change, err := s.target(ctx, cfg, path)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return nil, s.apply(ctx, actor, "admin", cfg)
+	}
+	if err := s.checkPending(path); err != nil {
+		return nil, err
+	}
+	change.Remove = true
+	return s.request(actor, change, gosvc.ModuleConfig{}), nil
+}
+
+// Changes returns the pending changes, oldest first.
+func (s *Service) Changes() []Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes := make([]Change, 0, len(s.pending))
+	for _, c := range s.pending {
+		changes = append(changes, *c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].seq < changes[j].seq })
+	return changes
+}
+
+// Approve applies the pending change id on behalf of actor, who must not have requested it.
+// The other fields of the module keep their current values, including edits made since
+// the change was requested.
+func (s *Service) Approve(ctx context.Context, actor, id string) (*Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, ok := s.pending[id]
+	if !ok {
+		return nil, fmt.Errorf("change %q: %w", id, ErrNotFound)
+	}
+	if actor == change.RequestedBy {
+		return nil, ErrSelfApproval
+	}
+	if now := s.now(); now.Before(change.ApprovableAt) {
+		return nil, fmt.Errorf("%w until %s", ErrCoolingDown, change.ApprovableAt.UTC().Format(time.RFC3339))
+	}
+	if current := s.svc.Resolve(ctx, change.Path); !sameTarget(current, change.Before) {
+		return nil, fmt.Errorf("%w: %s now resolves to %s", ErrConflict, change.Path, current.Repository)
+	}
+
+	cfg := s.svc.Config()
+	i := moduleIndex(cfg, change.Path)
+	switch {
+	case change.Remove:
+		setEntries(cfg, change.Path, nil, nil)
+	case change.alias != nil:
+		setEntries(cfg, change.Path, nil, change.alias)
+	case i >= 0:
+		cfg.Modules[i].Repository, cfg.Modules[i].VCS = change.module.Repository, change.module.VCS
+	default:
+		setEntries(cfg, change.Path, &change.module, nil)
+	}
+	source := fmt.Sprintf("change %s requested by %s", change.ID, change.RequestedBy)
+	if err := s.apply(ctx, actor, source, cfg); err != nil {
+		return nil, err
+	}
+	delete(s.pending, id)
+	return change, nil
+}
+
+// Cancel drops the pending change id. Any admin may cancel a change.
+func (s *Service) Cancel(id string) (*Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change, ok := s.pending[id]
+	if !ok {
+		return nil, fmt.Errorf("change %q: %w", id, ErrNotFound)
+	}
+	delete(s.pending, id)
+	return change, nil
+}
+
+// Versions returns the versions of the module registry kept in the history, oldest first.
+func (s *Service) Versions() []Version {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.history.Versions()
+}
+
+// Diff returns the import paths registered differently by the versions numbered from and to.
+func (s *Service) Diff(ctx context.Context, from, to int) ([]PathChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, err := s.history.Version(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.history.Version(to)
+	if err != nil {
+		return nil, err
+	}
+	return DiffVersions(ctx, s.svc, before, after)
+}
+
+// RollbackResult is the outcome of a rollback.
+type RollbackResult struct {
+	// Version is the latest version once the rest of the rollback is applied: the one it
+	// added to the history, unless it changed nothing at once.
+	Version
+	// Pending are the changes of repository or VCS of the rollback, waiting for approval.
+	Pending []Change `json:"pending,omitempty"`
+	// Warnings are the warnings of the verification of the repositories the rollback changes.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Rollback applies the registry of the version numbered n on behalf of actor. Like an
+// edit, the modules it points at another repository or VCS are verified first, and each
+// path it makes resolve to another repository or VCS keeps its current one until another
+// admin approves the pending change returned for it; the rest applies at once, as a new
+// version. Like any applied state, it becomes the latest version; the versions in between
+// are kept.
+func (s *Service) Rollback(ctx context.Context, actor string, n int) (*RollbackResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, err := s.history.Version(n)
+	if err != nil {
+		return nil, err
+	}
+	preview, err := s.svc.Preview(ctx, v.Registry)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	warnings, err := s.verify(ctx, v.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	current := s.svc.Config()
+	immediate := &gosvc.Config{
+		Modules:  slices.Clone(v.Registry.Modules),
+		Aliases:  slices.Clone(v.Registry.Aliases),
+		Policies: slices.Clone(v.Registry.Policies),
+	}
+	var changes []*Change
+	for _, c := range gosvc.Diff(s.svc.Registered(), preview.Registered()) {
+		path := strings.TrimPrefix(c.ImportPath, s.svc.Domain()+"/")
+		before, after := s.svc.Resolve(ctx, path), preview.Resolve(ctx, path)
+		if sameTarget(before, after) {
+			continue
+		}
+		if err := s.checkPending(path); err != nil {
+			return nil, err
+		}
+		mc, ac := entriesAt(v.Registry, path)
+		currentModule, currentAlias := entriesAt(current, path)
+		change := &Change{Path: path, Before: before, After: after, Remove: mc == nil && ac == nil, alias: ac}
+		if mc != nil {
+			change.module = *mc
+		}
+		// The path keeps resolving as now; the other fields of a module roll back at once.
+		if mc != nil && currentModule != nil {
+			held := *mc
+			held.Repository, held.VCS = currentModule.Repository, currentModule.VCS
+			setEntries(immediate, path, &held, nil)
+		} else {
+			setEntries(immediate, path, currentModule, currentAlias)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := s.apply(ctx, actor, fmt.Sprintf("rollback to version %d", n), immediate); err != nil {
+		return nil, err
+	}
+	latest, _ := s.history.Latest()
+	res := &RollbackResult{Version: latest, Warnings: warnings}
+	for _, change := range changes {
+		res.Pending = append(res.Pending, *s.request(actor, change, change.module))
+	}
+	return res, nil
+}
+
+// entriesAt returns the module and the alias cfg registers under path, if any.
+func entriesAt(cfg *gosvc.Config, path string) (*gosvc.ModuleConfig, *gosvc.AliasConfig) {
+	var mc *gosvc.ModuleConfig
+	if i := moduleIndex(cfg, path); i >= 0 {
+		mc = &cfg.Modules[i]
+	}
+	for i := range cfg.Aliases {
+		if cfg.Aliases[i].Path == path {
+			return mc, &cfg.Aliases[i]
+		}
+	}
+	return mc, nil
+}
+
+// setEntries makes cfg register mc and ac under path, each replacing the module or alias
+// registered there, or removing it when nil. Both are copied.
+func setEntries(cfg *gosvc.Config, path string, mc *gosvc.ModuleConfig, ac *gosvc.AliasConfig) {
+	var module *gosvc.ModuleConfig
+	if mc != nil {
+		m := *mc
+		module = &m
+	}
+	var alias *gosvc.AliasConfig
+	if ac != nil {
+		a := *ac
+		alias = &a
+	}
+
+	modules := cfg.Modules[:0:0]
+	for _, m := range cfg.Modules {
+		switch {
+		case m.Path != path:
+			modules = append(modules, m)
+		case module != nil:
+			modules, module = append(modules, *module), nil
+		}
+	}
+	if module != nil {
+		modules = append(modules, *module)
+	}
+	aliases := cfg.Aliases[:0:0]
+	for _, a := range cfg.Aliases {
+		switch {
+		case a.Path != path:
+			aliases = append(aliases, a)
+		case alias != nil:
+			aliases, alias = append(aliases, *alias), nil
+		}
+	}
+	if alias != nil {
+		aliases = append(aliases, *alias)
+	}
+	cfg.Modules, cfg.Aliases = modules, aliases
+}
+
+// target validates cfg and returns the change of the repository or VCS that path
+// resolves to with cfg, or nil when it resolves to the same ones as now.
+func (s *Service) target(ctx context.Context, cfg *gosvc.Config, path string) (*Change, error) {
+	preview, err := s.svc.Preview(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	before, after := s.svc.Resolve(ctx, path), preview.Resolve(ctx, path)
+	if sameTarget(before, after) {
+		return nil, nil
+	}
+	return &Change{Path: path, Before: before, After: after}, nil
+}
+
+// verify verifies the modules cfg registers with another repository or VCS than now,
+// and returns the warnings. A rejection is returned as an error wrapping ErrInvalid.
+func (s *Service) verify(ctx context.Context, cfg *gosvc.Config) ([]string, error) {
+	if s.verifier == nil {
+		return nil, nil
+	}
+	preview, err := s.svc.Preview(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	var modules []gosvc.Module
+	for _, c := range gosvc.Diff(s.svc.Modules(), preview.Modules()) {
+		if c.After != nil && (c.Before == nil || !sameTarget(*c.Before, *c.After)) {
+			modules = append(modules, *c.After)
+		}
+	}
+	warnings, err := s.verifier.Verify(ctx, modules)
+	if err != nil {
+		return warnings, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	return warnings, nil
+}
+
+// sameTarget reports whether a and b fetch the code from the same repository with the same VCS.
+func sameTarget(a, b gosvc.Module) bool {
+	return a.Repository == b.Repository && a.VCS == b.VCS
+}
+
+// checkPending returns ErrPending when a change of path is already pending.
+func (s *Service) checkPending(path string) error {
+	for _, c := range s.pending {
+		if c.Path == path {
+			return fmt.Errorf("module %q: %w (change %s)", path, ErrPending, c.ID)
+		}
+	}
+	return nil
+}
+
+// request records change, with the module configuration it applies, as pending
+// approval on behalf of actor, and returns a copy of it.
+// The caller checked that no change of its path is pending.
+func (s *Service) request(actor string, change *Change, mc gosvc.ModuleConfig) *Change {
+	s.lastID++
+	change.ID, change.seq = strconv.Itoa(s.lastID), s.lastID
+	change.RequestedBy = actor
+	change.RequestedAt = s.now().UTC()
+	change.ApprovableAt = change.RequestedAt.Add(s.cooldown)
+	change.module = mc
+	s.pending[change.ID] = change
+	c := *change
+	return &c
+}
+
+// apply writes cfg to the registry file and loads it, adding the modules it changes
+// to the audit event of ctx, and records it as a version applied by actor from source.
+func (s *Service) apply(ctx context.Context, actor, source string, cfg *gosvc.Config) error {
+	before := s.svc.Registered()
+	preview, err := s.svc.Preview(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if err := s.write(cfg); err != nil {
+		return err
+	}
+	if err := s.svc.Load(ctx, cfg); err != nil {
+		return err
+	}
+	for _, c := range auditChanges(gosvc.Diff(before, preview.Registered())) {
+		audit.AddChange(ctx, c)
+	}
+	_, err = s.record(actor, source)
+	return err
+}
+
+// record adds the loaded registry to the history as a version applied by author from source.
+func (s *Service) record(author, source string) (Version, error) {
+	return s.history.add(Version{Time: s.now().UTC(), Author: author, Source: source, Registry: s.svc.Config()})
+}
+
+// write replaces the registry file with cfg, keeping its permissions.
+func (s *Service) write(cfg *gosvc.Config) error {
+	if s.path == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := gosvc.WriteConfig(&buf, cfg); err != nil {
+		return err
+	}
+	if err := writeFile(s.path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write module registry: %w", err)
+	}
+	return nil
+}
+
+// writeFile replaces the file at path with data, keeping its permissions, or creating it
+// with mode. The data is written next to it and renamed, so readers never see a partial file.
+func writeFile(path string, data []byte, mode os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// registered returns the registered module at path, or nil if there is none.
+func (s *Service) registered(path string) *gosvc.Module {
+	importPath := s.svc.Domain() + "/" + path
+	for _, m := range s.svc.Modules() {
+		if m.ImportPath == importPath {
+			return &m
+		}
+	}
+	return nil
+}
+
+// moduleIndex returns the index of the module registered under path in cfg, or -1.
+func moduleIndex(cfg *gosvc.Config, path string) int {
+	return slices.IndexFunc(cfg.Modules, func(mc gosvc.ModuleConfig) bool { return mc.Path == path })
+}
+
+// auditChanges converts module changes to audited changes.
+func auditChanges(changes []gosvc.ModuleChange) []audit.Change {
+	var audited []audit.Change
+	for _, c := range changes {
+		change := audit.Change{Target: c.ImportPath}
+		if c.Before != nil {
+			change.Before = c.Before
+		}
+		if c.After != nil {
+			change.After = c.After
+		}
+		audited = append(audited, change)
+	}
+	return audited
+}
@@ -0,0 +1,328 @@
+package adminsvc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/audit"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+const registry = `modules:
+  - path: app
+  - path: tools
+    repository: https://gitlab.com/gllm-dev/tools
+`
+
+// newTestService returns a Service over a registry file holding the registry above,
+// with the given cool-down and a clock that can be moved forward.
+func newTestService(t *testing.T, cooldown time.Duration) (*Service, *time.Time, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "modules.yaml")
+	if err := os.WriteFile(path, []byte(registry), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	cfg, err := gosvc.ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Load(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(&Config{ApprovalCooldown: cooldown, HistoryRetention: 10}, svc, path, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now, path
+}
+
+func TestService_PutModule_Metadata(t *testing.T) {
+	ctx := context.Background()
+	s, _, path := newTestService(t, 0)
+
+	m, change, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "app", Status: "deprecated", Replacement: "go.gllm.dev/tools"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change != nil {
+		t.Errorf("PutModule() pending = %+v, want metadata applied immediately", change)
+	}
+	if m == nil || m.Status != gosvc.StatusDeprecated {
+		t.Errorf("PutModule() module = %+v, want deprecated", m)
+	}
+
+	cfg, err := gosvc.ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Modules) != 2 || cfg.Modules[0].Status != "deprecated" {
+		t.Errorf("registry file modules = %+v, want the change written", cfg.Modules)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("registry file mode = %v, want it kept", info.Mode().Perm())
+	}
+}
+
+func TestService_PutModule_Invalid(t *testing.T) {
+	s, _, _ := newTestService(t, 0)
+
+	_, _, _, err := s.PutModule(context.Background(), "alice", gosvc.ModuleConfig{Path: "app", VCS: "cvs"})
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("PutModule() error = %v, want ErrInvalid", err)
+	}
+}
+
+func TestService_PutModule_Approval(t *testing.T) {
+	ctx := context.Background()
+	s, now, _ := newTestService(t, time.Hour)
+
+	m, change, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "tools", Repository: "https://github.com/evil/tools", Status: "archived"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change == nil {
+		t.Fatal("PutModule() should leave the repository change pending")
+	}
+	if m.Repository != "https://gitlab.com/gllm-dev/tools" || m.Status != gosvc.StatusArchived {
+		t.Errorf("PutModule() module = %+v, want the status applied and the repository kept", m)
+	}
+	if change.Before.Repository != "https://gitlab.com/gllm-dev/tools" || change.After.Repository != "https://github.com/evil/tools" || change.RequestedBy != "alice" {
+		t.Errorf("pending change = %+v", change)
+	}
+	if got := s.svc.Resolve(ctx, "tools").Repository; got != "https://gitlab.com/gllm-dev/tools" {
+		t.Errorf("repository before approval = %q", got)
+	}
+
+	if _, _, _, err := s.PutModule(ctx, "bob", gosvc.ModuleConfig{Path: "tools", Repository: "https://github.com/other/tools"}); !errors.Is(err, ErrPending) {
+		t.Errorf("second change error = %v, want ErrPending", err)
+	}
+	if _, err := s.Approve(ctx, "alice", change.ID); !errors.Is(err, ErrSelfApproval) {
+		t.Errorf("Approve() by the requester error = %v, want ErrSelfApproval", err)
+	}
+	if _, err := s.Approve(ctx, "bob", change.ID); !errors.Is(err, ErrCoolingDown) {
+		t.Errorf("Approve() during the cool-down error = %v, want ErrCoolingDown", err)
+	}
+
+	// Metadata edits made while the change is pending are kept on approval.
+	if _, _, _, err := s.PutModule(ctx, "carol", gosvc.ModuleConfig{Path: "tools", Repository: "https://gitlab.com/gllm-dev/tools", Status: "deprecated"}); err != nil {
+		t.Fatal(err)
+	}
+
+	*now = now.Add(time.Hour)
+	if _, err := s.Approve(ctx, "bob", change.ID); err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	got := s.svc.Resolve(ctx, "tools")
+	if got.Repository != "https://github.com/evil/tools" || got.Status != gosvc.StatusDeprecated {
+		t.Errorf("module after approval = %+v", got)
+	}
+	if changes := s.Changes(); len(changes) != 0 {
+		t.Errorf("Changes() = %+v, want none left", changes)
+	}
+}
+
+func TestService_PutModule_NewModuleShadowingFallback(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTestService(t, 0)
+
+	// Unregistered paths resolve under the repository base URL; pointing one elsewhere needs approval.
+	m, change, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "new", Repository: "https://github.com/evil/new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil || change == nil {
+		t.Fatalf("PutModule() = %+v, %+v; want the new module wholly pending", m, change)
+	}
+	if _, err := s.Approve(ctx, "bob", change.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.svc.Resolve(ctx, "new").Repository; got != "https://github.com/evil/new" {
+		t.Errorf("repository after approval = %q", got)
+	}
+
+	// A new module at its default repository changes nothing and applies immediately.
+	if _, change, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "other"}); err != nil || change != nil {
+		t.Errorf("PutModule() default repository = %+v, %v; want applied", change, err)
+	}
+}
+
+func TestService_DeleteModule(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTestService(t, 0)
+
+	// app is at its default repository, so removing it changes nothing.
+	if change, err := s.DeleteModule(ctx, "alice", "app"); err != nil || change != nil {
+		t.Errorf("DeleteModule(app) = %+v, %v; want removed immediately", change, err)
+	}
+	if len(s.svc.Modules()) != 1 {
+		t.Errorf("modules = %+v, want app removed", s.svc.Modules())
+	}
+
+	change, err := s.DeleteModule(ctx, "alice", "tools")
+	if err != nil || change == nil || !change.Remove {
+		t.Fatalf("DeleteModule(tools) = %+v, %v; want a pending removal", change, err)
+	}
+	if _, err := s.Cancel(change.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(ctx, "bob", change.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Approve() cancelled change error = %v, want ErrNotFound", err)
+	}
+	if len(s.svc.Modules()) != 1 {
+		t.Errorf("modules = %+v, want tools kept", s.svc.Modules())
+	}
+
+	if _, err := s.DeleteModule(ctx, "alice", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteModule(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_Approve_Conflict(t *testing.T) {
+	ctx := context.Background()
+	s, _, path := newTestService(t, 0)
+
+	_, change, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "tools", Repository: "https://github.com/evil/tools"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The registry file is edited and reloaded while the change is pending.
+	edited := strings.Replace(registry, "gitlab.com/gllm-dev", "gitlab.com/moved", 1)
+	if err := os.WriteFile(path, []byte(edited), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(ctx, "SIGHUP"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Approve(ctx, "bob", change.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("Approve() error = %v, want ErrConflict", err)
+	}
+}
+
+func TestService_Reload_Audit(t *testing.T) {
+	ctx := context.Background()
+	s, _, path := newTestService(t, 0)
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	s.audit = log
+
+	if err := os.WriteFile(path, []byte(registry+"  - path: new\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(ctx, "SIGHUP"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("modules: [{path: x, vcs: cvs}]\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(ctx, "SIGHUP"); err == nil {
+		t.Fatal("Reload() of an invalid registry should fail")
+	}
+
+	events, err := log.Query(audit.Query{Action: "reload"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("recorded %d reloads, want 2", len(events))
+	}
+	if e := events[0]; e.Source != "SIGHUP" || len(e.Changes) != 1 || e.Changes[0].Target != "go.gllm.dev/new" || e.Changes[0].Before != nil {
+		t.Errorf("reload event = %+v, want the added module", e)
+	}
+	if e := events[1]; e.Error == "" || len(e.Changes) != 0 {
+		t.Errorf("failed reload event = %+v, want its error and no changes", e)
+	}
+}
+
+// fakeVerifier records the modules it verifies, rejects those registered under a
+// repository of reject, and warns about every other one.
+type fakeVerifier struct {
+	reject   string
+	verified []string
+}
+
+func (v *fakeVerifier) Verify(_ context.Context, modules []gosvc.Module) ([]string, error) {
+	var warnings []string
+	var errs []error
+	for _, m := range modules {
+		v.verified = append(v.verified, m.ImportPath)
+		if v.reject != "" && strings.HasPrefix(m.Repository, v.reject) {
+			errs = append(errs, errors.New(m.ImportPath+": rejected"))
+		} else {
+			warnings = append(warnings, m.ImportPath+": warning")
+		}
+	}
+	return warnings, errors.Join(errs...)
+}
+
+func TestService_Verify(t *testing.T) {
+	ctx := context.Background()
+	s, _, path := newTestService(t, 0)
+	v := &fakeVerifier{reject: "https://github.com/evil"}
+	s.verifier = v
+
+	// Metadata edits of registered modules are not verified.
+	_, _, warnings, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "app", Status: "deprecated"})
+	if err != nil || len(warnings) != 0 || len(v.verified) != 0 {
+		t.Errorf("PutModule() metadata = %q, %v; verified %q, want nothing verified", warnings, err, v.verified)
+	}
+
+	_, _, warnings, err = s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "new"})
+	if err != nil || len(warnings) != 1 || warnings[0] != "go.gllm.dev/new: warning" {
+		t.Errorf("PutModule() new = %q, %v; want its warning", warnings, err)
+	}
+
+	_, change, _, err := s.PutModule(ctx, "alice", gosvc.ModuleConfig{Path: "tools", Repository: "https://github.com/evil/tools"})
+	if !errors.Is(err, ErrInvalid) || change != nil {
+		t.Errorf("PutModule() rejected = %+v, %v; want ErrInvalid", change, err)
+	}
+	if got := strings.Join(v.verified, " "); got != "go.gllm.dev/new go.gllm.dev/tools" {
+		t.Errorf("verified %q, want new and tools", got)
+	}
+
+	v.verified = nil
+	edited := strings.Replace(registry, "gitlab.com/gllm-dev", "github.com/evil", 1)
+	if err := os.WriteFile(path, []byte(edited), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(ctx, "SIGHUP"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Reload() error = %v, want ErrInvalid", err)
+	}
+	if got := s.svc.Resolve(ctx, "tools").Repository; got != "https://gitlab.com/gllm-dev/tools" {
+		t.Errorf("repository after a rejected reload = %q", got)
+	}
+	// new was removed from the file, and app left unchanged.
+	if got := strings.Join(v.verified, " "); got != "go.gllm.dev/tools" {
+		t.Errorf("verified %q on reload, want tools", got)
+	}
+}
+
+func TestNew_NoStartupVerification(t *testing.T) {
+	s, _, path := newTestService(t, 0)
+
+	// Even a verifier rejecting every module does not stop the server.
+	v := &fakeVerifier{reject: "https://"}
+	if _, err := New(&Config{HistoryRetention: 10}, s.svc, path, nil, v); err != nil {
+		t.Fatalf("New() error = %v, want the server to start", err)
+	}
+	if len(v.verified) != 0 {
+		t.Errorf("verified %q at startup, want nothing verified", v.verified)
+	}
+}
@@ -0,0 +1,20 @@
+package docsvc
+
+// Config holds the configuration of the documentation rendered for the registered modules.
+type Config struct {
+	// Dir is the directory holding a checkout of each module at the module path relative
+	// to the vanity domain (e.g., "<Dir>/tools" for "go.gllm.dev/tools"). Documentation
+	// is not rendered when it is empty.
+	Dir string
+}
+
+// DefaultConfig returns the documentation configuration used when nothing is overridden.
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+// Validate reports every invalid value of the configuration.
+// Any directory is valid: modules without a checkout in it simply get no documentation.
+func (c *Config) Validate() error {
+	return nil
+}
@@ -0,0 +1,214 @@
+package docsvc
+
+import (
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/doc/comment"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// buildContext selects the files of a package like pkg.go.dev does, for linux/amd64.
+var buildContext = func() build.Context {
+	c := build.Default
+	c.GOOS, c.GOARCH, c.CgoEnabled = "linux", "amd64", true
+	return c
+}()
+
+// packageDoc is the documentation of a package, ready to be rendered.
+type packageDoc struct {
+	// ImportPath is the import path of the package.
+	ImportPath string
+	// Name is the package name.
+	Name string
+	// Synopsis is the first sentence of the package documentation.
+	Synopsis string
+	// Doc is the package documentation.
+	Doc template.HTML
+	// Consts and Vars are the exported constants and variables not associated with a type.
+	Consts, Vars []valueDoc
+	// Funcs are the exported functions not associated with a type.
+	Funcs []funcDoc
+	// Types are the exported types, with their constants, variables, constructors and methods.
+	Types []typeDoc
+}
+
+// valueDoc documents a declaration of constants or variables.
+type valueDoc struct {
+	Code string
+	Doc  template.HTML
+}
+
+// funcDoc documents a function or a method; ID is its anchor on the page, the one
+// doc links use ("Name" or "Type.Name").
+type funcDoc struct {
+	ID   string
+	Name string
+	Code string
+	Doc  template.HTML
+}
+
+// typeDoc documents a type declaration.
+type typeDoc struct {
+	funcDoc
+	Consts, Vars   []valueDoc
+	Funcs, Methods []funcDoc
+}
+
+// findPackages returns the directories of the packages of the module in dir, relative to
+// dir in slash-separated form, "." being the module root. Like the go command, it skips
+// testdata, vendor and directories starting with "." or "_", and nested modules.
+// Directories without Go files are listed too; readPackage tells them apart.
+func findPackages(dir string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if p != dir {
+			name := d.Name()
+			if name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, filepath.ToSlash(rel))
+		return nil
+	})
+	return dirs, err
+}
+
+// readPackage returns the documentation of the package in dir, whose import path is
+// importPath, or nil when dir holds no Go package. Doc links to packages under domain
+// point to their pages on the server, and to pkg.go.dev otherwise.
+func readPackage(domain, importPath, dir string) (*packageDoc, error) {
+	bp, err := buildContext.ImportDir(dir, 0)
+	if _, ok := err.(*build.NoGoError); ok {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	dp, err := doc.NewFromFiles(fset, files, importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &renderer{fset: fset, pkg: dp, printer: dp.Printer()}
+	r.printer.DocLinkURL = func(link *comment.DocLink) string {
+		return docLinkURL(domain, link)
+	}
+	p := &packageDoc{
+		ImportPath: importPath,
+		Name:       dp.Name,
+		Synopsis:   dp.Synopsis(dp.Doc),
+		Doc:        r.doc(dp.Doc),
+		Consts:     r.values(dp.Consts),
+		Vars:       r.values(dp.Vars),
+		Funcs:      r.funcs(dp.Funcs, ""),
+	}
+	for _, t := range dp.Types {
+		decl := *t.Decl
+		decl.Doc = nil
+		p.Types = append(p.Types, typeDoc{
+			funcDoc: funcDoc{ID: t.Name, Name: t.Name, Code: r.code(&decl), Doc: r.doc(t.Doc)},
+			Consts:  r.values(t.Consts),
+			Vars:    r.values(t.Vars),
+			Funcs:   r.funcs(t.Funcs, ""),
+			Methods: r.funcs(t.Methods, t.Name),
+		})
+	}
+	return p, nil
+}
+
+// docLinkURL returns the URL of a doc link: an anchor on the same page, the page of a
+// package under domain on the server, or the page of another package on pkg.go.dev.
+func docLinkURL(domain string, link *comment.DocLink) string {
+	fragment := ""
+	if link.Name != "" {
+		fragment = "#" + link.Name
+		if link.Recv != "" {
+			fragment = "#" + link.Recv + "." + link.Name
+		}
+	}
+	switch {
+	case link.ImportPath == "":
+		return fragment
+	case link.ImportPath == domain || strings.HasPrefix(link.ImportPath, domain+"/"):
+		return path.Join("/", strings.TrimPrefix(link.ImportPath, domain)) + fragment
+	default:
+		return "https://pkg.go.dev/" + link.ImportPath + fragment
+	}
+}
+
+// renderer turns the declarations and comments of a package into their documentation.
+type renderer struct {
+	fset    *token.FileSet
+	pkg     *doc.Package
+	printer *comment.Printer
+}
+
+// doc renders a doc comment as HTML.
+func (r *renderer) doc(text string) template.HTML {
+	// The comment printer escapes the text of the comment.
+	return template.HTML(r.printer.HTML(r.pkg.Parser().Parse(text)))
+}
+
+// code formats a declaration like gofmt.
+func (r *renderer) code(node ast.Node) string {
+	var b strings.Builder
+	// Declarations parsed from files always print, and a strings.Builder never fails.
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	_ = cfg.Fprint(&b, r.fset, node)
+	return b.String()
+}
+
+// values documents declarations of constants or variables.
+func (r *renderer) values(values []*doc.Value) []valueDoc {
+	docs := make([]valueDoc, 0, len(values))
+	for _, v := range values {
+		decl := *v.Decl
+		decl.Doc = nil
+		docs = append(docs, valueDoc{Code: r.code(&decl), Doc: r.doc(v.Doc)})
+	}
+	return docs
+}
+
+// funcs documents functions, or the methods of the type recv.
+func (r *renderer) funcs(funcs []*doc.Func, recv string) []funcDoc {
+	docs := make([]funcDoc, 0, len(funcs))
+	for _, f := range funcs {
+		decl := *f.Decl
+		decl.Doc, decl.Body = nil, nil
+		id := f.Name
+		if recv != "" {
+			id = recv + "." + f.Name
+		}
+		docs = append(docs, funcDoc{ID: id, Name: f.Name, Code: r.code(&decl), Doc: r.doc(f.Doc)})
+	}
+	return docs
+}
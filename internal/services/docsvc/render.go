@@ -0,0 +1,161 @@
+package docsvc
+
+import (
+	"html/template"
+
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// pageData is what a documentation page is rendered from.
+type pageData struct {
+	// Domain is the vanity domain.
+	Domain string
+	// ImportPath is the import path of the page.
+	ImportPath string
+	// Page is the page of the module the page belongs to, and ModuleURL the
+	// documentation of its root.
+	Page      *gosvc.Page
+	ModuleURL string
+	// Package is the documentation of the package at ImportPath; nil when the directory
+	// only holds other packages.
+	Package *packageDoc
+	// Dirs are the packages below ImportPath in the module.
+	Dirs []dirEntry
+}
+
+// Notices returns the banners of the page of the module: its notices, known
+// vulnerabilities and versions, rendered and escaped by gosvc.
+func (d pageData) Notices() template.HTML {
+	return template.HTML(d.Page.Notices)
+}
+
+// dirEntry links to the page of a package.
+type dirEntry struct {
+	URL        string
+	ImportPath string
+	Synopsis   string
+}
+
+// indexData is what the index of the domain is rendered from.
+type indexData struct {
+	Domain  string
+	Modules []indexEntry
+}
+
+// indexEntry links to the documentation of a module, on the server when it was rendered
+// and on pkg.go.dev otherwise.
+type indexEntry struct {
+	gosvc.Module
+	URL string
+}
+
+// style is shared by every page, which is served on its own.
+const style = `<style>
+body { font-family: sans-serif; max-width: 60em; margin: 1em auto; padding: 0 1em; line-height: 1.4; }
+pre { background: #f4f4f4; padding: 0.5em; overflow-x: auto; }
+table { border-collapse: collapse; }
+td { padding: 0.2em 1em 0.2em 0; vertical-align: top; }
+</style>`
+
+// pageTemplate renders the documentation of a package and the packages below it.
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{with .Package}}{{.Name}} package - {{end}}{{.ImportPath}}</title>
+` + style + `
+</head>
+<body>
+<p><a href="/">{{.Domain}}</a></p>
+{{with .Package}}<h1>package {{.Name}}</h1>{{else}}<h1>{{.ImportPath}}</h1>{{end}}
+{{- with .Package}}{{if ne .Name "main"}}
+<pre>import "{{.ImportPath}}"</pre>{{end}}{{end}}
+<p>Module <a href="{{.ModuleURL}}">{{.Page.Module.ImportPath}}</a>, repository <a href="{{.Page.Module.Repository}}">{{.Page.Module.Repository}}</a></p>
+{{.Notices}}
+{{- with .Package}}
+{{- with .Doc}}
+<h2 id="pkg-overview">Overview</h2>
+{{.}}
+{{- end}}
+{{- if .Consts}}
+<h2 id="pkg-constants">Constants</h2>
+{{- range .Consts}}
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{- end}}
+{{- end}}
+{{- if .Vars}}
+<h2 id="pkg-variables">Variables</h2>
+{{- range .Vars}}
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{- end}}
+{{- end}}
+{{- if .Funcs}}
+<h2 id="pkg-functions">Functions</h2>
+{{- range .Funcs}}
+<h3 id="{{.ID}}">func {{.Name}}</h3>
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{- end}}
+{{- end}}
+{{- if .Types}}
+<h2 id="pkg-types">Types</h2>
+{{- range $type := .Types}}
+<h3 id="{{.ID}}">type {{.Name}}</h3>
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{- range .Consts}}
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{- end}}
+{{- range .Vars}}
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{- end}}
+{{- range .Funcs}}
+<h4 id="{{.ID}}">func {{.Name}}</h4>
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{- end}}
+{{- range .Methods}}
+<h4 id="{{.ID}}">func ({{$type.Name}}) {{.Name}}</h4>
+<pre>{{.Code}}</pre>
+{{.Doc}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Dirs}}
+<h2 id="pkg-directories">Directories</h2>
+<table>
+{{- range .Dirs}}
+<tr><td><a href="{{.URL}}">{{.ImportPath}}</a></td><td>{{.Synopsis}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// indexTemplate lists the modules of the domain, linking each one to its documentation.
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Domain}}</title>
+` + style + `
+</head>
+<body>
+<h1>{{.Domain}}</h1>
+<ul>
+{{- range .Modules}}
+<li><a href="{{.URL}}">{{.ImportPath}}</a> ({{.VCS}}: <a href="{{.Repository}}">{{.Repository}}</a>)
+{{- if eq .Status "deprecated"}} deprecated{{with .Replacement}}, use <a href="https://pkg.go.dev/{{.}}">{{.}}</a>{{end}}{{end}}</li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
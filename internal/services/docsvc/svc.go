@@ -0,0 +1,267 @@
+// Package docsvc renders the documentation of the packages of the registered modules
+// from local checkouts, with go/doc, for the browsers visiting their import paths.
+//
+// Pages are rendered in the background whenever the module registry is loaded, and
+// replaced as a whole, so requests are answered from memory without reading any file.
+// Like the landing pages they replace, they show the notices, advisories and versions
+// of their module, rendered again whenever those change.
+// The index of the domain lists the modules, linking to their documentation.
+package docsvc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// Page is a rendered documentation page. Pages are shared between requests and must
+// not be modified.
+type Page struct {
+	// HTML is the rendered page.
+	HTML []byte
+	// Header holds the Content-Type and the strong ETag of the page.
+	Header http.Header
+}
+
+// Service renders the documentation of the modules of a gosvc.Service.
+type Service struct {
+	// svc is the service the modules are read from.
+	svc *gosvc.Service
+	// dir holds the checkouts of the modules.
+	dir string
+	// logger reports the packages that could not be rendered.
+	logger *slog.Logger
+	// pages are the rendered pages by request path relative to the vanity domain,
+	// "" being the index of the domain.
+	pages atomic.Pointer[map[string]*Page]
+
+	// mu serializes the renderings.
+	mu sync.Mutex
+	// modules are the documentation of the rendered modules by path, kept to render
+	// their pages again when the page of the module changes.
+	modules map[string]*moduleDocs
+}
+
+// moduleDocs is the documentation of a module, read from its checkout.
+type moduleDocs struct {
+	// page is the page of the module the documentation was last rendered with.
+	page *gosvc.Page
+	// data are what its pages are rendered from, by request path.
+	data map[string]pageData
+}
+
+// New creates a Service rendering the documentation of the modules of svc from their
+// checkouts in the directory of cfg. Nothing is rendered before Generate or Run.
+func New(cfg *Config, svc *gosvc.Service, logger *slog.Logger) *Service {
+	return &Service{svc: svc, dir: cfg.Dir, logger: logger}
+}
+
+// Page returns the documentation page of the request path, relative to the vanity
+// domain, or false when there is none. The empty path is the index of the domain.
+// It does not allocate.
+func (s *Service) Page(path string) (*Page, bool) {
+	pages := s.pages.Load()
+	if pages == nil {
+		return nil, false
+	}
+	page, ok := (*pages)[strings.TrimSuffix(path, "/")]
+	return page, ok
+}
+
+// Run renders the documentation at once and again whenever the modules are loaded,
+// so that registry reloads also pick up updated checkouts, until ctx is done. In
+// between, the pages of the modules whose page changes are rendered again, without
+// reading their checkouts.
+func (s *Service) Run(ctx context.Context) {
+	for {
+		loaded, rendered := s.svc.Loaded(), s.svc.Rendered()
+		if err := s.Generate(ctx); err != nil && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "Documentation rendering failed", slog.String("error", err.Error()))
+		}
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-loaded:
+				break wait
+			case <-rendered:
+				rendered = s.svc.Rendered()
+				if err := s.refresh(ctx); err != nil {
+					s.logger.ErrorContext(ctx, "Documentation rendering failed", slog.String("error", err.Error()))
+				}
+			}
+		}
+	}
+}
+
+// Generate renders the documentation of every registered module but the hidden ones
+// from its checkout, and the index of the domain, then replaces the pages served.
+// Modules without a checkout get no documentation, and packages that cannot be read
+// are logged and left out.
+func (s *Service) Generate(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domain := s.svc.Domain()
+	pages := make(map[string]*Page)
+	modules := make(map[string]*moduleDocs)
+	index := indexData{Domain: domain, Modules: []indexEntry{}}
+	for _, m := range s.svc.Modules() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if m.Status == gosvc.StatusHidden {
+			continue
+		}
+		path := strings.TrimPrefix(m.ImportPath, domain+"/")
+		docs := s.readModule(ctx, domain, path, m)
+		if docs != nil {
+			if err := s.renderModule(ctx, pages, path, docs); err != nil {
+				return err
+			}
+			modules[path] = docs
+		}
+		// Restricted modules are documented behind their access policy, but the index
+		// is public and does not name them.
+		if !m.Status.Listed() || s.svc.Policy(path) != nil {
+			continue
+		}
+		entry := indexEntry{Module: m, URL: "https://pkg.go.dev/" + m.ImportPath}
+		if docs != nil {
+			entry.URL = "/" + path
+		}
+		index.Modules = append(index.Modules, entry)
+	}
+
+	var b bytes.Buffer
+	if err := indexTemplate.Execute(&b, index); err != nil {
+		return fmt.Errorf("failed to render index: %w", err)
+	}
+	pages[""] = newPage(b.Bytes())
+	s.modules = modules
+	s.pages.Store(&pages)
+	s.logger.DebugContext(ctx, "Documentation rendered", slog.Int("pages", len(pages)))
+	return nil
+}
+
+// refresh renders the pages of the modules whose page changed since they were
+// rendered again, from the documentation already read, and replaces the pages served.
+func (s *Service) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.pages.Load()
+	if current == nil {
+		return nil
+	}
+	var pages map[string]*Page
+	for path, docs := range s.modules {
+		if s.svc.Page(ctx, path) == docs.page {
+			continue
+		}
+		if pages == nil {
+			pages = maps.Clone(*current)
+		}
+		if err := s.renderModule(ctx, pages, path, docs); err != nil {
+			return err
+		}
+	}
+	if pages != nil {
+		s.pages.Store(&pages)
+	}
+	return nil
+}
+
+// readModule reads the documentation of the module m, registered at path: one page for
+// each package and each directory holding packages, including the module root. It
+// returns nil when the module has no checkout with packages.
+func (s *Service) readModule(ctx context.Context, domain, path string, m gosvc.Module) *moduleDocs {
+	root := filepath.Join(s.dir, filepath.FromSlash(path))
+	dirs, err := findPackages(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "Module checkout reading failed", slog.String("module", m.ImportPath), slog.String("error", err.Error()))
+		return nil
+	}
+
+	packages := make(map[string]*packageDoc, len(dirs))
+	for _, dir := range dirs {
+		p, err := readPackage(domain, join(m.ImportPath, dir), filepath.Join(root, filepath.FromSlash(dir)))
+		if err != nil {
+			s.logger.WarnContext(ctx, "Package documentation rendering failed", slog.String("package", join(m.ImportPath, dir)), slog.String("error", err.Error()))
+			continue
+		}
+		if p != nil {
+			packages[dir] = p
+		}
+	}
+	if len(packages) == 0 {
+		return nil
+	}
+
+	docs := &moduleDocs{data: make(map[string]pageData, len(dirs))}
+	for _, dir := range dirs {
+		data := pageData{Domain: domain, ImportPath: join(m.ImportPath, dir), ModuleURL: "/" + path, Package: packages[dir]}
+		for _, sub := range dirs {
+			if p := packages[sub]; p != nil && sub != dir && (dir == "." || strings.HasPrefix(sub, dir+"/")) {
+				data.Dirs = append(data.Dirs, dirEntry{URL: "/" + join(path, sub), ImportPath: p.ImportPath, Synopsis: p.Synopsis})
+			}
+		}
+		if dir != "." && data.Package == nil && len(data.Dirs) == 0 {
+			continue
+		}
+		docs.data[join(path, dir)] = data
+	}
+	return docs
+}
+
+// renderModule adds the pages of docs, the documentation of the module registered at
+// path, to pages, with the current page of the module.
+func (s *Service) renderModule(ctx context.Context, pages map[string]*Page, path string, docs *moduleDocs) error {
+	docs.page = s.svc.Page(ctx, path)
+	for pagePath, data := range docs.data {
+		data.Page = docs.page
+		var b bytes.Buffer
+		if err := pageTemplate.Execute(&b, data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", data.ImportPath, err)
+		}
+		pages[pagePath] = newPage(b.Bytes())
+	}
+	return nil
+}
+
+// join returns the path of dir, relative to base in slash-separated form, below base.
+func join(base, dir string) string {
+	if dir == "." {
+		return base
+	}
+	return base + "/" + dir
+}
+
+// newPage returns the page serving html, with a strong ETag derived from it.
+func newPage(html []byte) *Page {
+	sum := sha256.Sum256(html)
+	return &Page{
+		HTML: html,
+		Header: http.Header{
+			"Content-Type": {"text/html; charset=utf-8"},
+			"Etag":         {`"` + hex.EncodeToString(sum[:]) + `"`},
+		},
+	}
+}
@@ -0,0 +1,242 @@
+package docsvc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/access"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// writeCheckouts writes files, keyed by their slash-separated names, in dir.
+func writeCheckouts(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkouts are the checkouts of app, with a package in a subdirectory, files the go
+// command ignores and a nested module, and of tools, which has no Go package.
+var checkouts = map[string]string{
+	"app/go.mod": "module go.gllm.dev/app\n",
+	"app/app.go": `// Package app greets <people>.
+//
+// See [Client] and [go.gllm.dev/app/cmd/greet.Main].
+package app
+
+// Version is the version of the package.
+const Version = "v1"
+
+// Client greets.
+type Client struct {
+	// Name is who is greeted.
+	Name string
+	secret string
+}
+
+// NewClient returns a Client greeting name.
+func NewClient(name string) *Client { return &Client{Name: name} }
+
+// Greet returns the greeting, see [Client.Name] and [strings.Builder].
+func (c *Client) Greet() string { return "hello " + c.Name }
+
+func internal() {}
+`,
+	"app/app_test.go":          "package app\n\nfunc TestHidden() {}\n",
+	"app/ignored.go":           "//go:build ignore\n\npackage main\n",
+	"app/cmd/greet/main.go":    "// Command greet greets.\npackage main\n\n// Main runs the command.\nfunc Main() {}\n",
+	"app/testdata/data.go":     "package data\n",
+	"app/_old/old.go":          "package old\n",
+	"app/v2/go.mod":            "module go.gllm.dev/app/v2\n",
+	"app/v2/app.go":            "package app\n",
+	"app/broken/broken.go":     "package broken\n\nfunc {\n",
+	"tools/README.md":          "tools\n",
+	"secret/secret.go":         "// Package secret is restricted.\npackage secret\n",
+	"hidden/hidden.go":         "// Package hidden is hidden.\npackage hidden\n",
+	"deprecated/deprecated.go": "// Package deprecated is deprecated.\npackage deprecated\n",
+}
+
+func newTestService(t *testing.T) (*Service, *gosvc.Service) {
+	t.Helper()
+	dir := t.TempDir()
+	writeCheckouts(t, dir, checkouts)
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &gosvc.Config{Modules: []gosvc.ModuleConfig{
+		{Path: "app"},
+		{Path: "deprecated", Status: "deprecated", Replacement: "go.gllm.dev/app"},
+		{Path: "hidden", Status: "hidden"},
+		{Path: "missing"},
+		{Path: "secret", Access: &access.Config{Networks: []string{"10.0.0.0/8"}}},
+		{Path: "tools"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(&Config{Dir: dir}, svc, discardLogger), svc
+}
+
+func TestService_Generate(t *testing.T) {
+	docs, _ := newTestService(t)
+	if _, ok := docs.Page(""); ok {
+		t.Fatal("Page() before Generate found a page")
+	}
+	if err := docs.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "module root package",
+			path: "app/",
+			want: []string{
+				"<title>app package - go.gllm.dev/app</title>",
+				`<pre>import "go.gllm.dev/app"</pre>`,
+				"<p>Package app greets &lt;people&gt;.",
+				`<a href="#Client">Client</a>`,
+				`<a href="/app/cmd/greet#Main">go.gllm.dev/app/cmd/greet.Main</a>`,
+				"<pre>const Version = &#34;v1&#34;</pre>",
+				`<h3 id="Client">type Client</h3>`,
+				"// Name is who is greeted.",
+				`<h4 id="NewClient">func NewClient</h4>`,
+				`<h4 id="Client.Greet">func (Client) Greet</h4>`,
+				"<pre>func (c *Client) Greet() string</pre>",
+				`<a href="#Client.Name">Client.Name</a>`,
+				`<a href="https://pkg.go.dev/strings#Builder">strings.Builder</a>`,
+				`<tr><td><a href="/app/cmd/greet">go.gllm.dev/app/cmd/greet</a></td><td>Command greet greets.</td></tr>`,
+			},
+			notWant: []string{"internal", "TestHidden", "secret string", "hello", "testdata", "_old", "app/v2", "broken"},
+		},
+		{
+			name:    "command",
+			path:    "app/cmd/greet",
+			want:    []string{"<h1>package main</h1>", `<h3 id="Main">func Main</h3>`, `Module <a href="/app">go.gllm.dev/app</a>`},
+			notWant: []string{"import &#34;", "Directories"},
+		},
+		{
+			name: "directory of packages",
+			path: "app/cmd",
+			want: []string{"<h1>go.gllm.dev/app/cmd</h1>", `<a href="/app/cmd/greet">go.gllm.dev/app/cmd/greet</a>`},
+		},
+		{
+			name: "deprecated module",
+			path: "deprecated",
+			want: []string{`<p>Deprecated: use <a href="https://pkg.go.dev/go.gllm.dev/app">go.gllm.dev/app</a> instead.</p>`},
+		},
+		{
+			name: "restricted module",
+			path: "secret",
+			want: []string{"Package secret is restricted."},
+		},
+		{
+			name: "index",
+			path: "",
+			want: []string{
+				`<li><a href="/app">go.gllm.dev/app</a> (git: <a href="https://github.com/gllm-dev/app">https://github.com/gllm-dev/app</a>)</li>`,
+				`<li><a href="/deprecated">go.gllm.dev/deprecated</a>`,
+				`<li><a href="https://pkg.go.dev/go.gllm.dev/missing">go.gllm.dev/missing</a>`,
+				`<li><a href="https://pkg.go.dev/go.gllm.dev/tools">go.gllm.dev/tools</a>`,
+			},
+			notWant: []string{"hidden", "secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, ok := docs.Page(tt.path)
+			if !ok {
+				t.Fatalf("Page(%q) found no page", tt.path)
+			}
+			if page.Header.Get("Content-Type") != "text/html; charset=utf-8" || !strings.HasPrefix(page.Header.Get("Etag"), `"`) {
+				t.Errorf("Header = %v", page.Header)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(page.HTML), want) {
+					t.Errorf("page = %s, want it to contain %s", page.HTML, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(string(page.HTML), notWant) {
+					t.Errorf("page = %s, want it not to contain %s", page.HTML, notWant)
+				}
+			}
+		})
+	}
+
+	for _, path := range []string{"app/broken", "app/testdata", "app/v2", "hidden", "missing", "tools", "other"} {
+		if _, ok := docs.Page(path); ok {
+			t.Errorf("Page(%q) found a page", path)
+		}
+	}
+}
+
+func TestService_Run(t *testing.T) {
+	docs, svc := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		docs.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the documentation")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor(func() bool { _, ok := docs.Page("app"); return ok })
+
+	// New versions and advisories of a module are shown without reading the checkouts again.
+	before, _ := docs.Page("app/cmd/greet")
+	svc.SetVersions(ctx, map[string]*gosvc.Versions{"go.gllm.dev/app": gosvc.ParseVersions("go.gllm.dev/app", []string{"v1.2.0"})})
+	svc.SetAdvisories(ctx, map[string][]gosvc.Advisory{"go.gllm.dev/app": {{ID: "GO-2026-0001", URL: "https://pkg.go.dev/vuln/GO-2026-0001"}}})
+	waitFor(func() bool {
+		page, _ := docs.Page("app/cmd/greet")
+		return strings.Contains(string(page.HTML), "GO-2026-0001")
+	})
+	page, _ := docs.Page("app/cmd/greet")
+	if !strings.Contains(string(page.HTML), "Latest version: ") || page.Header.Get("Etag") == before.Header.Get("Etag") {
+		t.Errorf("page = %s, want the latest version and a new ETag", page.HTML)
+	}
+	if unchanged, _ := docs.Page("deprecated"); !strings.Contains(string(unchanged.HTML), "Deprecated: use") {
+		t.Errorf("page of another module = %s, want it kept", unchanged.HTML)
+	}
+
+	// Loading the registry renders the documentation again, with the current checkouts.
+	writeCheckouts(t, docs.dir, map[string]string{"tools/tools.go": "// Package tools has tools.\npackage tools\n"})
+	if err := svc.Load(ctx, &gosvc.Config{Modules: []gosvc.ModuleConfig{{Path: "tools"}}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool { _, ok := docs.Page("tools"); return ok })
+	if _, ok := docs.Page("app"); ok {
+		t.Error("documentation of a removed module is still served")
+	}
+}
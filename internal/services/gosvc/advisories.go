@@ -0,0 +1,168 @@
+package gosvc
+
+import (
+	"context"
+	"html"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Advisory is a known vulnerability of a module.
+type Advisory struct {
+	// ID identifies the advisory (e.g., "GO-2024-0001").
+	ID string `json:"id"`
+	// Aliases are other identifiers of the vulnerability (e.g., "CVE-2024-1234").
+	Aliases []string `json:"aliases,omitempty"`
+	// Summary is a one-line description of the vulnerability, if any.
+	Summary string `json:"summary,omitempty"`
+	// URL is the page describing the advisory.
+	URL string `json:"url"`
+	// Module is the import path of the other major version of the module the advisory
+	// affects (e.g., "go.gllm.dev/app/v2" for the module "go.gllm.dev/app"); empty when
+	// it affects the module itself.
+	Module string `json:"module,omitempty"`
+	// Affected are the ranges of affected versions of the module.
+	Affected []AffectedRange `json:"affected"`
+}
+
+// AffectedRange is a range of affected versions of a module.
+type AffectedRange struct {
+	// Introduced is the first affected version; empty when every earlier version is affected.
+	Introduced string `json:"introduced,omitempty"`
+	// Fixed is the first version that is no longer affected; empty when none is fixed.
+	Fixed string `json:"fixed,omitempty"`
+	// LastAffected is the last affected version, included, for ranges that end there
+	// rather than at a fixed version; empty otherwise.
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// String describes the range (e.g., "v1.2.0 to before v1.2.3").
+func (r AffectedRange) String() string {
+	switch {
+	case r.Introduced != "" && r.Fixed != "":
+		return r.Introduced + " to before " + r.Fixed
+	case r.Fixed != "":
+		return "before " + r.Fixed
+	case r.Introduced != "" && r.LastAffected != "":
+		return r.Introduced + " to " + r.LastAffected
+	case r.LastAffected != "":
+		return r.LastAffected + " and earlier"
+	case r.Introduced != "":
+		return r.Introduced + " and later"
+	default:
+		return "all versions"
+	}
+}
+
+// Advisories returns the advisories of the module at importPath, as last set with
+// SetAdvisories, followed by those of its other major versions.
+func (s *Service) Advisories(importPath string) []Advisory {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.advisoriesOf(importPath)
+}
+
+// SetAdvisories replaces the known vulnerabilities of every module with advisories,
+// keyed by module path, and renders the pages of the registered modules whose
+// advisories changed again. They are kept across Load.
+//
+// Like it lists the other major versions of a module, its page also shows their
+// advisories (e.g., those of "go.gllm.dev/app/v2" for "go.gllm.dev/app"), as their
+// paths resolve to it unless they are registered themselves.
+func (s *Service) SetAdvisories(ctx context.Context, advisories map[string][]Advisory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	modules := s.modulesLocked()
+	before := make([][]Advisory, len(modules))
+	for i, m := range modules {
+		before[i] = s.advisoriesOf(m)
+	}
+	s.advisories = maps.Clone(advisories)
+	if s.advisories == nil {
+		s.advisories = make(map[string][]Advisory)
+	}
+	s.majorAdvisories = make(map[string][]string)
+	for modulePath := range s.advisories {
+		if prefix, pathMajor, ok := module.SplitPathVersion(modulePath); ok && strings.HasPrefix(pathMajor, "/") {
+			s.majorAdvisories[prefix] = append(s.majorAdvisories[prefix], modulePath)
+		}
+	}
+	for _, paths := range s.majorAdvisories {
+		slices.SortFunc(paths, func(a, b string) int {
+			return semver.Compare(path.Base(a), path.Base(b))
+		})
+	}
+
+	var changed []string
+	for i, m := range modules {
+		if !slices.EqualFunc(before[i], s.advisoriesOf(m), equalAdvisories) {
+			changed = append(changed, m)
+		}
+	}
+	if len(changed) > 0 {
+		s.rerender(ctx, changed...)
+	}
+}
+
+// advisoriesOf returns the advisories of the module at importPath followed by those of
+// its other major versions, lowest first, naming them. s.mu must be held.
+func (s *Service) advisoriesOf(importPath string) []Advisory {
+	advisories := s.advisories[importPath]
+	for _, major := range s.majorAdvisories[importPath] {
+		advisories = slices.Clip(advisories)
+		for _, a := range s.advisories[major] {
+			a.Module = major
+			advisories = append(advisories, a)
+		}
+	}
+	return advisories
+}
+
+// modulesLocked returns the import paths of the registered modules.
+func (s *Service) modulesLocked() []string {
+	r := s.registry.Load()
+	paths := make([]string, 0, len(r.paths))
+	for _, path := range r.paths {
+		paths = append(paths, r.page(path).Module.ImportPath)
+	}
+	return paths
+}
+
+// equalAdvisories reports whether a and b are the same advisory.
+func equalAdvisories(a, b Advisory) bool {
+	return a.ID == b.ID && a.Summary == b.Summary && a.URL == b.URL && a.Module == b.Module &&
+		slices.Equal(a.Aliases, b.Aliases) && slices.Equal(a.Affected, b.Affected)
+}
+
+// advisoriesNotice returns the list of the known vulnerabilities of a module on its page,
+// or nothing when there are none. Advisories come from outside, so every field is escaped.
+func advisoriesNotice(advisories []Advisory) string {
+	if len(advisories) == 0 {
+		return ""
+	}
+	notice := "<p>Known vulnerabilities:</p>\n<ul>\n"
+	for _, a := range advisories {
+		notice += `<li><a href="` + html.EscapeString(a.URL) + `">` + html.EscapeString(a.ID) + "</a>"
+		if a.Module != "" {
+			notice += " in " + html.EscapeString(a.Module)
+		}
+		if a.Summary != "" {
+			notice += ": " + html.EscapeString(a.Summary)
+		}
+		ranges := make([]string, len(a.Affected))
+		for i, r := range a.Affected {
+			ranges[i] = html.EscapeString(r.String())
+		}
+		if len(ranges) > 0 {
+			notice += " (affects " + strings.Join(ranges, ", ") + ")"
+		}
+		notice += "</li>\n"
+	}
+	return notice + "</ul>\n"
+}
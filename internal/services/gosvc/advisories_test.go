@@ -0,0 +1,93 @@
+package gosvc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestService_SetAdvisories(t *testing.T) {
+	ctx := context.Background()
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(ctx, &Config{Modules: []ModuleConfig{{Path: "app"}, {Path: "tools"}}}); err != nil {
+		t.Fatal(err)
+	}
+	before := svc.Page(ctx, "app")
+	tools := svc.Page(ctx, "tools")
+
+	advisories := map[string][]Advisory{"go.gllm.dev/app": {{
+		ID:       "GO-2026-0001",
+		Summary:  `Injection through "<script>"`,
+		URL:      "https://pkg.go.dev/vuln/GO-2026-0001?a=1&b=2",
+		Affected: []AffectedRange{{Introduced: "v1.0.0", Fixed: "v1.0.1"}, {Introduced: "v1.2.0", LastAffected: "v1.2.4"}, {}},
+	}}}
+	svc.SetAdvisories(ctx, advisories)
+
+	page := svc.Page(ctx, "app/cmd")
+	want := `<li><a href="https://pkg.go.dev/vuln/GO-2026-0001?a=1&amp;b=2">GO-2026-0001</a>: Injection through &#34;&lt;script&gt;&#34; (affects v1.0.0 to before v1.0.1, v1.2.0 to v1.2.4, all versions)</li>`
+	if !strings.Contains(string(page.HTML), want) {
+		t.Errorf("page = %s, want it to contain %s", page.HTML, want)
+	}
+	if page.Header.Get("Etag") == before.Header.Get("Etag") || page.JSONHeader.Get("Etag") == before.JSONHeader.Get("Etag") {
+		t.Error("ETags did not change with the advisories")
+	}
+	if got := svc.Page(ctx, "tools"); got.Header.Get("Etag") != tools.Header.Get("Etag") {
+		t.Error("page of an unaffected module changed")
+	}
+	var info Info
+	if err := json.Unmarshal(page.JSON, &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Advisories) != 1 || info.Advisories[0].ID != "GO-2026-0001" {
+		t.Errorf("JSON = %s, want the advisory", page.JSON)
+	}
+
+	// Advisories are kept across Load.
+	if err := svc.Load(ctx, &Config{Modules: []ModuleConfig{{Path: "app"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.Page(ctx, "app"); string(got.HTML) != string(page.HTML) {
+		t.Errorf("page after Load = %s, want the advisories kept", got.HTML)
+	}
+
+	svc.SetAdvisories(ctx, nil)
+	if got := svc.Page(ctx, "app"); strings.Contains(string(got.HTML), "Known vulnerabilities") || got.Header.Get("Etag") != before.Header.Get("Etag") {
+		t.Errorf("page = %s, want the advisories removed", got.HTML)
+	}
+}
+
+func TestService_SetAdvisories_MajorVersions(t *testing.T) {
+	ctx := context.Background()
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(ctx, &Config{Modules: []ModuleConfig{{Path: "app"}, {Path: "lib/v3"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.SetAdvisories(ctx, map[string][]Advisory{
+		"go.gllm.dev/app":     {{ID: "GO-2026-0001"}},
+		"go.gllm.dev/app/v10": {{ID: "GO-2026-0010"}},
+		"go.gllm.dev/app/v2":  {{ID: "GO-2026-0002"}},
+		"go.gllm.dev/lib/v3":  {{ID: "GO-2026-0003"}},
+	})
+
+	var ids []string
+	for _, a := range svc.Advisories("go.gllm.dev/app") {
+		ids = append(ids, a.ID+" "+a.Module)
+	}
+	if got, want := strings.Join(ids, ", "), "GO-2026-0001 , GO-2026-0002 go.gllm.dev/app/v2, GO-2026-0010 go.gllm.dev/app/v10"; got != want {
+		t.Errorf("Advisories(app) = %s, want %s", got, want)
+	}
+	if page := string(svc.Page(ctx, "app/v2").HTML); !strings.Contains(page, "GO-2026-0002</a> in go.gllm.dev/app/v2") {
+		t.Errorf("page of app/v2 = %s, want the advisory of the second major version", page)
+	}
+	// A registered major version keeps its advisories on its own page.
+	if got := svc.Advisories("go.gllm.dev/lib/v3"); len(got) != 1 || got[0].Module != "" {
+		t.Errorf("Advisories(lib/v3) = %+v, want its own advisory", got)
+	}
+
+	svc.SetAdvisories(ctx, map[string][]Advisory{"go.gllm.dev/app": {{ID: "GO-2026-0001"}}})
+	if page := string(svc.Page(ctx, "app").HTML); strings.Contains(page, "GO-2026-0002") {
+		t.Errorf("page = %s, want the advisories of the removed major version gone", page)
+	}
+}
@@ -0,0 +1,84 @@
+package gosvc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GovanityurlsConfig is the vanity.yaml file of Google's govanityurls server.
+//
+// Example:
+//
+//	host: go.example.com
+//	cache_max_age: 3600
+//	paths:
+//	  /portmidi:
+//	    repo: https://github.com/rakyll/portmidi
+//	  /glupload:
+//	    repo: https://bitbucket.org/zombiezen/glupload
+//	    vcs: hg
+type GovanityurlsConfig struct {
+	// Host is the vanity domain. govanityurls uses the request host when it is empty.
+	Host string `yaml:"host,omitempty"`
+	// CacheMaxAge is the max-age of the pages in seconds. govanityurls defaults to a day.
+	CacheMaxAge *int64 `yaml:"cache_max_age,omitempty"`
+	// Paths maps module roots, with a leading slash, to their repositories.
+	Paths map[string]GovanityurlsPath `yaml:"paths"`
+}
+
+// GovanityurlsPath is a module of a govanityurls configuration.
+type GovanityurlsPath struct {
+	// Repo is the URL of the repository hosting the module.
+	Repo string `yaml:"repo"`
+	// Display is the go-source content following the import path.
+	Display string `yaml:"display,omitempty"`
+	// VCS is the version control system of the repository.
+	VCS string `yaml:"vcs,omitempty"`
+}
+
+// Convert translates the configuration into a module registry with the same semantics
+// as govanityurls, which differ from the registry defaults in two ways:
+//   - go-source links of GitHub repositories point to the master branch, and those of
+//     Bitbucket repositories to the default branch, so Display is filled in for them;
+//   - the VCS can only be omitted for GitHub repositories.
+//
+// Host and CacheMaxAge are server settings, not part of the registry; they map to the
+// vanity domain and the cache max age of the server configuration.
+func (g *GovanityurlsConfig) Convert() (*Config, error) {
+	paths := make([]string, 0, len(g.Paths))
+	for path := range g.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	cfg := &Config{Modules: make([]ModuleConfig, 0, len(paths))}
+	for _, path := range paths {
+		p := g.Paths[path]
+		mc := ModuleConfig{
+			Path:       strings.Trim(path, "/"),
+			Repository: p.Repo,
+			VCS:        p.VCS,
+			Display:    p.Display,
+		}
+
+		switch {
+		case mc.Display != "":
+		case strings.HasPrefix(p.Repo, "https://github.com/"):
+			mc.Display = fmt.Sprintf("%v %v/tree/master{/dir} %v/blob/master{/dir}/{file}#L{line}", p.Repo, p.Repo, p.Repo)
+		case strings.HasPrefix(p.Repo, "https://bitbucket.org"):
+			mc.Display = fmt.Sprintf("%v %v/src/default{/dir} %v/src/default{/dir}/{file}#{file}-{line}", p.Repo, p.Repo, p.Repo)
+		}
+
+		switch {
+		case mc.VCS != "":
+		case strings.HasPrefix(p.Repo, "https://github.com/"):
+			mc.VCS = "git"
+		default:
+			return nil, fmt.Errorf("configuration for %v: cannot infer VCS from %s", path, p.Repo)
+		}
+
+		cfg.Modules = append(cfg.Modules, mc)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,129 @@
+package gosvc
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGovanityurlsConfig_RoundTrip(t *testing.T) {
+	tests := []struct {
+		file string
+		host string
+		// want maps request paths to the go-import and go-source contents served by govanityurls.
+		want map[string][2]string
+	}{
+		{
+			file: "readme.yaml",
+			host: "example.com",
+			want: map[string][2]string{
+				"foo/bar": {
+					"example.com/foo git https://github.com/example/foo",
+					"example.com/foo https://github.com/example/foo https://github.com/example/foo/tree/master{/dir} https://github.com/example/foo/blob/master{/dir}/{file}#L{line}",
+				},
+			},
+		},
+		{
+			file: "inferred.yaml",
+			host: "go.example.com",
+			want: map[string][2]string{
+				"portmidi": {
+					"go.example.com/portmidi git https://github.com/rakyll/portmidi",
+					"go.example.com/portmidi https://github.com/rakyll/portmidi https://github.com/rakyll/portmidi/tree/master{/dir} https://github.com/rakyll/portmidi/blob/master{/dir}/{file}#L{line}",
+				},
+				"glupload/cmd": {
+					"go.example.com/glupload hg https://bitbucket.org/zombiezen/glupload",
+					"go.example.com/glupload https://bitbucket.org/zombiezen/glupload https://bitbucket.org/zombiezen/glupload/src/default{/dir} https://bitbucket.org/zombiezen/glupload/src/default{/dir}/{file}#{file}-{line}",
+				},
+				"tools/cli": {
+					"go.example.com/tools/cli git https://git.example.com/tools/cli.git",
+					"go.example.com/tools/cli https://git.example.com/tools/cli https://git.example.com/tools/cli/src{/dir} https://git.example.com/tools/cli/src{/dir}/{file}#L{line}",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join("testdata", "govanityurls", tt.file)
+			g, err := ReadGovanityurlsConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Host != tt.host {
+				t.Errorf("Host = %q, want %q", g.Host, tt.host)
+			}
+			converted, err := g.Convert()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Loading the file directly gives the converted registry.
+			direct, err := ReadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(direct, converted) {
+				t.Errorf("ReadConfig() = %+v, want %+v", direct, converted)
+			}
+
+			// The converted registry survives being written and read back.
+			var buf bytes.Buffer
+			if err := WriteConfig(&buf, converted); err != nil {
+				t.Fatal(err)
+			}
+			written := filepath.Join(t.TempDir(), "modules.yaml")
+			if err := os.WriteFile(written, buf.Bytes(), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			reread, err := ReadConfig(written)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(reread, converted) {
+				t.Errorf("round trip = %+v, want %+v\n%s", reread, converted, buf.String())
+			}
+
+			// The pages carry the meta tags govanityurls serves.
+			svc := New(tt.host, "https://github.com/unused")
+			if err := svc.Load(context.Background(), reread); err != nil {
+				t.Fatal(err)
+			}
+			for reqPath, meta := range tt.want {
+				html := svc.Vanity(context.Background(), reqPath)
+				for _, tag := range []string{
+					`<meta name="go-import" content="` + meta[0] + `">`,
+					`<meta name="go-source" content="` + meta[1] + `">`,
+				} {
+					if !strings.Contains(html, tag) {
+						t.Errorf("page for %s should contain %s, got\n%s", reqPath, tag, html)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGovanityurlsConfig_Convert_Errors(t *testing.T) {
+	g := &GovanityurlsConfig{Paths: map[string]GovanityurlsPath{
+		"/gitlab": {Repo: "https://gitlab.com/example/gitlab"},
+	}}
+	if _, err := g.Convert(); err == nil || !strings.Contains(err.Error(), "cannot infer VCS") {
+		t.Errorf("Convert() error = %v, want VCS inference error", err)
+	}
+}
+
+func TestReadConfig_GovanityurlsUnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vanity.yaml")
+	content := "paths:\n  /foo:\n    repository: https://github.com/example/foo\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadConfig(path); err == nil {
+		t.Error("ReadConfig() should reject unknown govanityurls fields")
+	}
+}
@@ -0,0 +1,139 @@
+package gosvc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.gllm.dev/vanity-go/internal/access"
+)
+
+// PathState is how the server answers a path with a given module registry.
+type PathState struct {
+	// Rule is the path of the registered module or alias serving the path,
+	// or empty when it falls back to the repository base URL.
+	Rule string `json:"rule,omitempty"`
+	// GoImport is the content of the go-import meta tag.
+	GoImport string `json:"go_import"`
+	// GoSource is the content of the go-source meta tag.
+	GoSource string `json:"go_source"`
+	// Redirect is where browsers are redirected to, for aliases of renamed modules
+	// that redirect; empty otherwise.
+	Redirect string `json:"redirect,omitempty"`
+	// Access describes who may resolve the path.
+	Access string `json:"access"`
+
+	// module is the module the path resolves to.
+	module Module
+	// policy is the access policy of the path; nil when everyone may resolve it.
+	policy *access.Policy
+}
+
+// Impact is the effect of a change of the module registry on a path: the path itself
+// and, unless a more specific rule serves them, every path below it.
+type Impact struct {
+	// ImportPath is the affected import path.
+	ImportPath string `json:"import_path"`
+	// Before is how the path is answered before the change.
+	Before PathState `json:"before"`
+	// After is how the path is answered after the change.
+	After PathState `json:"after"`
+	// Captures reports whether the path is newly registered, so that it and the paths
+	// below it are no longer served by Before.Rule, or the repository base URL when empty.
+	Captures bool `json:"captures,omitempty"`
+	// Dangers are the reasons the change may break or hijack the builds of importers,
+	// such as a switch of repository host; empty when it is safe.
+	Dangers []string `json:"dangers,omitempty"`
+}
+
+// Impacts returns how the module registry loaded in after changes the answers of the
+// server compared with the one loaded in before, for every path registered by either
+// as a module, alias or access policy, ordered by import path.
+// Paths answered the same way by both are left out.
+func Impacts(ctx context.Context, before, after *Service) []Impact {
+	r, q := before.registry.Load(), after.registry.Load()
+	paths := make(map[string]bool)
+	for _, m := range []map[string]*entry{r.pages, q.pages} {
+		for path := range m {
+			paths[path] = true
+		}
+	}
+	for _, m := range []map[string]*access.Policy{r.policies, q.policies} {
+		for path := range m {
+			paths[path] = true
+		}
+	}
+
+	var impacts []Impact
+	for path := range paths {
+		_, registered := r.pages[path]
+		_, registers := q.pages[path]
+		impact := Impact{
+			ImportPath: after.domain + "/" + path,
+			Before:     before.state(ctx, path),
+			After:      after.state(ctx, path),
+			Captures:   registers && !registered,
+		}
+		if !impact.Captures && impact.Before.same(impact.After) {
+			continue
+		}
+		impact.Dangers = dangers(impact.Before, impact.After)
+		impacts = append(impacts, impact)
+	}
+
+	sort.Slice(impacts, func(i, j int) bool { return impacts[i].ImportPath < impacts[j].ImportPath })
+	return impacts
+}
+
+// state returns how the service answers path.
+func (s *Service) state(ctx context.Context, path string) PathState {
+	res := s.Explain(ctx, path)
+	m := res.Module.withDefaults()
+	state := PathState{
+		Rule:     res.Rule,
+		GoImport: m.ImportPath + " " + m.VCS + " " + m.Repository,
+		GoSource: m.ImportPath + " " + m.Display,
+		module:   res.Module,
+		policy:   s.Policy(path),
+	}
+	if page := s.Page(ctx, path); page.Redirect {
+		state.Redirect = page.Location(path)
+	}
+	state.Access = state.policy.String()
+	return state
+}
+
+// same reports whether the server answers the same way in both states.
+func (st PathState) same(other PathState) bool {
+	return st.GoImport == other.GoImport &&
+		st.GoSource == other.GoSource &&
+		st.Redirect == other.Redirect &&
+		st.policy.Equal(other.policy)
+}
+
+// dangers returns the reasons the change from before to after may break or hijack builds:
+// the repository moving to another host, the VCS changing, or an access restriction lifted.
+func dangers(before, after PathState) []string {
+	var reasons []string
+	if from, to := repositoryHost(before.module.Repository), repositoryHost(after.module.Repository); from != to {
+		reasons = append(reasons, fmt.Sprintf("repository host changes from %s to %s", from, to))
+	}
+	if before.module.VCS != after.module.VCS {
+		reasons = append(reasons, fmt.Sprintf("vcs changes from %s to %s", before.module.VCS, after.module.VCS))
+	}
+	if before.policy != nil && after.policy == nil {
+		reasons = append(reasons, "access restriction removed")
+	}
+	return reasons
+}
+
+// repositoryHost returns the host of a repository URL, or the URL itself when it has none.
+func repositoryHost(repository string) string {
+	u, err := url.Parse(repository)
+	if err != nil || u.Host == "" {
+		return repository
+	}
+	return strings.ToLower(u.Host)
+}
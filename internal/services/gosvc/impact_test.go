@@ -0,0 +1,79 @@
+package gosvc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.gllm.dev/vanity-go/internal/access"
+)
+
+func TestImpacts(t *testing.T) {
+	ctx := context.Background()
+	load := func(cfg *Config) *Service {
+		t.Helper()
+		s := New("go.gllm.dev", "https://github.com/gllm-dev")
+		if err := s.Load(ctx, cfg); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	before := load(&Config{
+		Modules: []ModuleConfig{
+			{Path: "tools", Repository: "https://gitlab.com/gllm-dev/tools"},
+			{Path: "app"},
+			{Path: "same"},
+		},
+		Aliases:  []AliasConfig{{Path: "oldapp", Target: "app"}},
+		Policies: []PolicyConfig{{Prefix: "internal", Config: access.Config{Networks: []string{"10.0.0.0/8"}}}},
+	})
+	after := load(&Config{
+		Modules: []ModuleConfig{
+			{Path: "tools", Repository: "https://gitlab.com/gllm-dev/tools"},
+			{Path: "tools/cli"},
+			{Path: "app", VCS: "hg"},
+			{Path: "same"},
+			{Path: "foo"},
+		},
+		Aliases: []AliasConfig{{Path: "oldapp", Target: "app", Redirect: true}},
+	})
+
+	impacts := Impacts(ctx, before, after)
+	type summary struct {
+		path     string
+		captures bool
+		dangers  []string
+	}
+	var got []summary
+	for _, impact := range impacts {
+		got = append(got, summary{impact.ImportPath, impact.Captures, impact.Dangers})
+	}
+	want := []summary{
+		{path: "go.gllm.dev/app", dangers: []string{"vcs changes from git to hg"}},
+		{path: "go.gllm.dev/foo", captures: true},
+		{path: "go.gllm.dev/internal", dangers: []string{"access restriction removed"}},
+		{path: "go.gllm.dev/oldapp", dangers: []string{"vcs changes from git to hg"}},
+		{path: "go.gllm.dev/tools/cli", captures: true, dangers: []string{"repository host changes from gitlab.com to github.com"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Impacts() = %+v, want %+v", got, want)
+	}
+
+	cli := impacts[4]
+	if cli.Before.Rule != "tools" || cli.After.Rule != "tools/cli" {
+		t.Errorf("tools/cli rules = %q -> %q, want tools -> tools/cli", cli.Before.Rule, cli.After.Rule)
+	}
+	if cli.Before.GoImport != "go.gllm.dev/tools git https://gitlab.com/gllm-dev/tools" {
+		t.Errorf("tools/cli go-import before = %q", cli.Before.GoImport)
+	}
+	if got := impacts[3].After.Redirect; got != "https://go.gllm.dev/app" {
+		t.Errorf("oldapp redirect = %q", got)
+	}
+	if got := impacts[2].Before.Access; got != "networks 10.0.0.0/8" {
+		t.Errorf("internal access before = %q", got)
+	}
+
+	if impacts := Impacts(ctx, before, before); len(impacts) != 0 {
+		t.Errorf("Impacts() of the same registry = %+v, want none", impacts)
+	}
+}
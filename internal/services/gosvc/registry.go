@@ -0,0 +1,709 @@
+package gosvc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"go.gllm.dev/vanity-go/internal/access"
+	"go.gllm.dev/vanity-go/internal/errjoin"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the module registry file.
+//
+// Example:
+//
+//	modules:
+//	  - path: vanity-go
+//	  - path: tools
+//	    repository: https://gitlab.com/gllm-dev/tools
+//	    vcs: git
+//	aliases:
+//	  - path: oldname
+//	    target: vanity-go
+//	policies:
+//	  - prefix: internal
+//	    networks: [10.0.0.0/8]
+type Config struct {
+	// Modules are the modules served by the vanity domain.
+	Modules []ModuleConfig `yaml:"modules"`
+	// Aliases are former paths of renamed modules.
+	Aliases []AliasConfig `yaml:"aliases,omitempty"`
+	// Policies restrict access to every path under a prefix, registered or not.
+	Policies []PolicyConfig `yaml:"policies,omitempty"`
+}
+
+// ModuleConfig registers a module under the vanity domain.
+type ModuleConfig struct {
+	// Path is the module root relative to the vanity domain (e.g., "vanity-go").
+	// Requests for any path inside it are answered with this module.
+	Path string `yaml:"path"`
+	// Repository is the URL of the repository hosting the module.
+	// It defaults to the path under the repository base URL.
+	Repository string `yaml:"repository,omitempty"`
+	// VCS is the version control system of the repository. It defaults to "git".
+	VCS string `yaml:"vcs,omitempty"`
+	// Display is the go-source content following the import path: the home page,
+	// directory and file URL templates (e.g., "https://host/repo https://host/repo/tree/master{/dir}
+	// https://host/repo/blob/master{/dir}/{file}#L{line}"). It defaults to the GitHub
+	// layout of the repository on the main branch.
+	Display string `yaml:"display,omitempty"`
+	// Packages are package directories inside the module (e.g., "cmd/cli").
+	// The server answers any package without them; they only list the extra
+	// pages written by a static export, where every path needs its own file.
+	Packages []string `yaml:"packages,omitempty"`
+	// Status is the lifecycle state of the module: active, deprecated, archived or hidden.
+	// It defaults to active.
+	Status string `yaml:"status,omitempty"`
+	// Replacement is the import path deprecated modules point their users to (e.g., "go.gllm.dev/newname").
+	Replacement string `yaml:"replacement,omitempty"`
+	// Access restricts which clients may resolve the module; nil means everyone.
+	Access *access.Config `yaml:"access,omitempty"`
+}
+
+// PolicyConfig restricts access to every path under Prefix.
+// Clients that are not allowed get 404 Not Found, as if nothing existed there.
+type PolicyConfig struct {
+	// Prefix is the path the policy applies to, relative to the vanity domain (e.g., "internal").
+	// It covers the path and every path below it.
+	Prefix        string `yaml:"prefix"`
+	access.Config `yaml:",inline"`
+}
+
+// AliasConfig keeps serving a module under a former path after it was renamed.
+// The go command still gets the go-import of the old path, pointing at the repository
+// of the target, so existing importers keep building; responses carry an
+// X-Module-Moved header naming the new import path.
+type AliasConfig struct {
+	// Path is the former module root relative to the vanity domain (e.g., "oldname").
+	Path string `yaml:"path"`
+	// Target is the path the module moved to: a registered module or another alias,
+	// which is followed to its module.
+	Target string `yaml:"target"`
+	// Redirect sends browsers to the new path instead of showing a notice on the old one.
+	Redirect bool `yaml:"redirect,omitempty"`
+}
+
+// vcsKinds are the version control systems understood by the go command.
+var vcsKinds = map[string]bool{
+	"bzr":    true,
+	"fossil": true,
+	"git":    true,
+	"hg":     true,
+	"mod":    true,
+	"svn":    true,
+}
+
+// ReadConfig reads a module registry file.
+// Files in the vanity.yaml format of govanityurls, recognised by their top-level
+// "paths" key, are converted with GovanityurlsConfig.Convert.
+// Unknown fields are rejected so that typos do not silently change behavior.
+func ReadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open module registry: %w", err)
+	}
+
+	if isGovanityurls(data) {
+		g, err := parseGovanityurls(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse govanityurls configuration %s: %w", path, err)
+		}
+		cfg, err := g.Convert()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert govanityurls configuration %s: %w", path, err)
+		}
+		return cfg, nil
+	}
+
+	cfg := &Config{}
+	if err := decodeStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse module registry %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// WriteConfig writes cfg as a module registry file that ReadConfig reads back unchanged.
+func WriteConfig(w io.Writer, cfg *Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode module registry: %w", err)
+	}
+	return enc.Close()
+}
+
+// ReadGovanityurlsConfig reads a vanity.yaml file of govanityurls.
+func ReadGovanityurlsConfig(path string) (*GovanityurlsConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open govanityurls configuration: %w", err)
+	}
+	g, err := parseGovanityurls(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse govanityurls configuration %s: %w", path, err)
+	}
+	return g, nil
+}
+
+// parseGovanityurls decodes a vanity.yaml file of govanityurls.
+func parseGovanityurls(data []byte) (*GovanityurlsConfig, error) {
+	g := &GovanityurlsConfig{}
+	if err := decodeStrict(data, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// isGovanityurls reports whether data is a govanityurls configuration rather than a module registry.
+func isGovanityurls(data []byte) bool {
+	var keys map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return false
+	}
+	_, ok := keys["paths"]
+	return ok
+}
+
+// ParseModuleConfig decodes a module configuration written in YAML or JSON with the
+// field names of the module registry file, rejecting unknown fields.
+func ParseModuleConfig(data []byte) (ModuleConfig, error) {
+	var mc ModuleConfig
+	if err := decodeStrict(data, &mc); err != nil {
+		return ModuleConfig{}, fmt.Errorf("failed to parse module: %w", err)
+	}
+	return mc, nil
+}
+
+// decodeStrict decodes the YAML document in data into v, rejecting unknown fields.
+func decodeStrict(data []byte, v any) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	return dec.Decode(v)
+}
+
+// registry is an immutable snapshot of the registered modules with their precomputed pages.
+// Only the pages of its entries change, when the details of their module do.
+type registry struct {
+	// pages maps each module and alias root to its entry.
+	pages map[string]*entry
+	// paths are the module roots in lexical order.
+	paths []string
+	// aliases are the alias roots in lexical order.
+	aliases []string
+	// policies maps the paths with an access policy to it.
+	policies map[string]*access.Policy
+	// config is the configuration the registry was loaded from.
+	config *Config
+}
+
+// entry holds the current page of a registered path. A page rendered again replaces the
+// former one in place, so that updating a module costs the same whatever the size of
+// the registry, and readers see either page whole.
+type entry struct {
+	page atomic.Pointer[Page]
+}
+
+// newRegistry creates a registry from already rendered pages and access policies.
+func newRegistry(pages map[string]*Page, policies map[string]*access.Policy, cfg *Config) *registry {
+	r := &registry{pages: make(map[string]*entry, len(pages)), policies: policies, config: cfg.clone()}
+	for path, page := range pages {
+		e := new(entry)
+		e.page.Store(page)
+		r.pages[path] = e
+		if page.Module.MovedTo != "" {
+			r.aliases = append(r.aliases, path)
+		} else {
+			r.paths = append(r.paths, path)
+		}
+	}
+	sort.Strings(r.paths)
+	sort.Strings(r.aliases)
+	return r
+}
+
+// page returns the page registered at path, or nil.
+func (r *registry) page(path string) *Page {
+	if e, ok := r.pages[path]; ok {
+		return e.page.Load()
+	}
+	return nil
+}
+
+// lookup returns the page of the innermost registered module containing path.
+func (r *registry) lookup(path string) (*Page, bool) {
+	e, ok := lookupPrefix(r.pages, path)
+	if !ok {
+		return nil, false
+	}
+	return e.page.Load(), true
+}
+
+// lookupPrefix returns the value of the longest key of m that is path or one of its parents.
+// It trims one path element at a time and allocates nothing.
+func lookupPrefix[V any](m map[string]V, path string) (V, bool) {
+	for {
+		if v, ok := m[path]; ok {
+			return v, true
+		}
+		i := strings.LastIndexByte(path, '/')
+		if i < 0 {
+			var zero V
+			return zero, false
+		}
+		path = path[:i]
+	}
+}
+
+// Load validates the modules and aliases of cfg, renders their pages and atomically
+// replaces the registered modules with them. On error the current modules are kept.
+func (s *Service) Load(ctx context.Context, cfg *Config) error {
+	ctx, span := tracer.Start(ctx, "gosvc.Load")
+	defer span.End()
+
+	res, err := s.resolveConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions := make(map[string]*Versions, len(s.versions))
+	pages := make(map[string]*Page, len(res.modules)+len(res.aliases))
+	for path, m := range res.modules {
+		if v := s.versions[m.ImportPath]; v != nil {
+			versions[m.ImportPath] = v
+		}
+		pages[path] = s.newPage(ctx, m, s.details(m.ImportPath))
+	}
+	for path, a := range res.aliases {
+		page := s.newPage(ctx, a.module, details{})
+		page.Redirect, page.root = a.redirect, path
+		pages[path] = page
+	}
+
+	s.versions = versions
+	s.registry.Store(newRegistry(pages, res.policies, cfg))
+	close(s.loaded)
+	s.loaded = make(chan struct{})
+	s.notifyRendered()
+	return nil
+}
+
+// Loaded returns a channel that is closed the next time modules are loaded, even when
+// they did not change, so that what is derived from them can be built again.
+func (s *Service) Loaded() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loaded
+}
+
+// Rendered returns a channel that is closed the next time pages are rendered, when
+// modules are loaded or their versions or advisories change, so that what shows them
+// can be built again.
+func (s *Service) Rendered() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rendered
+}
+
+// notifyRendered closes the channel returned by Rendered and replaces it. s.mu must be held.
+func (s *Service) notifyRendered() {
+	close(s.rendered)
+	s.rendered = make(chan struct{})
+}
+
+// Config returns a copy of the configuration the registered modules were loaded from.
+func (s *Service) Config() *Config {
+	return s.registry.Load().config.clone()
+}
+
+// clone returns a copy of c whose lists can be changed without affecting c.
+// The access configurations of modules are shared.
+func (c *Config) clone() *Config {
+	return &Config{
+		Modules:  slices.Clone(c.Modules),
+		Aliases:  slices.Clone(c.Aliases),
+		Policies: slices.Clone(c.Policies),
+	}
+}
+
+// Preview returns a new service for the same domain and repository base URL with
+// cfg loaded, to see how paths would resolve without changing the registered modules.
+func (s *Service) Preview(ctx context.Context, cfg *Config) (*Service, error) {
+	preview := New(s.domain, s.repository)
+	if err := preview.Load(ctx, cfg); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// Validate checks the modules and aliases of cfg without loading them.
+// Every problem found is reported, joined with errors.Join.
+func (s *Service) Validate(cfg *Config) error {
+	_, err := s.resolveConfig(cfg)
+	return err
+}
+
+// resolved is a validated module registry.
+type resolved struct {
+	modules  map[string]Module
+	aliases  map[string]alias
+	policies map[string]*access.Policy
+}
+
+// resolveConfig validates the modules, aliases and policies of cfg, reporting the errors of all.
+func (s *Service) resolveConfig(cfg *Config) (*resolved, error) {
+	modules, err := s.modules(cfg.Modules)
+	aliases, aliasErr := s.aliases(cfg.Aliases, modules)
+	policies, policyErr := s.policies(cfg, aliases)
+	return &resolved{modules: modules, aliases: aliases, policies: policies}, errors.Join(err, aliasErr, policyErr)
+}
+
+// policies validates the access policies of the modules and prefixes of cfg and indexes
+// them by path. Aliases without a policy of their own share the policy of their module,
+// so that a former path does not reveal a restricted repository.
+func (s *Service) policies(cfg *Config, aliases map[string]alias) (map[string]*access.Policy, error) {
+	var errs []error
+	policies := make(map[string]*access.Policy)
+	for i, mc := range cfg.Modules {
+		if mc.Access == nil {
+			continue
+		}
+		p, err := access.New(*mc.Access)
+		if err != nil {
+			for _, err := range errjoin.Split(err) {
+				errs = append(errs, fmt.Errorf("module %d (%q): access: %w", i+1, mc.Path, err))
+			}
+			continue
+		}
+		policies[mc.Path] = p
+	}
+
+	for i, pc := range cfg.Policies {
+		var err error
+		switch {
+		case pc.Prefix == "":
+			err = errors.New("prefix is required")
+		case checkPath(pc.Prefix) != nil:
+			err = fmt.Errorf("prefix %w", checkPath(pc.Prefix))
+		case policies[pc.Prefix] != nil:
+			err = errors.New("a module or another policy already restricts this path")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("policy %d (%q): %w", i+1, pc.Prefix, err))
+			continue
+		}
+		p, err := access.New(pc.Config)
+		if err != nil {
+			for _, err := range errjoin.Split(err) {
+				errs = append(errs, fmt.Errorf("policy %d (%q): %w", i+1, pc.Prefix, err))
+			}
+			continue
+		}
+		policies[pc.Prefix] = p
+	}
+
+	// The target of an alias may lie under another alias, whose policy is then shared in
+	// turn, so aliases are resolved through each other rather than in map order.
+	shared := make(map[string]*access.Policy, len(aliases))
+	resolving := make(map[string]bool)
+	var share func(path string) *access.Policy
+	share = func(path string) *access.Policy {
+		if p, ok := shared[path]; ok || resolving[path] {
+			return p
+		}
+		resolving[path] = true
+		var p *access.Policy
+		for prefix := strings.TrimPrefix(aliases[path].module.MovedTo, s.domain+"/"); ; {
+			if own, ok := policies[prefix]; ok {
+				p = own
+				break
+			}
+			if _, ok := aliases[prefix]; ok {
+				p = share(prefix)
+				break
+			}
+			i := strings.LastIndexByte(prefix, '/')
+			if i < 0 {
+				break
+			}
+			prefix = prefix[:i]
+		}
+		shared[path] = p
+		return p
+	}
+	for path := range aliases {
+		if _, ok := policies[path]; !ok {
+			share(path)
+		}
+	}
+	for path, p := range shared {
+		if p != nil {
+			policies[path] = p
+		}
+	}
+	return policies, errors.Join(errs...)
+}
+
+// modules validates every module configuration, applies defaults and indexes
+// the result by module path. All errors are collected rather than stopping at the first.
+func (s *Service) modules(configs []ModuleConfig) (map[string]Module, error) {
+	var errs []error
+	modules := make(map[string]Module, len(configs))
+	for i, mc := range configs {
+		m, err := s.module(mc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("module %d (%q): %w", i+1, mc.Path, err))
+			continue
+		}
+		if _, ok := modules[mc.Path]; ok {
+			errs = append(errs, fmt.Errorf("module %d (%q): duplicate path", i+1, mc.Path))
+			continue
+		}
+		modules[mc.Path] = m
+	}
+	return modules, errors.Join(errs...)
+}
+
+// alias is a validated alias: the module served under its path and how browsers are answered.
+type alias struct {
+	module   Module
+	redirect bool
+}
+
+// aliases validates every alias configuration against the registered modules and indexes
+// the result by alias path. Each alias is followed through other aliases to its module;
+// unknown targets, cycles and paths already taken by a module or another alias are errors.
+func (s *Service) aliases(configs []AliasConfig, modules map[string]Module) (map[string]alias, error) {
+	var errs []error
+	targets := make(map[string]string, len(configs))
+	var valid []int
+	for i, ac := range configs {
+		err := checkAlias(ac)
+		switch {
+		case err != nil:
+		case targets[ac.Path] != "":
+			err = fmt.Errorf("duplicate path")
+		default:
+			if _, ok := modules[ac.Path]; ok {
+				err = fmt.Errorf("path is already a module")
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("alias %d (%q): %w", i+1, ac.Path, err))
+			continue
+		}
+		targets[ac.Path] = ac.Target
+		valid = append(valid, i)
+	}
+
+	aliases := make(map[string]alias, len(targets))
+	for _, i := range valid {
+		ac := configs[i]
+		chain := []string{ac.Path}
+		target := ac.Target
+		for {
+			if _, ok := modules[target]; ok {
+				break
+			}
+			next, ok := targets[target]
+			if !ok {
+				errs = append(errs, fmt.Errorf("alias %d (%q): unknown target %q", i+1, ac.Path, target))
+				break
+			}
+			if slices.Contains(chain, target) {
+				errs = append(errs, fmt.Errorf("alias %d (%q): cycle %s", i+1, ac.Path, strings.Join(append(chain, target), " -> ")))
+				break
+			}
+			chain = append(chain, target)
+			target = next
+		}
+		m, ok := modules[target]
+		if !ok {
+			continue
+		}
+		m.MovedTo = m.ImportPath
+		m.ImportPath = s.domain + "/" + ac.Path
+		aliases[ac.Path] = alias{module: m, redirect: ac.Redirect}
+	}
+	return aliases, errors.Join(errs...)
+}
+
+// checkAlias validates the paths of an alias configuration.
+func checkAlias(ac AliasConfig) error {
+	if ac.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+	if err := checkPath(ac.Path); err != nil {
+		return fmt.Errorf("path %w", err)
+	}
+	if ac.Target == "" {
+		return fmt.Errorf("target is required")
+	}
+	if err := checkPath(ac.Target); err != nil {
+		return fmt.Errorf("target %w", err)
+	}
+	return nil
+}
+
+// module validates a module configuration and applies its defaults.
+func (s *Service) module(mc ModuleConfig) (Module, error) {
+	if mc.Path == "" {
+		return Module{}, fmt.Errorf("path is required")
+	}
+	if err := checkPath(mc.Path); err != nil {
+		return Module{}, fmt.Errorf("path %w", err)
+	}
+	for _, pkg := range mc.Packages {
+		if err := checkPath(pkg); err != nil {
+			return Module{}, fmt.Errorf("package %q %w", pkg, err)
+		}
+	}
+
+	m := Module{
+		ImportPath: s.domain + "/" + mc.Path,
+		VCS:        mc.VCS,
+		Repository: mc.Repository,
+		Display:    mc.Display,
+	}
+	if m.VCS == "" {
+		m.VCS = "git"
+	}
+	if !vcsKinds[m.VCS] {
+		return Module{}, fmt.Errorf("unknown vcs %q", m.VCS)
+	}
+	status := Status(mc.Status)
+	if status != "" && !slices.Contains(Statuses, status) {
+		return Module{}, fmt.Errorf("unknown status %q", mc.Status)
+	}
+	if mc.Replacement != "" && status != StatusDeprecated {
+		return Module{}, fmt.Errorf("replacement requires status %q", StatusDeprecated)
+	}
+	if strings.ContainsAny(mc.Replacement, " \t\n\"<>") {
+		return Module{}, fmt.Errorf("replacement %q is not an import path", mc.Replacement)
+	}
+	m.Status, m.Replacement = status, mc.Replacement
+	if m.Repository == "" {
+		m.Repository = s.repository + "/" + mc.Path
+	}
+	if u, err := url.Parse(m.Repository); err != nil || u.Scheme == "" || u.Host == "" {
+		return Module{}, fmt.Errorf("repository %q must be an absolute URL", m.Repository)
+	}
+
+	return m, nil
+}
+
+// checkPath reports whether p is a clean relative slash-separated path.
+func checkPath(p string) error {
+	if strings.HasPrefix(p, "/") || strings.HasSuffix(p, "/") || strings.Contains(p, "//") {
+		return fmt.Errorf("must not start or end with a slash or contain empty elements")
+	}
+	for _, elem := range strings.Split(p, "/") {
+		if elem == "." || elem == ".." {
+			return fmt.Errorf("must not contain %q elements", elem)
+		}
+	}
+	return nil
+}
+
+// Resolution explains how a requested path was resolved.
+type Resolution struct {
+	// Module is the module the path resolved to. Its MovedTo is set when Rule is an alias.
+	Module Module
+	// Rule is the path of the registered module or alias that matched,
+	// or empty when the path fell back to the repository base URL.
+	Rule string
+	// Subdir is the requested path relative to the module root, without leading slash.
+	Subdir string
+}
+
+// Explain resolves the requested path like Resolve and reports which rule matched.
+func (s *Service) Explain(ctx context.Context, path string) Resolution {
+	res := Resolution{Module: s.Resolve(ctx, path)}
+	if page, ok := s.registry.Load().lookup(path); ok {
+		res.Rule = strings.TrimPrefix(page.Module.ImportPath, s.domain+"/")
+		res.Subdir = strings.Trim(strings.TrimPrefix(path, res.Rule), "/")
+	}
+	return res
+}
+
+// Modules returns the registered modules ordered by import path.
+func (s *Service) Modules() []Module {
+	r := s.registry.Load()
+	modules := make([]Module, 0, len(r.paths))
+	for _, path := range r.paths {
+		modules = append(modules, r.page(path).Module)
+	}
+	return modules
+}
+
+// Aliases returns the registered aliases ordered by import path.
+// Their MovedTo is the import path of the module they resolve to.
+func (s *Service) Aliases() []Module {
+	r := s.registry.Load()
+	aliases := make([]Module, 0, len(r.aliases))
+	for _, path := range r.aliases {
+		aliases = append(aliases, r.page(path).Module)
+	}
+	return aliases
+}
+
+// Registered returns the registered modules followed by the registered aliases.
+func (s *Service) Registered() []Module {
+	return append(s.Modules(), s.Aliases()...)
+}
+
+// ModuleChange is a difference between two sets of registered modules.
+type ModuleChange struct {
+	// ImportPath is the import path of the changed module or alias.
+	ImportPath string
+	// Before is the module before the change; nil when it was added.
+	Before *Module
+	// After is the module after the change; nil when it was removed.
+	After *Module
+}
+
+// Diff returns the modules and aliases added, removed or changed from before to after,
+// ordered by import path.
+func Diff(before, after []Module) []ModuleChange {
+	old := make(map[string]Module, len(before))
+	for _, m := range before {
+		old[m.ImportPath] = m
+	}
+
+	var changes []ModuleChange
+	for _, m := range after {
+		prev, ok := old[m.ImportPath]
+		delete(old, m.ImportPath)
+		switch {
+		case !ok:
+			changes = append(changes, ModuleChange{ImportPath: m.ImportPath, After: &m})
+		case prev != m:
+			changes = append(changes, ModuleChange{ImportPath: m.ImportPath, Before: &prev, After: &m})
+		}
+	}
+	for _, m := range old {
+		changes = append(changes, ModuleChange{ImportPath: m.ImportPath, Before: &m})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ImportPath < changes[j].ImportPath })
+	return changes
+}
+
+// Policy returns the access policy of the requested path: the policy of the innermost
+// module or prefix containing it, or nil when everyone may resolve it.
+// Like Page, it allocates nothing.
+func (s *Service) Policy(path string) *access.Policy {
+	p, _ := lookupPrefix(s.registry.Load().policies, path)
+	return p
+}
@@ -0,0 +1,707 @@
+package gosvc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.gllm.dev/vanity-go/internal/access"
+)
+
+func TestService_Load(t *testing.T) {
+	tests := []struct {
+		name    string
+		modules []ModuleConfig
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			modules: []ModuleConfig{
+				{Path: "vanity-go"},
+				{Path: "tools/cli", Repository: "https://gitlab.com/gllm-dev/cli", VCS: "git"},
+				{Path: "legacy", VCS: "hg", Packages: []string{"cmd/legacy"}},
+			},
+		},
+		{
+			name:    "empty path",
+			modules: []ModuleConfig{{Path: ""}},
+			wantErr: "path is required",
+		},
+		{
+			name:    "leading slash",
+			modules: []ModuleConfig{{Path: "/vanity-go"}},
+			wantErr: "must not start or end with a slash",
+		},
+		{
+			name:    "empty element",
+			modules: []ModuleConfig{{Path: "tools//cli"}},
+			wantErr: "empty elements",
+		},
+		{
+			name:    "dot dot element",
+			modules: []ModuleConfig{{Path: "tools/../../etc"}},
+			wantErr: `must not contain ".." elements`,
+		},
+		{
+			name:    "invalid package",
+			modules: []ModuleConfig{{Path: "tools", Packages: []string{"/cmd"}}},
+			wantErr: `package "/cmd" must not start or end with a slash`,
+		},
+		{
+			name:    "duplicate path",
+			modules: []ModuleConfig{{Path: "vanity-go"}, {Path: "vanity-go"}},
+			wantErr: "duplicate path",
+		},
+		{
+			name:    "unknown vcs",
+			modules: []ModuleConfig{{Path: "vanity-go", VCS: "cvs"}},
+			wantErr: `unknown vcs "cvs"`,
+		},
+		{
+			name: "statuses",
+			modules: []ModuleConfig{
+				{Path: "active", Status: "active"},
+				{Path: "deprecated", Status: "deprecated", Replacement: "go.gllm.dev/active"},
+				{Path: "archived", Status: "archived"},
+				{Path: "hidden", Status: "hidden"},
+			},
+		},
+		{
+			name:    "unknown status",
+			modules: []ModuleConfig{{Path: "vanity-go", Status: "retired"}},
+			wantErr: `unknown status "retired"`,
+		},
+		{
+			name:    "replacement without deprecation",
+			modules: []ModuleConfig{{Path: "vanity-go", Replacement: "go.gllm.dev/other"}},
+			wantErr: `replacement requires status "deprecated"`,
+		},
+		{
+			name:    "invalid replacement",
+			modules: []ModuleConfig{{Path: "vanity-go", Status: "deprecated", Replacement: `go.gllm.dev/"><script>`}},
+			wantErr: "is not an import path",
+		},
+		{
+			name:    "relative repository",
+			modules: []ModuleConfig{{Path: "vanity-go", Repository: "gllm-dev/vanity-go"}},
+			wantErr: "must be an absolute URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+			err := svc.Load(context.Background(), &Config{Modules: tt.modules})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if len(svc.Modules()) != 0 {
+				t.Error("failed Load() should not register modules")
+			}
+		})
+	}
+}
+
+func TestService_Validate_ReportsAllErrors(t *testing.T) {
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Validate(&Config{Modules: []ModuleConfig{
+		{Path: "vanity-go"},
+		{Path: ""},
+		{Path: "vanity-go"},
+		{Path: "tools", VCS: "cvs"},
+	}})
+	if err == nil {
+		t.Fatal("Validate() expected error")
+	}
+	for _, want := range []string{
+		`module 2 (""): path is required`,
+		`module 3 ("vanity-go"): duplicate path`,
+		`module 4 ("tools"): unknown vcs "cvs"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to contain %q", err, want)
+		}
+	}
+	if len(svc.Modules()) != 0 {
+		t.Error("Validate() should not register modules")
+	}
+}
+
+func TestService_Load_Aliases(t *testing.T) {
+	modules := []ModuleConfig{{Path: "newname"}, {Path: "tools"}}
+	tests := []struct {
+		name    string
+		aliases []AliasConfig
+		wantErr string
+	}{
+		{
+			name:    "alias",
+			aliases: []AliasConfig{{Path: "oldname", Target: "newname"}},
+		},
+		{
+			name:    "chain",
+			aliases: []AliasConfig{{Path: "oldest", Target: "oldname"}, {Path: "oldname", Target: "newname", Redirect: true}},
+		},
+		{
+			name:    "missing target",
+			aliases: []AliasConfig{{Path: "oldname"}},
+			wantErr: "target is required",
+		},
+		{
+			name:    "invalid path",
+			aliases: []AliasConfig{{Path: "oldname/", Target: "newname"}},
+			wantErr: "path must not start or end with a slash",
+		},
+		{
+			name:    "unknown target",
+			aliases: []AliasConfig{{Path: "oldname", Target: "nowhere"}},
+			wantErr: `alias 1 ("oldname"): unknown target "nowhere"`,
+		},
+		{
+			name:    "conflicts with module",
+			aliases: []AliasConfig{{Path: "tools", Target: "newname"}},
+			wantErr: `alias 1 ("tools"): path is already a module`,
+		},
+		{
+			name:    "duplicate path",
+			aliases: []AliasConfig{{Path: "oldname", Target: "newname"}, {Path: "oldname", Target: "tools"}},
+			wantErr: `alias 2 ("oldname"): duplicate path`,
+		},
+		{
+			name:    "self",
+			aliases: []AliasConfig{{Path: "oldname", Target: "oldname"}},
+			wantErr: "cycle oldname -> oldname",
+		},
+		{
+			name:    "cycle",
+			aliases: []AliasConfig{{Path: "a", Target: "b"}, {Path: "b", Target: "c"}, {Path: "c", Target: "a"}},
+			wantErr: "cycle a -> b -> c -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+			err := svc.Load(context.Background(), &Config{Modules: modules, Aliases: tt.aliases})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() unexpected error: %v", err)
+				}
+				if got := len(svc.Aliases()); got != len(tt.aliases) {
+					t.Errorf("len(Aliases()) = %d, want %d", got, len(tt.aliases))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if len(svc.Modules()) != 0 {
+				t.Error("failed Load() should not register modules")
+			}
+		})
+	}
+}
+
+func TestService_Page_Alias(t *testing.T) {
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &Config{
+		Modules: []ModuleConfig{{Path: "newname", Repository: "https://gitlab.com/gllm-dev/newname"}},
+		Aliases: []AliasConfig{
+			{Path: "oldest", Target: "oldname", Redirect: true},
+			{Path: "oldname", Target: "newname"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := svc.Page(context.Background(), "oldest/cmd/tool")
+	html := string(page.HTML)
+	for _, want := range []string{
+		`<meta name="go-import" content="go.gllm.dev/oldest git https://gitlab.com/gllm-dev/newname">`,
+		`This module has moved to <a href="https://pkg.go.dev/go.gllm.dev/newname">go.gllm.dev/newname</a>.`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Page() missing %q in:\n%s", want, html)
+		}
+	}
+	if got := page.Header.Get("X-Module-Moved"); got != "go.gllm.dev/newname" {
+		t.Errorf("X-Module-Moved = %q, want %q", got, "go.gllm.dev/newname")
+	}
+	if !page.Redirect {
+		t.Error("Redirect = false, want true")
+	}
+	if got, want := page.Location("oldest/cmd/tool"), "https://go.gllm.dev/newname/cmd/tool"; got != want {
+		t.Errorf("Location() = %q, want %q", got, want)
+	}
+
+	if page := svc.Page(context.Background(), "oldname"); page.Redirect {
+		t.Error("Redirect = true for an alias without redirect")
+	}
+	if page := svc.Page(context.Background(), "newname"); page.Header.Get("X-Module-Moved") != "" || strings.Contains(string(page.HTML), "moved") {
+		t.Error("page of the target module announces a move")
+	}
+	if got := svc.Page(context.Background(), "newname").Header.Get("Etag"); got == page.Header.Get("Etag") {
+		t.Error("alias and target share an ETag")
+	}
+}
+
+func TestService_Page_Deprecated(t *testing.T) {
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &Config{Modules: []ModuleConfig{
+		{Path: "replaced", Status: "deprecated", Replacement: "go.gllm.dev/successor"},
+		{Path: "abandoned", Status: "deprecated"},
+		{Path: "archived", Status: "archived"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "replaced/pkg", want: `<p>Deprecated: use <a href="https://pkg.go.dev/go.gllm.dev/successor">go.gllm.dev/successor</a> instead.</p>`},
+		{path: "abandoned", want: "<p>Deprecated: this module is no longer maintained.</p>"},
+		{path: "archived", want: "<body>\nNothing to see here"},
+	}
+	for _, tt := range tests {
+		page := svc.Page(context.Background(), tt.path)
+		if !strings.Contains(string(page.HTML), tt.want) {
+			t.Errorf("Page(%q) missing %q in:\n%s", tt.path, tt.want, page.HTML)
+		}
+		if !strings.Contains(string(page.HTML), `<meta name="go-import" content="go.gllm.dev/`) {
+			t.Errorf("Page(%q) should still resolve for the go command", tt.path)
+		}
+	}
+}
+
+func TestService_Policy(t *testing.T) {
+	internal := &access.Config{Networks: []string{"10.0.0.0/8"}}
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &Config{
+		Modules: []ModuleConfig{
+			{Path: "public"},
+			{Path: "secret", Access: internal},
+			{Path: "internal/tools"},
+			{Path: "oldsecret/tool"},
+		},
+		Aliases: []AliasConfig{
+			{Path: "oldsecret", Target: "secret"},
+			{Path: "oldpublic", Target: "public"},
+			// The target lies under another alias, which is restricted through its own target.
+			{Path: "oldtool", Target: "oldsecret/tool"},
+		},
+		Policies: []PolicyConfig{{Prefix: "internal", Config: *internal}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path       string
+		restricted bool
+	}{
+		{path: "public/pkg"},
+		{path: "secret", restricted: true},
+		{path: "secret/cmd/tool", restricted: true},
+		{path: "secretive"},
+		{path: "oldsecret/pkg", restricted: true},
+		{path: "oldpublic"},
+		{path: "oldsecret/tool", restricted: true},
+		{path: "oldtool", restricted: true},
+		{path: "internal", restricted: true},
+		{path: "internal/tools/cli", restricted: true},
+		{path: "internal/unregistered", restricted: true},
+		{path: "internals"},
+		{path: ""},
+	}
+	for _, tt := range tests {
+		if got := svc.Policy(tt.path) != nil; got != tt.restricted {
+			t.Errorf("Policy(%q) restricted = %v, want %v", tt.path, got, tt.restricted)
+		}
+	}
+}
+
+func TestService_Load_Policies(t *testing.T) {
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Validate(&Config{
+		Modules: []ModuleConfig{
+			{Path: "secret", Access: &access.Config{}},
+			{Path: "internal", Access: &access.Config{Networks: []string{"10.0.0.0/8"}}},
+		},
+		Policies: []PolicyConfig{
+			{Prefix: "", Config: access.Config{Networks: []string{"10.0.0.0/8"}}},
+			{Prefix: "internal", Config: access.Config{Networks: []string{"10.0.0.0/8"}}},
+			{Prefix: "private/", Config: access.Config{Networks: []string{"10.0.0.0/8"}}},
+			{Prefix: "private", Config: access.Config{Networks: []string{"everyone"}}},
+		},
+	})
+	for _, want := range []string{
+		`module 1 ("secret"): access: no networks, basic_auth, bearer or subjects allowed`,
+		`policy 1 (""): prefix is required`,
+		`policy 2 ("internal"): a module or another policy already restricts this path`,
+		`policy 3 ("private/"): prefix must not start or end with a slash`,
+		`policy 4 ("private"): invalid network "everyone"`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestService_Explain(t *testing.T) {
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &Config{Modules: []ModuleConfig{
+		{Path: "tools"},
+		{Path: "tools/cli"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path       string
+		wantRule   string
+		wantModule string
+		wantSubdir string
+	}{
+		{path: "tools", wantRule: "tools", wantModule: "go.gllm.dev/tools"},
+		{path: "tools/internal/x", wantRule: "tools", wantModule: "go.gllm.dev/tools", wantSubdir: "internal/x"},
+		{path: "tools/cli/cmd", wantRule: "tools/cli", wantModule: "go.gllm.dev/tools/cli", wantSubdir: "cmd"},
+		{path: "other/pkg", wantModule: "go.gllm.dev/other/pkg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := svc.Explain(context.Background(), tt.path)
+			if got.Rule != tt.wantRule || got.Module.ImportPath != tt.wantModule || got.Subdir != tt.wantSubdir {
+				t.Errorf("Explain() = %+v, want rule %q, module %q, subdir %q", got, tt.wantRule, tt.wantModule, tt.wantSubdir)
+			}
+		})
+	}
+}
+
+func TestService_Resolve_Registered(t *testing.T) {
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	err := svc.Load(context.Background(), &Config{Modules: []ModuleConfig{
+		{Path: "vanity-go"},
+		{Path: "tools", Repository: "https://gitlab.com/gllm-dev/tools"},
+		{Path: "tools/cli", Repository: "https://gitlab.com/gllm-dev/cli", VCS: "hg"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want Module
+	}{
+		{
+			path: "vanity-go",
+			want: Module{ImportPath: "go.gllm.dev/vanity-go", VCS: "git", Repository: "https://github.com/gllm-dev/vanity-go"},
+		},
+		{
+			path: "vanity-go/internal/services",
+			want: Module{ImportPath: "go.gllm.dev/vanity-go", VCS: "git", Repository: "https://github.com/gllm-dev/vanity-go"},
+		},
+		{
+			path: "vanity-go/",
+			want: Module{ImportPath: "go.gllm.dev/vanity-go", VCS: "git", Repository: "https://github.com/gllm-dev/vanity-go"},
+		},
+		{
+			path: "tools/other",
+			want: Module{ImportPath: "go.gllm.dev/tools", VCS: "git", Repository: "https://gitlab.com/gllm-dev/tools"},
+		},
+		{
+			path: "tools/cli/cmd",
+			want: Module{ImportPath: "go.gllm.dev/tools/cli", VCS: "hg", Repository: "https://gitlab.com/gllm-dev/cli"},
+		},
+		{
+			path: "vanity-gopher",
+			want: Module{ImportPath: "go.gllm.dev/vanity-gopher", VCS: "git", Repository: "https://github.com/gllm-dev/vanity-gopher"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := svc.Resolve(context.Background(), tt.path); got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_Load_Replaces(t *testing.T) {
+	ctx := context.Background()
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+
+	if err := svc.Load(ctx, &Config{Modules: []ModuleConfig{{Path: "app"}}}); err != nil {
+		t.Fatal(err)
+	}
+	before := svc.Page(ctx, "app")
+
+	if err := svc.Load(ctx, &Config{Modules: []ModuleConfig{{Path: "app", Repository: "https://gitlab.com/gllm-dev/app"}}}); err != nil {
+		t.Fatal(err)
+	}
+	after := svc.Page(ctx, "app")
+
+	if before.Header.Get("ETag") == after.Header.Get("ETag") {
+		t.Error("ETag should change when the module changes")
+	}
+	if !strings.Contains(string(after.HTML), "https://gitlab.com/gllm-dev/app") {
+		t.Errorf("page should use the reloaded repository, got %s", after.HTML)
+	}
+	if strings.Contains(string(before.HTML), "gitlab") {
+		t.Error("pages handed out before a reload must not change")
+	}
+
+	if err := svc.Load(ctx, &Config{Modules: []ModuleConfig{{Path: "app", VCS: "cvs"}}}); err == nil {
+		t.Fatal("expected error for invalid module")
+	}
+	if got := svc.Page(ctx, "app"); got != after {
+		t.Error("failed Load() should keep the current modules")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+
+	if err := svc.Load(ctx, &Config{
+		Modules: []ModuleConfig{{Path: "app"}, {Path: "kept"}, {Path: "old"}},
+		Aliases: []AliasConfig{{Path: "legacy", Target: "app"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	before := svc.Registered()
+
+	if err := svc.Load(ctx, &Config{
+		Modules: []ModuleConfig{{Path: "app", Repository: "https://gitlab.com/gllm-dev/app"}, {Path: "kept"}, {Path: "new"}},
+		Aliases: []AliasConfig{{Path: "legacy", Target: "app"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	changes := Diff(before, svc.Registered())
+
+	var got []string
+	for _, c := range changes {
+		kind := "changed"
+		switch {
+		case c.Before == nil:
+			kind = "added"
+		case c.After == nil:
+			kind = "removed"
+		}
+		got = append(got, c.ImportPath+" "+kind)
+	}
+	want := []string{
+		"go.gllm.dev/app changed",
+		"go.gllm.dev/legacy changed",
+		"go.gllm.dev/new added",
+		"go.gllm.dev/old removed",
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+	if changes[0].Before.Repository != "https://github.com/gllm-dev/app" || changes[0].After.Repository != "https://gitlab.com/gllm-dev/app" {
+		t.Errorf("Diff() app change = %+v -> %+v", changes[0].Before, changes[0].After)
+	}
+}
+
+func TestService_Page_MatchesVanity(t *testing.T) {
+	ctx := context.Background()
+	registered := New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := registered.Load(ctx, &Config{Modules: []ModuleConfig{{Path: "tools"}}}); err != nil {
+		t.Fatal(err)
+	}
+	fallback := New("go.gllm.dev", "https://github.com/gllm-dev")
+
+	got := registered.Page(ctx, "tools")
+	want := fallback.Page(ctx, "tools")
+
+	if string(got.HTML) != string(want.HTML) {
+		t.Errorf("precomputed page differs from rendered page:\ngot:  %s\nwant: %s", got.HTML, want.HTML)
+	}
+	if got.Header.Get("ETag") != want.Header.Get("ETag") {
+		t.Errorf("ETag = %s, want %s", got.Header.Get("ETag"), want.Header.Get("ETag"))
+	}
+}
+
+func TestService_Page_Allocations(t *testing.T) {
+	ctx := context.Background()
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(ctx, &Config{Modules: []ModuleConfig{{Path: "tools"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = svc.Page(ctx, "tools/cmd/cli")
+	})
+	if allocs != 0 {
+		t.Errorf("Page() for a registered module allocated %v times, want 0", allocs)
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []ModuleConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			content: `modules:
+  - path: vanity-go
+  - path: tools
+    repository: https://gitlab.com/gllm-dev/tools
+    vcs: git
+`,
+			want: []ModuleConfig{
+				{Path: "vanity-go"},
+				{Path: "tools", Repository: "https://gitlab.com/gllm-dev/tools", VCS: "git"},
+			},
+		},
+		{
+			name: "unknown field",
+			content: `modules:
+  - path: vanity-go
+    repo: https://github.com/gllm-dev/vanity-go
+`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			content: `modules: [`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "modules.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := ReadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if fmt.Sprint(cfg.Modules) != fmt.Sprint(tt.want) {
+				t.Errorf("ReadConfig() = %+v, want %+v", cfg.Modules, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("ReadConfig() should fail for a missing file")
+	}
+}
+
+// newBenchmarkService returns a service with n registered modules.
+func newBenchmarkService(b *testing.B, n int) *Service {
+	b.Helper()
+
+	modules := make([]ModuleConfig, n)
+	for i := range modules {
+		modules[i] = ModuleConfig{Path: fmt.Sprintf("module%d", i)}
+	}
+
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(context.Background(), &Config{Modules: modules}); err != nil {
+		b.Fatal(err)
+	}
+	return svc
+}
+
+func BenchmarkService_Page(b *testing.B) {
+	ctx := context.Background()
+	svc := newBenchmarkService(b, 100000)
+
+	paths := make([]string, 1024)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("module%d/pkg/sub", i*97)
+	}
+
+	b.Run("registered_100k", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = svc.Page(ctx, paths[i%len(paths)])
+		}
+	})
+
+	b.Run("registered_100k_parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				_ = svc.Page(ctx, paths[i%len(paths)])
+				i++
+			}
+		})
+	})
+
+	b.Run("fallback", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = svc.Page(ctx, "unregistered/pkg")
+		}
+	})
+}
+
+// BenchmarkService_SetVersions measures the update of the versions of a single module
+// and of a batch of them in a registry of 100k modules; a single update should not
+// grow with the registry.
+func BenchmarkService_SetVersions(b *testing.B) {
+	ctx := context.Background()
+	svc := newBenchmarkService(b, 100000)
+	v := ParseVersions("go.gllm.dev/module0", []string{"v1.0.0"})
+
+	b.Run("one_of_100k", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			svc.SetVersions(ctx, map[string]*Versions{fmt.Sprintf("go.gllm.dev/module%d", i%100000): v})
+		}
+	})
+
+	batch := make(map[string]*Versions, 1000)
+	for i := 0; i < 1000; i++ {
+		batch[fmt.Sprintf("go.gllm.dev/module%d", i*97)] = v
+	}
+	b.Run("batch_1000_of_100k", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			svc.SetVersions(ctx, batch)
+		}
+	})
+}
+
+func BenchmarkService_Load(b *testing.B) {
+	modules := make([]ModuleConfig, 100000)
+	for i := range modules {
+		modules[i] = ModuleConfig{Path: fmt.Sprintf("module%d", i)}
+	}
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := svc.Load(context.Background(), &Config{Modules: modules}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
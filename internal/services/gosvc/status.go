@@ -0,0 +1,34 @@
+package gosvc
+
+// Status is the lifecycle state of a module.
+type Status string
+
+const (
+	// StatusActive modules are listed and served to everyone. It is the default.
+	StatusActive Status = "active"
+	// StatusDeprecated modules are still served, with a banner on their page
+	// pointing at their replacement, if any.
+	StatusDeprecated Status = "deprecated"
+	// StatusArchived modules are still served but left out of the index.
+	StatusArchived Status = "archived"
+	// StatusHidden modules are only served to the go command and left out of
+	// the index and the manifest; other clients get 404 Not Found.
+	StatusHidden Status = "hidden"
+)
+
+// Statuses are all the lifecycle states.
+var Statuses = []Status{StatusActive, StatusDeprecated, StatusArchived, StatusHidden}
+
+// OrActive returns s, or StatusActive when s is empty.
+func (s Status) OrActive() Status {
+	if s == "" {
+		return StatusActive
+	}
+	return s
+}
+
+// Listed reports whether modules with the status appear in the index of the domain.
+func (s Status) Listed() bool {
+	s = s.OrActive()
+	return s == StatusActive || s == StatusDeprecated
+}
@@ -2,9 +2,47 @@ package gosvc
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html"
+	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer creates the spans for module resolution and template rendering.
+var tracer = otel.Tracer("go.gllm.dev/vanity-go/internal/services/gosvc")
+
+// startSpan starts a child span only when the span in ctx is recording.
+// Otherwise it returns the non-recording span from ctx unchanged, whose End is a no-op.
+// Spans are then free when tracing is disabled or the trace is not sampled,
+// which keeps the precomputed page path allocation-free.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.IsRecording() {
+		return ctx, parent
+	}
+	return tracer.Start(ctx, name)
+}
+
+// annotate records the resolved module on the current span, if it is recording.
+func annotate(ctx context.Context, m Module) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		attribute.String("vanity.import_path", m.ImportPath),
+		attribute.String("vanity.repository", m.Repository),
+	)
+}
+
 // Service handles the generation of vanity import HTML responses.
 // It contains the domain and repository information needed to construct
 // the proper meta tags for Go's import path resolution.
@@ -13,32 +51,96 @@ type Service struct {
 	domain string
 	// repository is the base repository URL (e.g., "https://github.com/gllm-dev")
 	repository string
+	// registry holds the registered modules and their precomputed pages.
+	// It is replaced as a whole on every Load, so readers never see a partial update;
+	// pages rendered again in between replace their former page alone.
+	registry atomic.Pointer[registry]
+
+	// mu serializes the replacements of the registry.
+	mu sync.Mutex
+	// versions are the versions of the registered modules by import path, see SetVersions.
+	versions map[string]*Versions
+	// advisories are the vulnerability advisories by module path, see SetAdvisories.
+	advisories map[string][]Advisory
+	// majorAdvisories are the module paths of advisories ending in a major version
+	// suffix by the module path without it, lowest major version first.
+	majorAdvisories map[string][]string
+	// loaded is closed and replaced by every Load, see Loaded.
+	loaded chan struct{}
+	// rendered is closed and replaced whenever pages are rendered again, see Rendered.
+	rendered chan struct{}
+}
+
+// details is what is known about a registered module besides its configuration,
+// shown on its page.
+type details struct {
+	// versions are the versions of the module; nil when they are unknown.
+	versions *Versions
+	// advisories are the known vulnerabilities of the module.
+	advisories []Advisory
+}
+
+// details returns what is known about the module at importPath. s.mu must be held.
+func (s *Service) details(importPath string) details {
+	return details{versions: s.versions[importPath], advisories: s.advisoriesOf(importPath)}
+}
+
+// rerender renders the pages of the registered modules at importPaths again, with
+// their current details, replacing them in their entries of the registry one by one;
+// the rest of the registry is left as it is. s.mu must be held.
+func (s *Service) rerender(ctx context.Context, importPaths ...string) {
+	r := s.registry.Load()
+	for _, importPath := range importPaths {
+		e, ok := r.pages[strings.TrimPrefix(importPath, s.domain+"/")]
+		if !ok {
+			continue
+		}
+		if page := e.page.Load(); page.Module.MovedTo == "" && page.Module.ImportPath == importPath {
+			e.page.Store(s.newPage(ctx, page.Module, s.details(importPath)))
+		}
+	}
+	s.notifyRendered()
 }
 
 // New creates a new Service instance with the given domain and repository base URL.
 // The domain should be the vanity import domain without protocol (e.g., "go.gllm.dev").
 // The repository should be the base URL where modules are hosted (e.g., "https://github.com/gllm-dev").
+// The service starts with no registered modules; see Load.
 func New(domain, repository string) *Service {
-	return &Service{
+	s := &Service{
 		domain:     domain,
 		repository: repository,
+		versions:   make(map[string]*Versions),
+		advisories: make(map[string][]Advisory),
+		loaded:     make(chan struct{}),
+		rendered:   make(chan struct{}),
 	}
+	s.registry.Store(newRegistry(nil, nil, &Config{}))
+	return s
+}
+
+// Domain returns the vanity domain the service answers for.
+func (s *Service) Domain() string {
+	return s.domain
 }
 
 // template defines the HTML template returned for vanity import requests.
 // It includes:
 // - go-import meta tag: tells go get where to find the repository
 // - go-source meta tag: provides source code browsing information for godoc.org
-// The placeholders {{.domain}}, {{.repository}}, and {{.module}} are replaced
-// with actual values when generating the response.
+// The placeholders {{.domain}}, {{.vcs}}, {{.repository}} and {{.display}} are replaced
+// with actual values when generating the response; {{.notice}} announces the new
+// import path of a renamed module and the deprecation of a deprecated one, and is
+// empty otherwise; {{.advisories}} lists the known vulnerabilities of the module and
+// {{.versions}} its versions, when they are known.
 const template = `<!DOCTYPE html>
 <html>
 <head>
-<meta name="go-import" content="{{.domain}} git {{.repository}}">
-<meta name="go-source" content="{{.domain}} {{.repository}} {{.repository}}/tree/main{/dir} {{.repository}}/blob/main{/dir}/{file}#L{line}">
+<meta name="go-import" content="{{.domain}} {{.vcs}} {{.repository}}">
+<meta name="go-source" content="{{.domain}} {{.display}}">
 </head>
 <body>
-Nothing to see here; <a href="https://pkg.go.dev/{{.domain}}">see the package on pkg.go.dev</a>.
+{{.notice}}{{.advisories}}{{.versions}}Nothing to see here; <a href="https://pkg.go.dev/{{.domain}}">see the package on pkg.go.dev</a>.
 </body>
 </html>`
 
@@ -54,18 +156,276 @@ Nothing to see here; <a href="https://pkg.go.dev/{{.domain}}">see the package on
 //	For domain="go.gllm.dev", repository="https://github.com/gllm-dev", and module="vanity-go",
 //	it generates meta tags that redirect "go.gllm.dev/vanity-go" to "https://github.com/gllm-dev/vanity-go".
 func (s *Service) Vanity(ctx context.Context, module string) string {
-	domain := s.domain
-	repository := s.repository
+	return string(s.Page(ctx, module).HTML)
+}
+
+// Module is the result of resolving a requested path: everything needed to render its page.
+type Module struct {
+	// ImportPath is the import path announced in the meta tags (e.g., "go.gllm.dev/vanity-go").
+	ImportPath string `json:"import_path"`
+	// VCS is the version control system of the repository (e.g., "git").
+	VCS string `json:"vcs"`
+	// Repository is the URL of the repository hosting the module.
+	Repository string `json:"repository"`
+	// Display is the go-source content following the import path: the home page,
+	// directory and file URL templates. Empty means the GitHub layout of Repository
+	// on the main branch.
+	Display string `json:"display,omitempty"`
+	// MovedTo is the import path the module was renamed to when ImportPath is an alias;
+	// it is empty otherwise.
+	MovedTo string `json:"moved_to,omitempty"`
+	// Status is the lifecycle state of the module. Empty means StatusActive.
+	Status Status `json:"status,omitempty"`
+	// Replacement is the import path a deprecated module points its users to, if any.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// defaultDisplay returns the go-source display of a repository laid out like GitHub.
+func defaultDisplay(repository string) string {
+	return repository + " " + repository + "/tree/main{/dir} " + repository + "/blob/main{/dir}/{file}#L{line}"
+}
+
+// withDefaults returns m with its empty optional fields set to their defaults.
+func (m Module) withDefaults() Module {
+	if m.Display == "" {
+		m.Display = defaultDisplay(m.Repository)
+	}
+	m.Status = m.Status.OrActive()
+	return m
+}
+
+// Page is the response served for a module.
+// Pages of registered modules are shared between requests and must not be modified.
+type Page struct {
+	// Module is the module the page was rendered for.
+	Module Module
+	// Versions are the versions of the module shown on the page; nil when they are unknown.
+	Versions *Versions
+	// Advisories are the known vulnerabilities of the module shown on the page.
+	Advisories []Advisory
+	// Notices are the banners of the page as HTML: the move and deprecation notices
+	// of the module, its known vulnerabilities and its versions.
+	Notices string
+	// HTML is the rendered page.
+	HTML []byte
+	// Header holds the response headers describing the page: its Content-Type,
+	// a strong ETag built from Service.Digest and, for aliases, X-Module-Moved
+	// with the new import path.
+	Header http.Header
+	// JSON is the JSON form of the page, an Info.
+	JSON []byte
+	// JSONHeader holds the Content-Type and the strong ETag of JSON.
+	JSONHeader http.Header
+	// Redirect reports whether browsers are sent to the new import path of an alias,
+	// see Location, rather than shown the page.
+	Redirect bool
+	// root is the alias path the page is registered under; empty for other pages.
+	root string
+}
+
+// Info is the JSON form of the page of a module.
+type Info struct {
+	Module
+	// Versions are the versions of the module; omitted when they are unknown.
+	Versions *Versions `json:"versions,omitempty"`
+	// Advisories are the known vulnerabilities of the module; omitted when there are none.
+	Advisories []Advisory `json:"advisories,omitempty"`
+}
+
+// newPage renders the page of m with its details d.
+func (s *Service) newPage(ctx context.Context, m Module, d details) *Page {
+	// Info holds strings and times, which always marshal.
+	data, _ := json.Marshal(Info{Module: m, Versions: d.versions, Advisories: d.advisories})
+	sum := sha256.Sum256(data)
+	page := &Page{
+		Module:     m,
+		Versions:   d.versions,
+		Advisories: d.advisories,
+		Notices:    m.withDefaults().notices(d),
+		HTML:       []byte(s.render(ctx, m, d)),
+		Header: http.Header{
+			"Content-Type": {"text/html; charset=utf-8"},
+			"Etag":         {`"` + s.digest(m, d) + `"`},
+		},
+		JSON: data,
+		JSONHeader: http.Header{
+			"Content-Type": {"application/json"},
+			"Etag":         {`"` + hex.EncodeToString(sum[:]) + `"`},
+		},
+	}
+	if m.MovedTo != "" {
+		page.Header["X-Module-Moved"] = []string{m.MovedTo}
+	}
+	return page
+}
+
+// Location returns the URL of the requested path under the new import path of a
+// renamed module, keeping the path inside the module (e.g., "go.gllm.dev/oldname/cmd"
+// becomes "https://go.gllm.dev/newname/cmd"). It is empty when the page is not an alias.
+func (p *Page) Location(path string) string {
+	if p.root == "" {
+		return ""
+	}
+	return "https://" + p.Module.MovedTo + strings.TrimPrefix(path, p.root)
+}
 
-	var fullDomain, fullRepository string
-	if module == "" {
-		fullDomain = domain
-		fullRepository = repository
-	} else {
-		fullDomain = domain + "/" + module
-		fullRepository = repository + "/" + module
+// Page returns the page served for the requested path.
+//
+// Paths inside a registered module are answered with the page precomputed
+// when the module was loaded, without allocating. Any other path falls back
+// to the repository base URL and its page is rendered on every call.
+func (s *Service) Page(ctx context.Context, path string) *Page {
+	if page, ok := s.registry.Load().lookup(path); ok {
+		annotate(ctx, page.Module)
+		return page
+	}
+
+	ctx, span := startSpan(ctx, "gosvc.Page")
+	defer span.End()
+
+	return s.newPage(ctx, s.Resolve(ctx, path), details{})
+}
+
+// Resolve maps the requested path to the module it is served from.
+// Paths inside a registered module resolve to it; any other path resolves
+// to the same path under the repository base URL.
+func (s *Service) Resolve(ctx context.Context, path string) Module {
+	if page, ok := s.registry.Load().lookup(path); ok {
+		annotate(ctx, page.Module)
+		return page.Module
+	}
+
+	ctx, span := startSpan(ctx, "gosvc.Resolve")
+	defer span.End()
+
+	m := Module{
+		ImportPath: s.domain,
+		VCS:        "git",
+		Repository: s.repository,
+	}
+	if path != "" {
+		m.ImportPath += "/" + path
+		m.Repository += "/" + path
 	}
 
-	parsedTemplate := strings.ReplaceAll(template, "{{.domain}}", fullDomain)
-	return strings.ReplaceAll(parsedTemplate, "{{.repository}}", fullRepository)
+	annotate(ctx, m)
+	return m
+}
+
+// Render fills the HTML template with the resolved module, without its details.
+func (s *Service) Render(ctx context.Context, m Module) string {
+	return s.render(ctx, m, details{})
+}
+
+// render fills the HTML template with the resolved module and its details.
+func (s *Service) render(ctx context.Context, m Module, d details) string {
+	_, span := startSpan(ctx, "gosvc.Render")
+	defer span.End()
+
+	m = m.withDefaults()
+	size := 0
+	for _, segment := range templateSegments {
+		size += len(m.expand(segment, d))
+	}
+
+	var b strings.Builder
+	b.Grow(size)
+	for _, segment := range templateSegments {
+		b.WriteString(m.expand(segment, d))
+	}
+	return b.String()
+}
+
+// expand returns the value of a template placeholder for m and its details d, escaped
+// for HTML, or segment itself if it is literal text.
+func (m Module) expand(segment string, d details) string {
+	switch segment {
+	case "{{.domain}}":
+		return html.EscapeString(m.ImportPath)
+	case "{{.vcs}}":
+		return html.EscapeString(m.VCS)
+	case "{{.repository}}":
+		return html.EscapeString(m.Repository)
+	case "{{.display}}":
+		return html.EscapeString(m.Display)
+	case "{{.notice}}":
+		return m.notice()
+	case "{{.advisories}}":
+		return advisoriesNotice(d.advisories)
+	case "{{.versions}}":
+		return versionsNotice(m, d.versions)
+	default:
+		return segment
+	}
+}
+
+// notices returns the banners of the page of m with its details d, as the template
+// places them.
+func (m Module) notices(d details) string {
+	return m.notice() + advisoriesNotice(d.advisories) + versionsNotice(m, d.versions)
+}
+
+// notice returns the banners of the page of m, each a paragraph of its own, escaped for HTML.
+func (m Module) notice() string {
+	var notice string
+	if m.MovedTo != "" {
+		movedTo := html.EscapeString(m.MovedTo)
+		notice += `<p>This module has moved to <a href="https://pkg.go.dev/` + movedTo + `">` + movedTo + "</a>.</p>\n"
+	}
+	if m.Status == StatusDeprecated {
+		if m.Replacement != "" {
+			replacement := html.EscapeString(m.Replacement)
+			notice += `<p>Deprecated: use <a href="https://pkg.go.dev/` + replacement + `">` + replacement + "</a> instead.</p>\n"
+		} else {
+			notice += "<p>Deprecated: this module is no longer maintained.</p>\n"
+		}
+	}
+	return notice
+}
+
+// templateSegments is the template split into literal text and placeholders,
+// so rendering is a single pass over precomputed pieces.
+var templateSegments = splitTemplate(template)
+
+// splitTemplate splits t around its "{{...}}" placeholders, keeping them as separate segments.
+func splitTemplate(t string) []string {
+	var segments []string
+	for {
+		start := strings.Index(t, "{{")
+		if start < 0 {
+			return append(segments, t)
+		}
+		end := strings.Index(t[start:], "}}")
+		if end < 0 {
+			return append(segments, t)
+		}
+		end += start + len("}}")
+		segments = append(segments, t[:start], t[start:end])
+		t = t[end:]
+	}
+}
+
+// templateDigest identifies the template, so digests change when it does.
+var templateDigest = sha256.Sum256([]byte(template))
+
+// Digest returns a hex-encoded digest identifying the page rendered for m.
+// Two modules share a digest exactly when Render produces the same page for them,
+// which makes it suitable as a strong HTTP entity tag without rendering the page.
+func (s *Service) Digest(m Module) string {
+	return s.digest(m, details{})
+}
+
+// digest returns the digest of the page rendered for m with its details d.
+func (s *Service) digest(m Module, d details) string {
+	m = m.withDefaults()
+	h := sha256.New()
+	h.Write(templateDigest[:])
+	// Fields are hashed escaped, as they are rendered, so the digest changes with the page.
+	for _, field := range []string{m.ImportPath, m.VCS, m.Repository, m.Display, m.MovedTo, string(m.Status), m.Replacement} {
+		h.Write([]byte(html.EscapeString(field)))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(advisoriesNotice(d.advisories)))
+	h.Write([]byte(versionsNotice(m, d.versions)))
+	return hex.EncodeToString(h.Sum(nil))
 }
@@ -4,6 +4,11 @@ import (
 	"context"
 	"strings"
 	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 func TestNew(t *testing.T) {
@@ -193,9 +198,48 @@ func TestService_Vanity_SpecialCharacters(t *testing.T) {
 	}
 }
 
+func TestService_Vanity_Escaping(t *testing.T) {
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+
+	// The fallback page reflects the requested path.
+	got := svc.Vanity(context.Background(), `"><script>alert(1)</script>`)
+	if strings.Contains(got, "<script>") {
+		t.Errorf("Vanity() reflects the path unescaped:\n%s", got)
+	}
+	if want := `go.gllm.dev/&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt; git`; !strings.Contains(got, want) {
+		t.Errorf("Vanity() = %s, want the escaped path %s", got, want)
+	}
+
+	m := Module{
+		ImportPath:  "go.gllm.dev/app",
+		VCS:         "git",
+		Repository:  `https://github.com/gllm-dev/app"><b>`,
+		Display:     "<i>",
+		MovedTo:     `go.gllm.dev/new"><b>`,
+		Status:      StatusDeprecated,
+		Replacement: `go.gllm.dev/other"><b>`,
+	}
+	got = svc.Render(context.Background(), m)
+	for _, unsafe := range []string{"<b>", "<i>"} {
+		if strings.Contains(got, unsafe) {
+			t.Errorf("Render() = %s, want %s escaped", got, unsafe)
+		}
+	}
+	for _, want := range []string{
+		`content="go.gllm.dev/app git https://github.com/gllm-dev/app&#34;&gt;&lt;b&gt;"`,
+		`content="go.gllm.dev/app &lt;i&gt;"`,
+		`<a href="https://pkg.go.dev/go.gllm.dev/new&#34;&gt;&lt;b&gt;">`,
+		`<a href="https://pkg.go.dev/go.gllm.dev/other&#34;&gt;&lt;b&gt;">`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Render() = %s, want it to contain %s", got, want)
+		}
+	}
+}
+
 func BenchmarkService_Vanity(b *testing.B) {
 	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
-	
+
 	b.Run("root_package", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			_ = svc.Vanity(context.Background(), "")
@@ -213,4 +257,80 @@ func BenchmarkService_Vanity(b *testing.B) {
 			_ = svc.Vanity(context.Background(), "pkg/sub/deep/nested/package")
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestService_Page_Spans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(context.Background(), &Config{Modules: []ModuleConfig{{Path: "registered"}}}); err != nil {
+		t.Fatal(err)
+	}
+	exporter.Reset()
+
+	spansOf := func(path string) map[string]tracetest.SpanStub {
+		exporter.Reset()
+		ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+		_ = svc.Page(ctx, path)
+		parent.End()
+
+		spans := make(map[string]tracetest.SpanStub)
+		for _, span := range exporter.GetSpans() {
+			spans[span.Name] = span
+		}
+		return spans
+	}
+
+	importPathOf := func(span tracetest.SpanStub) string {
+		for _, attr := range span.Attributes {
+			if attr.Key == "vanity.import_path" {
+				return attr.Value.AsString()
+			}
+		}
+		return ""
+	}
+
+	t.Run("rendered page", func(t *testing.T) {
+		spans := spansOf("tools")
+
+		root, ok := spans["gosvc.Page"]
+		if !ok {
+			t.Fatalf("missing gosvc.Page span, got %v", spans)
+		}
+		for _, name := range []string{"gosvc.Resolve", "gosvc.Render"} {
+			child, ok := spans[name]
+			if !ok {
+				t.Errorf("missing %s span", name)
+				continue
+			}
+			if child.Parent.SpanID() != root.SpanContext.SpanID() {
+				t.Errorf("%s span should be a child of gosvc.Page", name)
+			}
+		}
+		if got := importPathOf(spans["gosvc.Resolve"]); got != "go.gllm.dev/tools" {
+			t.Errorf("vanity.import_path = %q, want %q", got, "go.gllm.dev/tools")
+		}
+	})
+
+	t.Run("precomputed page", func(t *testing.T) {
+		spans := spansOf("registered/sub")
+
+		if len(spans) != 1 {
+			t.Errorf("precomputed page should not create spans, got %v", spans)
+		}
+		if got := importPathOf(spans["request"]); got != "go.gllm.dev/registered" {
+			t.Errorf("vanity.import_path = %q, want %q", got, "go.gllm.dev/registered")
+		}
+	})
+
+	t.Run("not recording", func(t *testing.T) {
+		exporter.Reset()
+		_ = svc.Page(context.Background(), "tools")
+		if spans := exporter.GetSpans(); len(spans) != 0 {
+			t.Errorf("expected no spans without a recording parent, got %d", len(spans))
+		}
+	})
+}
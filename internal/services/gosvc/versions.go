@@ -0,0 +1,236 @@
+package gosvc
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Versions are the versions of a module found in the tags of its repository, and what
+// the go.mod file of its latest version tells its users.
+type Versions struct {
+	// Latest is the highest release version that is not retracted, if any.
+	Latest string `json:"latest,omitempty"`
+	// LatestPrerelease is the highest prerelease version that is not retracted, when it is
+	// higher than Latest.
+	LatestPrerelease string `json:"latest_prerelease,omitempty"`
+	// Versions are every version of the module, highest first.
+	Versions []string `json:"versions"`
+	// Majors are the import paths of the other major versions tagged in the repository
+	// (e.g., "go.gllm.dev/app/v2" for the module "go.gllm.dev/app"), lowest first.
+	Majors []string `json:"majors,omitempty"`
+	// Deprecated is the deprecation message of the module, from the "// Deprecated:"
+	// comment of the module directive of the go.mod file of GoModVersion.
+	Deprecated string `json:"deprecated,omitempty"`
+	// Retractions are the retract directives of the go.mod file of GoModVersion.
+	Retractions []Retraction `json:"retractions,omitempty"`
+	// Retracted are the versions the retractions apply to, highest first.
+	Retracted []string `json:"retracted,omitempty"`
+	// FetchedAt is when the tags were listed.
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// ParseVersions returns the versions of the module at importPath among the tags of its
+// repository, as the go command sees them: a tag is a version of the module when it is a
+// canonical semantic version without build metadata whose major version matches the
+// major version suffix of importPath. Tags of other major versions are reported as
+// Majors; prefixed tags of modules in subdirectories are ignored.
+func ParseVersions(importPath string, tags []string) *Versions {
+	prefix, pathMajor, ok := module.SplitPathVersion(importPath)
+	if !ok {
+		prefix, pathMajor = importPath, ""
+	}
+	gopkgin := strings.HasPrefix(pathMajor, ".")
+
+	v := &Versions{Versions: []string{}}
+	majors := map[int]string{}
+	for _, tag := range tags {
+		if !semver.IsValid(tag) || semver.Canonical(tag) != tag || semver.Build(tag) != "" {
+			continue
+		}
+		if module.CheckPathMajor(tag, pathMajor) == nil {
+			v.Versions = append(v.Versions, tag)
+			continue
+		}
+		if gopkgin {
+			continue
+		}
+		major, _ := strconv.Atoi(strings.TrimPrefix(semver.Major(tag), "v"))
+		path := prefix
+		if major >= 2 {
+			path += "/v" + strconv.Itoa(major)
+		}
+		if path != importPath {
+			majors[max(major, 1)] = path
+		}
+	}
+
+	semver.Sort(v.Versions)
+	slices.Reverse(v.Versions)
+	v.setLatest()
+
+	keys := make([]int, 0, len(majors))
+	for major := range majors {
+		keys = append(keys, major)
+	}
+	sort.Ints(keys)
+	for _, major := range keys {
+		v.Majors = append(v.Majors, majors[major])
+	}
+	return v
+}
+
+// setLatest sets Latest and LatestPrerelease to the highest versions that are not retracted.
+func (v *Versions) setLatest() {
+	v.Latest, v.LatestPrerelease = "", ""
+	for _, version := range v.Versions {
+		if slices.Contains(v.Retracted, version) {
+			continue
+		}
+		if v.LatestPrerelease == "" && v.Latest == "" && semver.Prerelease(version) != "" {
+			v.LatestPrerelease = version
+		}
+		if semver.Prerelease(version) == "" {
+			v.Latest = version
+			break
+		}
+	}
+}
+
+// Retraction is a retract directive of a go.mod file.
+type Retraction struct {
+	// Low and High are the bounds of the retracted versions; they are equal for a single version.
+	Low  string `json:"low"`
+	High string `json:"high"`
+	// Rationale is the comment explaining the retraction, if any.
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// GoModVersion returns the version whose go.mod file the go command reads the deprecation
+// and the retractions of the module from: the highest release, or the highest prerelease
+// when there is none, retracted or not. It is empty when the module has no versions.
+func (v *Versions) GoModVersion() string {
+	for _, version := range v.Versions {
+		if semver.Prerelease(version) == "" {
+			return version
+		}
+	}
+	if len(v.Versions) > 0 {
+		return v.Versions[0]
+	}
+	return ""
+}
+
+// SetGoMod records the deprecation message and the retractions of the go.mod file data of
+// GoModVersion, and leaves the retracted versions out of Latest and LatestPrerelease,
+// like the go command.
+func (v *Versions) SetGoMod(data []byte) error {
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return err
+	}
+	if f.Module == nil {
+		return fmt.Errorf("go.mod has no module directive")
+	}
+	v.Deprecated = f.Module.Deprecated
+	v.Retractions, v.Retracted = nil, nil
+	for _, r := range f.Retract {
+		v.Retractions = append(v.Retractions, Retraction{Low: r.Low, High: r.High, Rationale: r.Rationale})
+	}
+	for _, version := range v.Versions {
+		if _, ok := v.retraction(version); ok {
+			v.Retracted = append(v.Retracted, version)
+		}
+	}
+	v.setLatest()
+	return nil
+}
+
+// retraction returns the first retraction applying to version, if any.
+func (v *Versions) retraction(version string) (Retraction, bool) {
+	for _, r := range v.Retractions {
+		if semver.Compare(r.Low, version) <= 0 && semver.Compare(version, r.High) <= 0 {
+			return r, true
+		}
+	}
+	return Retraction{}, false
+}
+
+// Versions returns the versions of the registered module at importPath last set with
+// SetVersions, or nil when they are unknown.
+func (s *Service) Versions(importPath string) *Versions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versions[importPath]
+}
+
+// SetVersions records the versions of registered modules, keyed by import path, and
+// renders their pages again to show them. They are kept across Load while the modules
+// stay registered. Versions of paths that are not registered modules are ignored.
+func (s *Service) SetVersions(ctx context.Context, versions map[string]*Versions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.registry.Load()
+	changed := make([]string, 0, len(versions))
+	for importPath, v := range versions {
+		page := r.page(strings.TrimPrefix(importPath, s.domain+"/"))
+		if page == nil || page.Module.MovedTo != "" || page.Module.ImportPath != importPath {
+			continue
+		}
+		s.versions[importPath] = v
+		changed = append(changed, importPath)
+	}
+	if len(changed) > 0 {
+		s.rerender(ctx, changed...)
+	}
+}
+
+// versionsNotice returns the paragraphs of the page of a module listing its versions,
+// or nothing when they are unknown. Versions are canonical semantic versions, which
+// need no escaping; import paths and the messages of go.mod files are escaped.
+func versionsNotice(m Module, v *Versions) string {
+	if v == nil {
+		return ""
+	}
+	importPath := html.EscapeString(m.ImportPath)
+	var notice string
+	if v.Deprecated != "" {
+		notice += "<p>Deprecated: " + html.EscapeString(v.Deprecated) + "</p>\n"
+	}
+	if v.Latest != "" {
+		notice += `<p>Latest version: <a href="https://pkg.go.dev/` + importPath + "@" + v.Latest + `">` + v.Latest + "</a>.</p>\n"
+	}
+	if v.LatestPrerelease != "" {
+		notice += `<p>Latest prerelease: <a href="https://pkg.go.dev/` + importPath + "@" + v.LatestPrerelease + `">` + v.LatestPrerelease + "</a>.</p>\n"
+	}
+	if len(v.Majors) > 0 {
+		links := make([]string, len(v.Majors))
+		for i, major := range v.Majors {
+			major = html.EscapeString(major)
+			links[i] = `<a href="https://pkg.go.dev/` + major + `">` + major + "</a>"
+		}
+		notice += "<p>Other major versions: " + strings.Join(links, ", ") + ".</p>\n"
+	}
+	if len(v.Retracted) > 0 {
+		notice += "<p>Retracted versions:</p>\n<ul>\n"
+		for _, version := range v.Retracted {
+			notice += "<li>" + version
+			if r, _ := v.retraction(version); r.Rationale != "" {
+				notice += ": " + html.EscapeString(r.Rationale)
+			}
+			notice += "</li>\n"
+		}
+		notice += "</ul>\n"
+	}
+	return notice
+}
@@ -0,0 +1,182 @@
+package gosvc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseVersions(t *testing.T) {
+	tags := []string{"v0.9.0", "v1.0.0", "v1.2.0", "v1.3.0-rc.1", "v1.1.0+build", "v1.4", "cmd/v1.5.0", "latest", "v2.0.0", "v2.1.0-beta", "v3.0.0-alpha"}
+	tests := []struct {
+		name       string
+		importPath string
+		tags       []string
+		want       Versions
+	}{
+		{
+			name:       "v1 module",
+			importPath: "go.gllm.dev/app",
+			tags:       tags,
+			want: Versions{
+				Latest:           "v1.2.0",
+				LatestPrerelease: "v1.3.0-rc.1",
+				Versions:         []string{"v1.3.0-rc.1", "v1.2.0", "v1.0.0", "v0.9.0"},
+				Majors:           []string{"go.gllm.dev/app/v2", "go.gllm.dev/app/v3"},
+			},
+		},
+		{
+			name:       "major version suffix",
+			importPath: "go.gllm.dev/app/v2",
+			tags:       tags,
+			want: Versions{
+				Latest:           "v2.0.0",
+				LatestPrerelease: "v2.1.0-beta",
+				Versions:         []string{"v2.1.0-beta", "v2.0.0"},
+				Majors:           []string{"go.gllm.dev/app", "go.gllm.dev/app/v3"},
+			},
+		},
+		{
+			name:       "prerelease only",
+			importPath: "go.gllm.dev/app/v3",
+			tags:       tags,
+			want: Versions{
+				LatestPrerelease: "v3.0.0-alpha",
+				Versions:         []string{"v3.0.0-alpha"},
+				Majors:           []string{"go.gllm.dev/app", "go.gllm.dev/app/v2"},
+			},
+		},
+		{
+			name:       "prerelease older than the latest release",
+			importPath: "go.gllm.dev/app",
+			tags:       []string{"v1.0.0-rc.1", "v1.0.0"},
+			want:       Versions{Latest: "v1.0.0", Versions: []string{"v1.0.0", "v1.0.0-rc.1"}},
+		},
+		{
+			name:       "gopkg.in",
+			importPath: "gopkg.in/yaml.v3",
+			tags:       []string{"v2.4.0", "v3.0.1", "v3.0.0"},
+			want:       Versions{Latest: "v3.0.1", Versions: []string{"v3.0.1", "v3.0.0"}},
+		},
+		{
+			name:       "no tags",
+			importPath: "go.gllm.dev/app",
+			want:       Versions{Versions: []string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseVersions(tt.importPath, tt.tags); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseVersions() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_SetVersions(t *testing.T) {
+	ctx := context.Background()
+	svc := New("go.gllm.dev", "https://github.com/gllm-dev")
+	cfg := &Config{
+		Modules: []ModuleConfig{{Path: "app"}, {Path: "tools"}},
+		Aliases: []AliasConfig{{Path: "old", Target: "app"}},
+	}
+	if err := svc.Load(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	before := svc.Page(ctx, "app")
+
+	v := ParseVersions("go.gllm.dev/app", []string{"v1.0.0", "v2.0.0"})
+	v.FetchedAt = time.Date(2026, 3, 2, 10, 15, 0, 0, time.UTC)
+	svc.SetVersions(ctx, map[string]*Versions{"go.gllm.dev/app": v, "go.gllm.dev/old": v, "go.gllm.dev/unknown": v})
+
+	page := svc.Page(ctx, "app/cmd")
+	for _, want := range []string{
+		`<p>Latest version: <a href="https://pkg.go.dev/go.gllm.dev/app@v1.0.0">v1.0.0</a>.</p>`,
+		`<p>Other major versions: <a href="https://pkg.go.dev/go.gllm.dev/app/v2">go.gllm.dev/app/v2</a>.</p>`,
+	} {
+		if !strings.Contains(string(page.HTML), want) {
+			t.Errorf("page = %s, want it to contain %s", page.HTML, want)
+		}
+	}
+	if page.Header.Get("Etag") == before.Header.Get("Etag") || page.JSONHeader.Get("Etag") == before.JSONHeader.Get("Etag") {
+		t.Error("ETags did not change with the versions")
+	}
+	var info Info
+	if err := json.Unmarshal(page.JSON, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.ImportPath != "go.gllm.dev/app" || info.Versions == nil || info.Versions.Latest != "v1.0.0" {
+		t.Errorf("JSON = %s, want the module and its versions", page.JSON)
+	}
+	if strings.Contains(string(svc.Page(ctx, "old").HTML), "Latest version") || svc.Versions("go.gllm.dev/old") != nil {
+		t.Error("versions were set on an alias")
+	}
+	if string(svc.Page(ctx, "tools").HTML) != svc.Vanity(ctx, "tools") || svc.Versions("go.gllm.dev/unknown") != nil {
+		t.Error("versions were set on another path")
+	}
+
+	// Versions are kept while the module stays registered.
+	if err := svc.Load(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.Page(ctx, "app"); string(got.HTML) != string(page.HTML) {
+		t.Errorf("page after Load = %s, want the versions kept", got.HTML)
+	}
+	if err := svc.Load(ctx, &Config{Modules: []ModuleConfig{{Path: "tools"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Load(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Versions("go.gllm.dev/app") != nil {
+		t.Error("versions of a removed module were kept")
+	}
+}
+
+func TestVersions_SetGoMod(t *testing.T) {
+	v := ParseVersions("go.gllm.dev/app", []string{"v1.0.0", "v1.1.0", "v1.2.0", "v1.3.0-rc.1"})
+	if got := v.GoModVersion(); got != "v1.2.0" {
+		t.Fatalf("GoModVersion() = %q, want v1.2.0", got)
+	}
+	err := v.SetGoMod([]byte(`// Deprecated: use <go.gllm.dev/app/v2> instead.
+module go.gllm.dev/app
+
+retract (
+	v1.2.0 // Published too early.
+	[v1.3.0-rc.1, v1.3.0]
+)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Deprecated != "use <go.gllm.dev/app/v2> instead." {
+		t.Errorf("Deprecated = %q", v.Deprecated)
+	}
+	want := []Retraction{{Low: "v1.2.0", High: "v1.2.0", Rationale: "Published too early."}, {Low: "v1.3.0-rc.1", High: "v1.3.0"}}
+	if !reflect.DeepEqual(v.Retractions, want) {
+		t.Errorf("Retractions = %+v, want %+v", v.Retractions, want)
+	}
+	if !reflect.DeepEqual(v.Retracted, []string{"v1.3.0-rc.1", "v1.2.0"}) {
+		t.Errorf("Retracted = %q", v.Retracted)
+	}
+	if v.Latest != "v1.1.0" || v.LatestPrerelease != "" {
+		t.Errorf("Latest = %q, LatestPrerelease = %q; want v1.1.0 and none", v.Latest, v.LatestPrerelease)
+	}
+
+	notice := versionsNotice(Module{ImportPath: "go.gllm.dev/app"}, v)
+	for _, want := range []string{
+		"<p>Deprecated: use &lt;go.gllm.dev/app/v2&gt; instead.</p>",
+		"<li>v1.3.0-rc.1</li>\n<li>v1.2.0: Published too early.</li>",
+	} {
+		if !strings.Contains(notice, want) {
+			t.Errorf("notice = %s, want it to contain %s", notice, want)
+		}
+	}
+	if err := v.SetGoMod([]byte("retract v1.0.0\n")); err == nil {
+		t.Error("SetGoMod() of a go.mod without module directive succeeded")
+	}
+}
@@ -0,0 +1,89 @@
+package upstreamsvc
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Config holds the configuration of the checks of the repositories of the registered modules.
+type Config struct {
+	// CheckInterval is how often every repository is checked. Checks are disabled when it is zero.
+	CheckInterval time.Duration
+	// CheckTimeout bounds the check of each repository.
+	CheckTimeout time.Duration
+	// CloneDir is the directory mirror clones of the repositories are kept in, to read
+	// their go.mod files with the git command. Without it, only the existence of the
+	// repositories is checked.
+	CloneDir string
+	// VerifyGoMod is what happens when a module is registered, or moved to another
+	// repository, with a go.mod declaring another module path: VerifyOff, VerifyWarn or
+	// VerifyReject.
+	VerifyGoMod string
+	// VersionsTTL is how long the versions of a module, listed from the tags of its
+	// repository, are kept before they are listed again in the background. Versions are
+	// not listed when it is zero.
+	VersionsTTL time.Duration
+	// Proxy is the URL of a module proxy the go.mod files of the latest versions of the
+	// modules are read from (e.g., "https://proxy.golang.org"). Without it, they are read
+	// from the clones in CloneDir, if any.
+	Proxy string
+}
+
+// Verification modes of the go.mod of newly registered modules.
+const (
+	// VerifyOff registers modules without reading their go.mod.
+	VerifyOff = "off"
+	// VerifyWarn registers modules and warns about mismatching go.mod files.
+	VerifyWarn = "warn"
+	// VerifyReject refuses to register modules with a mismatching go.mod file.
+	VerifyReject = "reject"
+)
+
+const (
+	// Default values for the upstream configuration.
+	// These can be overridden through the config package.
+
+	// defaultCheckInterval is the default interval between checks: disabled.
+	defaultCheckInterval = 0
+	// defaultCheckTimeout is the default time limit of the check of a repository.
+	defaultCheckTimeout = time.Minute
+	// defaultVersionsTTL is the default lifetime of the versions of a module: not listed.
+	defaultVersionsTTL = 0
+)
+
+// DefaultConfig returns the upstream configuration used when nothing is overridden.
+func DefaultConfig() *Config {
+	return &Config{
+		CheckInterval: defaultCheckInterval,
+		CheckTimeout:  defaultCheckTimeout,
+		VerifyGoMod:   VerifyOff,
+		VersionsTTL:   defaultVersionsTTL,
+	}
+}
+
+// Validate reports every invalid value of the configuration.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.CheckInterval < 0 {
+		errs = append(errs, fmt.Errorf("check interval must not be negative"))
+	}
+	if c.CheckTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("check timeout must be positive"))
+	}
+	if c.VersionsTTL < 0 {
+		errs = append(errs, fmt.Errorf("versions TTL must not be negative"))
+	}
+	if c.Proxy != "" {
+		if u, err := url.Parse(c.Proxy); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid proxy %q: must be an http or https URL", c.Proxy))
+		}
+	}
+	switch c.VerifyGoMod {
+	case VerifyOff, VerifyWarn, VerifyReject:
+	default:
+		errs = append(errs, fmt.Errorf("invalid go.mod verification %q: must be %q, %q or %q", c.VerifyGoMod, VerifyOff, VerifyWarn, VerifyReject))
+	}
+	return errors.Join(errs...)
+}
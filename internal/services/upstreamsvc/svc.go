@@ -0,0 +1,501 @@
+// Package upstreamsvc periodically checks the repositories hosting the registered modules,
+// so that a renamed or deleted repository, or a go.mod file declaring another module path,
+// is noticed before the go command fails for users.
+//
+// Every git repository is checked for existence through its git smart HTTP ref
+// advertisement. When a clone directory is configured, the go.mod file at its HEAD is
+// then read from a mirror clone and its module directive compared with the vanity path.
+// Repositories of other version control systems are skipped.
+//
+// Modules can also be verified before they are registered, so that a go.mod declaring
+// another module path is reported, or rejected, when the module is added.
+//
+// The versions of the modules are listed from the tags of their repositories, read from
+// the ref advertisement or, for other repositories, from their clone, and kept in the
+// gosvc.Service for their pages, with the deprecation and the retractions declared by the
+// go.mod file of their latest version, read from a module proxy or from their clone.
+package upstreamsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.gllm.dev/vanity-go/internal/errjoin"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"go.gllm.dev/vanity-go/internal/upstream"
+)
+
+// ErrRejected is returned by Verify when a go.mod file does not match the import path of its module.
+var ErrRejected = errors.New("go.mod does not match the import path")
+
+// Status is the outcome of the check of a repository.
+type Status string
+
+const (
+	// StatusOK repositories exist and their go.mod declares the vanity path.
+	StatusOK Status = "ok"
+	// StatusUnverified repositories exist, but their go.mod file was not read:
+	// no clone directory is configured, or cloning failed.
+	StatusUnverified Status = "unverified"
+	// StatusUnreachable repositories do not exist, are private, or could not be reached.
+	StatusUnreachable Status = "unreachable"
+	// StatusMissingGoMod repositories have no go.mod file at their HEAD.
+	StatusMissingGoMod Status = "missing_go_mod"
+	// StatusMismatch repositories have a go.mod declaring another module path.
+	StatusMismatch Status = "mismatch"
+	// StatusSkipped repositories are not hosted with git and are not checked.
+	StatusSkipped Status = "skipped"
+)
+
+// Statuses are all the check outcomes.
+var Statuses = []Status{StatusOK, StatusUnverified, StatusUnreachable, StatusMissingGoMod, StatusMismatch, StatusSkipped}
+
+// Problem reports whether the status means the go command fails to fetch the module.
+func (s Status) Problem() bool {
+	return s == StatusUnreachable || s == StatusMissingGoMod || s == StatusMismatch
+}
+
+// Result is the outcome of the check of the repository of a module.
+type Result struct {
+	// ImportPath is the import path of the module.
+	ImportPath string `json:"import_path"`
+	// Repository is the URL of the repository hosting it.
+	Repository string `json:"repository"`
+	// Status is the outcome of the check.
+	Status Status `json:"status"`
+	// GoMod is the go.mod file read from the repository, if any.
+	GoMod *upstream.GoMod `json:"go_mod,omitempty"`
+	// Detail explains a status other than StatusOK.
+	Detail string `json:"detail,omitempty"`
+	// CheckedAt is when the repository was checked.
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the outcome of a round of checks of every registered module.
+type Report struct {
+	// CheckedAt is when the round started; zero until the first round completed.
+	CheckedAt time.Time `json:"checked_at"`
+	// Results are the results of the modules, ordered by import path.
+	Results []Result `json:"results"`
+}
+
+// Problems returns the results whose status is a problem.
+func (r Report) Problems() []Result {
+	var problems []Result
+	for _, res := range r.Results {
+		if res.Status.Problem() {
+			problems = append(problems, res)
+		}
+	}
+	return problems
+}
+
+// Service checks the repositories of the modules registered in a gosvc.Service.
+type Service struct {
+	// svc is the service whose modules are checked.
+	svc *gosvc.Service
+	// interval is how often the modules are checked; zero disables Run.
+	interval time.Duration
+	// timeout bounds the check of each repository.
+	timeout time.Duration
+	// client fetches the ref advertisements.
+	client *http.Client
+	// mirror keeps the clones go.mod files are read from; nil when none is configured.
+	mirror *upstream.Mirror
+	// verify is the verification mode of the go.mod of newly registered modules.
+	verify string
+	// versionsTTL is how long the versions of a module are kept; zero disables RunVersions.
+	versionsTTL time.Duration
+	// proxy is the URL of the module proxy go.mod files of versions are read from, if any.
+	proxy string
+	// logger reports the problems found.
+	logger *slog.Logger
+	// modules is the number of modules by status at the last round.
+	modules *prometheus.GaugeVec
+	// lastCheck is when the last round started.
+	lastCheck prometheus.Gauge
+
+	// round serializes the rounds of checks.
+	round sync.Mutex
+	mu    sync.RWMutex
+	// report is the outcome of the last round.
+	report Report
+	// refresh serializes the rounds of RefreshVersions and guards listFailed and goMods.
+	refresh sync.Mutex
+	// listFailed is when listing the tags of a repository last failed, by import path,
+	// so that it is not retried before the versions TTL elapsed.
+	listFailed map[string]time.Time
+	// goMods are the go.mod files last read for the versions of the modules, by import
+	// path, used when they cannot be read again.
+	goMods map[string]goModAt
+}
+
+// goModAt is the go.mod file of a version of a module.
+type goModAt struct {
+	version string
+	data    []byte
+}
+
+// New creates a Service checking the modules of svc. Its gauges are registered in reg.
+func New(cfg *Config, svc *gosvc.Service, reg prometheus.Registerer, logger *slog.Logger) *Service {
+	s := &Service{
+		svc:         svc,
+		interval:    cfg.CheckInterval,
+		timeout:     cfg.CheckTimeout,
+		verify:      cfg.VerifyGoMod,
+		versionsTTL: cfg.VersionsTTL,
+		proxy:       cfg.Proxy,
+		client:      &http.Client{},
+		logger:      logger,
+		listFailed:  make(map[string]time.Time),
+		goMods:      make(map[string]goModAt),
+		modules: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "vanity_upstream_modules",
+			Help: "Registered modules by outcome of the last check of their repository.",
+		}, []string{"status"}),
+		lastCheck: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "vanity_upstream_last_check_timestamp_seconds",
+			Help: "Time the last check of the repositories of the registered modules started.",
+		}),
+	}
+	if cfg.CloneDir != "" {
+		s.mirror = upstream.NewMirror(cfg.CloneDir)
+	}
+	reg.MustRegister(s.modules, s.lastCheck)
+	return s
+}
+
+// Enabled reports whether Run checks the modules periodically.
+func (s *Service) Enabled() bool {
+	return s.interval > 0
+}
+
+// Run checks every module at once and then at every interval, until ctx is done.
+// It returns immediately when checks are disabled.
+func (s *Service) Run(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.CheckAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Report returns the outcome of the last round of checks.
+func (s *Service) Report() Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.report
+}
+
+// CheckAll checks the repository of every registered module, one after the other,
+// and keeps the outcome as the last report. Aliases are not checked: the go.mod of
+// the module they point to declares its new path.
+func (s *Service) CheckAll(ctx context.Context) Report {
+	s.round.Lock()
+	defer s.round.Unlock()
+
+	report := Report{CheckedAt: time.Now().UTC()}
+	counts := make(map[Status]int, len(Statuses))
+	for _, m := range s.svc.Modules() {
+		if ctx.Err() != nil {
+			return s.Report()
+		}
+		res := s.Check(ctx, m)
+		if res.Status.Problem() {
+			s.logger.WarnContext(ctx, "Module repository check failed",
+				slog.String("import_path", res.ImportPath),
+				slog.String("repository", res.Repository),
+				slog.String("status", string(res.Status)),
+				slog.String("detail", res.Detail))
+		}
+		counts[res.Status]++
+		report.Results = append(report.Results, res)
+	}
+
+	s.mu.Lock()
+	s.report = report
+	s.mu.Unlock()
+	for _, status := range Statuses {
+		s.modules.WithLabelValues(string(status)).Set(float64(counts[status]))
+	}
+	s.lastCheck.Set(float64(report.CheckedAt.UnixNano()) / 1e9)
+	return report
+}
+
+// Check checks the repository of m.
+func (s *Service) Check(ctx context.Context, m gosvc.Module) Result {
+	res := Result{ImportPath: m.ImportPath, Repository: m.Repository, CheckedAt: time.Now().UTC()}
+	if m.VCS != "git" {
+		res.Status, res.Detail = StatusSkipped, fmt.Sprintf("vcs %s is not checked", m.VCS)
+		return res
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if _, err := upstream.ListRefs(ctx, s.client, m.Repository); err != nil {
+		res.Status, res.Detail = StatusUnreachable, err.Error()
+		return res
+	}
+	if s.mirror == nil {
+		res.Status, res.Detail = StatusUnverified, "no clone directory is configured to read go.mod from"
+		return res
+	}
+	res.Status, res.GoMod, res.Detail = readGoMod(ctx, s.mirror, m)
+	return res
+}
+
+// readGoMod fetches the repository of m into mirror and compares the go.mod file at its
+// HEAD with the import path of m. When the repository cannot be fetched, the go.mod file
+// is read from the clone already in mirror, if any.
+func readGoMod(ctx context.Context, mirror *upstream.Mirror, m gosvc.Module) (Status, *upstream.GoMod, string) {
+	if err := mirror.Sync(ctx, m.Repository); err != nil && !mirror.Cloned(m.Repository) {
+		return StatusUnverified, nil, err.Error()
+	}
+	gomod, err := upstream.FindGoMod(m.ImportPath, func(name string) ([]byte, error) {
+		return mirror.ReadFile(ctx, m.Repository, "HEAD", name)
+	})
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return StatusMissingGoMod, nil, "no go.mod file at HEAD"
+	case err != nil && gomod.File != "":
+		return StatusMismatch, &gomod, err.Error()
+	case err != nil:
+		return StatusUnverified, nil, err.Error()
+	case !gomod.Matches(m.ImportPath):
+		return StatusMismatch, &gomod, gomod.Mismatch(m.ImportPath)
+	default:
+		return StatusOK, &gomod, ""
+	}
+}
+
+// Verify reads the go.mod files of the repositories of modules, which are about to be
+// registered, and compares them with their import paths, as configured by VerifyGoMod.
+// It returns a warning for every module whose go.mod could not be read, and for every
+// mismatch when warning; when rejecting, the mismatches are returned as errors wrapping
+// ErrRejected, joined with errors.Join. Repositories are fetched into the clone
+// directory, or a temporary one.
+func (s *Service) Verify(ctx context.Context, modules []gosvc.Module) ([]string, error) {
+	if s.verify == VerifyOff || len(modules) == 0 {
+		return nil, nil
+	}
+	mirror := s.mirror
+	if mirror == nil {
+		dir, err := os.MkdirTemp("", "vanity-go-verify-")
+		if err != nil {
+			return []string{fmt.Sprintf("go.mod not verified: %v", err)}, nil
+		}
+		defer os.RemoveAll(dir)
+		mirror = upstream.NewMirror(dir)
+	}
+
+	var warnings []string
+	var rejected []error
+	for _, m := range modules {
+		if m.VCS != "git" {
+			continue
+		}
+		mctx, cancel := context.WithTimeout(ctx, s.timeout)
+		status, _, detail := readGoMod(mctx, mirror, m)
+		cancel()
+		switch {
+		case status == StatusOK:
+		case status.Problem() && s.verify == VerifyReject:
+			rejected = append(rejected, fmt.Errorf("%w: %s: %s", ErrRejected, m.ImportPath, detail))
+		case status == StatusUnverified:
+			warnings = append(warnings, fmt.Sprintf("%s: go.mod not verified: %s", m.ImportPath, detail))
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s: %s", m.ImportPath, detail))
+		}
+	}
+	for _, w := range warnings {
+		s.logger.WarnContext(ctx, "Module go.mod verification", slog.String("warning", w))
+	}
+	return warnings, errors.Join(rejected...)
+}
+
+// VerifyRegistered verifies the go.mod of every registered module, as Verify, and logs what
+// it finds. It is meant for the registry loaded at startup, which is served before it is
+// verified so that slow or failing repositories never keep the server from starting:
+// rejected modules are therefore only logged, as errors, and stay registered.
+func (s *Service) VerifyRegistered(ctx context.Context) {
+	_, err := s.Verify(ctx, s.svc.Modules())
+	for _, err := range errjoin.Split(err) {
+		s.logger.ErrorContext(ctx, "Registered module failed go.mod verification", slog.String("error", err.Error()))
+	}
+}
+
+// versionsTick is the longest time between two rounds of RunVersions, so that the
+// versions of newly registered modules are listed soon.
+const versionsTick = time.Minute
+
+// RunVersions lists the versions of every registered module at once and then keeps them
+// up to date, listing them again when they are older than the versions TTL, until ctx is
+// done. It returns immediately when the listing is disabled.
+func (s *Service) RunVersions(ctx context.Context) {
+	if s.versionsTTL <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(s.versionsTTL, versionsTick))
+	defer ticker.Stop()
+	for {
+		s.RefreshVersions(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshVersions lists the versions of the registered git modules whose versions are
+// unknown or older than the versions TTL, reads the go.mod file of their latest version,
+// and sets them in the gosvc.Service. When the tags of a repository cannot be listed, the
+// versions already known are kept, and the listing is retried once the TTL elapsed; when
+// only the go.mod file cannot be read, what was read from it before is kept. The versions
+// listed in a round are set at once, so that pages are rendered again once per round.
+func (s *Service) RefreshVersions(ctx context.Context) {
+	s.refresh.Lock()
+	defer s.refresh.Unlock()
+
+	now := time.Now()
+	failed := make(map[string]time.Time, len(s.listFailed))
+	listed := make(map[string]*gosvc.Versions)
+	// Versions listed before ctx is done are still set.
+	defer func() { s.svc.SetVersions(ctx, listed) }()
+	modules := s.svc.Modules()
+	for _, m := range modules {
+		if ctx.Err() != nil {
+			return
+		}
+		if m.VCS != "git" {
+			continue
+		}
+		if v := s.svc.Versions(m.ImportPath); v != nil && now.Sub(v.FetchedAt) < s.versionsTTL {
+			continue
+		}
+		if at, ok := s.listFailed[m.ImportPath]; ok && now.Sub(at) < s.versionsTTL {
+			failed[m.ImportPath] = at
+			continue
+		}
+
+		mctx, cancel := context.WithTimeout(ctx, s.timeout)
+		v, err := s.listVersions(mctx, m)
+		cancel()
+		if err != nil {
+			failed[m.ImportPath] = now
+			s.logger.WarnContext(ctx, "Module versions listing failed",
+				slog.String("import_path", m.ImportPath),
+				slog.String("repository", m.Repository),
+				slog.String("error", err.Error()))
+			continue
+		}
+		listed[m.ImportPath] = v
+	}
+	s.listFailed = failed
+	for path := range s.goMods {
+		if !slices.ContainsFunc(modules, func(m gosvc.Module) bool { return m.ImportPath == path }) {
+			delete(s.goMods, path)
+		}
+	}
+}
+
+// listVersions lists the versions of m and reads the go.mod file of its latest version.
+// Failing to read it is only logged, and the go.mod file last read for the same version,
+// if any, is used instead.
+func (s *Service) listVersions(ctx context.Context, m gosvc.Module) (*gosvc.Versions, error) {
+	tags, err := s.ListTags(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	v := gosvc.ParseVersions(m.ImportPath, tags)
+	v.FetchedAt = time.Now().UTC()
+	version := v.GoModVersion()
+	if version == "" {
+		return v, nil
+	}
+
+	data, err := s.readGoModAt(ctx, m, version)
+	if err == nil && data != nil {
+		err = v.SetGoMod(data)
+	}
+	if err == nil {
+		if data != nil {
+			s.goMods[m.ImportPath] = goModAt{version: version, data: data}
+		}
+		return v, nil
+	}
+	s.logger.WarnContext(ctx, "Module go.mod reading failed",
+		slog.String("import_path", m.ImportPath),
+		slog.String("version", version),
+		slog.String("error", err.Error()))
+	if last, ok := s.goMods[m.ImportPath]; ok && last.version == version {
+		// It was parsed before.
+		_ = v.SetGoMod(last.data)
+	}
+	return v, nil
+}
+
+// readGoModAt returns the go.mod file of m at version, from the module proxy when one is
+// configured, or else from the clone of its repository. It returns nil when there is
+// neither.
+func (s *Service) readGoModAt(ctx context.Context, m gosvc.Module, version string) ([]byte, error) {
+	if s.proxy != "" {
+		return upstream.FetchGoMod(ctx, s.client, s.proxy, m.ImportPath, version)
+	}
+	if s.mirror == nil {
+		return nil, nil
+	}
+	if err := s.mirror.Sync(ctx, m.Repository); err != nil && !s.mirror.Cloned(m.Repository) {
+		return nil, err
+	}
+	var data []byte
+	_, err := upstream.FindGoMod(m.ImportPath, func(name string) ([]byte, error) {
+		content, err := s.mirror.ReadFile(ctx, m.Repository, "refs/tags/"+version, name)
+		if err == nil {
+			data = content
+		}
+		return content, err
+	})
+	return data, err
+}
+
+// ListTags returns the tags of the repository of m. Repositories served over HTTP are
+// read from their ref advertisement; when that fails, or for other repositories, the
+// tags are read from their clone, fetched first, if a clone directory is configured.
+func (s *Service) ListTags(ctx context.Context, m gosvc.Module) ([]string, error) {
+	var err error
+	if u, _ := url.Parse(m.Repository); u != nil && (u.Scheme == "https" || u.Scheme == "http") {
+		var refs []upstream.Ref
+		if refs, err = upstream.ListRefs(ctx, s.client, m.Repository); err == nil {
+			return upstream.Tags(refs), nil
+		}
+	}
+	if s.mirror == nil {
+		if err == nil {
+			err = fmt.Errorf("no clone directory is configured to list the tags of %s", m.Repository)
+		}
+		return nil, err
+	}
+	if syncErr := s.mirror.Sync(ctx, m.Repository); syncErr != nil && !s.mirror.Cloned(m.Repository) {
+		return nil, errors.Join(err, syncErr)
+	}
+	return s.mirror.Tags(ctx, m.Repository)
+}
@@ -0,0 +1,274 @@
+package upstreamsvc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"go.gllm.dev/vanity-go/internal/errjoin"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+	"go.gllm.dev/vanity-go/internal/upstream/upstreamtest"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestService returns a Service checking modules registered under the repositories
+// of a test git server, which hosts app, v2 (a module in a major version subdirectory),
+// forked (declaring its former path) and nomod (without go.mod).
+func newTestService(t *testing.T, cloneDir string) (*Service, *prometheus.Registry) {
+	t.Helper()
+	srv := upstreamtest.NewServer(t, map[string]map[string]string{
+		"app":    {"go.mod": "module go.gllm.dev/app\n"},
+		"v2":     {"go.mod": "module go.gllm.dev/v2\n", "v2/go.mod": "module go.gllm.dev/v2/v2\n"},
+		"forked": {"go.mod": "module github.com/gllm-dev/forked\n"},
+		"nomod":  {"README.md": "hello\n"},
+	})
+	svc := gosvc.New("go.gllm.dev", srv.URL)
+	err := svc.Load(context.Background(), &gosvc.Config{
+		Modules: []gosvc.ModuleConfig{
+			{Path: "app"},
+			{Path: "forked"},
+			{Path: "gone"},
+			{Path: "nomod"},
+			{Path: "svn", VCS: "svn", Repository: "https://svn.example.com/svn"},
+			{Path: "v2/v2", Repository: srv.URL + "/v2"},
+		},
+		Aliases: []gosvc.AliasConfig{{Path: "old", Target: "app"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := prometheus.NewRegistry()
+	cfg := &Config{CheckInterval: time.Hour, CheckTimeout: time.Minute, CloneDir: cloneDir}
+	return New(cfg, svc, reg, discardLogger), reg
+}
+
+func TestService_CheckAll(t *testing.T) {
+	s, reg := newTestService(t, t.TempDir())
+	if !s.Report().CheckedAt.IsZero() {
+		t.Fatal("Report() before the first check has a time")
+	}
+
+	report := s.CheckAll(context.Background())
+	want := []struct {
+		importPath string
+		status     Status
+		goMod      string
+	}{
+		{"go.gllm.dev/app", StatusOK, "go.mod"},
+		{"go.gllm.dev/forked", StatusMismatch, "go.mod"},
+		{"go.gllm.dev/gone", StatusUnreachable, ""},
+		{"go.gllm.dev/nomod", StatusMissingGoMod, ""},
+		{"go.gllm.dev/svn", StatusSkipped, ""},
+		{"go.gllm.dev/v2/v2", StatusOK, "v2/go.mod"},
+	}
+	if len(report.Results) != len(want) {
+		t.Fatalf("CheckAll() = %+v, want %d results", report.Results, len(want))
+	}
+	for i, res := range report.Results {
+		var goMod string
+		if res.GoMod != nil {
+			goMod = res.GoMod.File
+		}
+		if res.ImportPath != want[i].importPath || res.Status != want[i].status || goMod != want[i].goMod {
+			t.Errorf("result %d = %s %s (%s, %s), want %s %s (%s)", i, res.ImportPath, res.Status, goMod, res.Detail, want[i].importPath, want[i].status, want[i].goMod)
+		}
+	}
+	if detail := report.Results[1].Detail; !strings.Contains(detail, "declares module github.com/gllm-dev/forked") {
+		t.Errorf("mismatch detail = %q", detail)
+	}
+	if got := len(s.Report().Problems()); got != 3 {
+		t.Errorf("Report().Problems() = %d results, want 3", got)
+	}
+
+	expected := `
+# HELP vanity_upstream_modules Registered modules by outcome of the last check of their repository.
+# TYPE vanity_upstream_modules gauge
+vanity_upstream_modules{status="missing_go_mod"} 1
+vanity_upstream_modules{status="mismatch"} 1
+vanity_upstream_modules{status="ok"} 2
+vanity_upstream_modules{status="skipped"} 1
+vanity_upstream_modules{status="unreachable"} 1
+vanity_upstream_modules{status="unverified"} 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "vanity_upstream_modules"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestService_CheckAll_WithoutCloneDir(t *testing.T) {
+	s, _ := newTestService(t, "")
+	report := s.CheckAll(context.Background())
+	for _, res := range report.Results {
+		want := StatusUnverified
+		switch res.ImportPath {
+		case "go.gllm.dev/gone":
+			want = StatusUnreachable
+		case "go.gllm.dev/svn":
+			want = StatusSkipped
+		}
+		if res.Status != want {
+			t.Errorf("%s status = %s, want %s", res.ImportPath, res.Status, want)
+		}
+	}
+}
+
+func TestService_Verify(t *testing.T) {
+	tests := []struct {
+		mode         string
+		wantWarnings int
+		wantRejected int
+	}{
+		{mode: VerifyOff},
+		// forked and nomod do not match, gone cannot be fetched.
+		{mode: VerifyWarn, wantWarnings: 3},
+		{mode: VerifyReject, wantWarnings: 1, wantRejected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			s, _ := newTestService(t, "")
+			s.verify = tt.mode
+			warnings, err := s.Verify(context.Background(), s.svc.Modules())
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("Verify() warnings = %q, want %d", warnings, tt.wantWarnings)
+			}
+			if rejected := len(errjoin.Split(err)); rejected != tt.wantRejected {
+				t.Errorf("Verify() error = %v, want %d rejections", err, tt.wantRejected)
+			}
+			if tt.wantRejected > 0 && !errors.Is(err, ErrRejected) {
+				t.Errorf("Verify() error = %v, want ErrRejected", err)
+			}
+		})
+	}
+}
+
+func TestService_VerifyRegistered(t *testing.T) {
+	for _, mode := range []string{VerifyWarn, VerifyReject} {
+		t.Run(mode, func(t *testing.T) {
+			s, _ := newTestService(t, "")
+			s.verify = mode
+			var buf bytes.Buffer
+			s.logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+			s.VerifyRegistered(context.Background())
+			// forked declares its former path in the registry loaded at startup.
+			if !strings.Contains(buf.String(), "go.gllm.dev/forked: go.mod declares module github.com/gllm-dev/forked") {
+				t.Errorf("log = %s, want the mismatch of forked reported", buf.String())
+			}
+			if len(s.svc.Modules()) != 6 {
+				t.Errorf("modules = %d, want every module still registered", len(s.svc.Modules()))
+			}
+		})
+	}
+}
+
+func TestService_RefreshVersions(t *testing.T) {
+	ctx := context.Background()
+	srv := upstreamtest.NewServer(t, map[string]map[string]string{
+		"app": {"go.mod": "module go.gllm.dev/app\n", "v2/go.mod": "module go.gllm.dev/app/v2\n"},
+	})
+	srv.Tag(t, "app", "v1.0.0", "v1.1.0-rc.1", "v2.0.0")
+	svc := gosvc.New("go.gllm.dev", srv.URL)
+	err := svc.Load(ctx, &gosvc.Config{Modules: []gosvc.ModuleConfig{
+		{Path: "app"},
+		{Path: "app/v2", Repository: srv.URL + "/app"},
+		{Path: "gone"},
+		{Path: "svn", VCS: "svn", Repository: "https://svn.example.com/svn"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{CheckTimeout: time.Minute, VersionsTTL: time.Hour}
+	s := New(cfg, svc, prometheus.NewRegistry(), discardLogger)
+
+	s.RefreshVersions(ctx)
+	v := svc.Versions("go.gllm.dev/app")
+	if v == nil || v.Latest != "v1.0.0" || v.LatestPrerelease != "v1.1.0-rc.1" || len(v.Majors) != 1 || v.Majors[0] != "go.gllm.dev/app/v2" || v.FetchedAt.IsZero() {
+		t.Errorf("versions of app = %+v", v)
+	}
+	if v := svc.Versions("go.gllm.dev/app/v2"); v == nil || v.Latest != "v2.0.0" {
+		t.Errorf("versions of app/v2 = %+v", v)
+	}
+	if !strings.Contains(string(svc.Page(ctx, "app").HTML), "Latest version") {
+		t.Error("page of app does not show its versions")
+	}
+	if svc.Versions("go.gllm.dev/gone") != nil || svc.Versions("go.gllm.dev/svn") != nil {
+		t.Error("versions were set for a missing or svn repository")
+	}
+	if _, ok := s.listFailed["go.gllm.dev/gone"]; !ok {
+		t.Error("failed listing of gone was not recorded")
+	}
+
+	// Versions younger than the TTL are kept.
+	s.RefreshVersions(ctx)
+	if again := svc.Versions("go.gllm.dev/app"); again != v {
+		t.Errorf("versions were listed again before the TTL elapsed: %+v", again)
+	}
+}
+
+func TestService_RefreshVersions_GoMod(t *testing.T) {
+	const goMod = "// Deprecated: use go.gllm.dev/app/v2.\nmodule go.gllm.dev/app\n\nretract v1.1.0 // Broken build.\n"
+	srv := upstreamtest.NewServer(t, map[string]map[string]string{
+		"app": {"go.mod": goMod},
+	})
+	srv.Tag(t, "app", "v1.0.0", "v1.1.0")
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/go.gllm.dev/app/@v/v1.1.0.mod" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, goMod)
+	}))
+	defer proxy.Close()
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantRead bool
+	}{
+		{name: "clone", cfg: Config{CloneDir: t.TempDir()}, wantRead: true},
+		{name: "proxy", cfg: Config{Proxy: proxy.URL}, wantRead: true},
+		{name: "neither"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := gosvc.New("go.gllm.dev", srv.URL)
+			if err := svc.Load(ctx, &gosvc.Config{Modules: []gosvc.ModuleConfig{{Path: "app"}}}); err != nil {
+				t.Fatal(err)
+			}
+			cfg := tt.cfg
+			cfg.CheckTimeout, cfg.VersionsTTL = time.Minute, time.Hour
+			New(&cfg, svc, prometheus.NewRegistry(), discardLogger).RefreshVersions(ctx)
+
+			v := svc.Versions("go.gllm.dev/app")
+			if v == nil {
+				t.Fatal("versions were not listed")
+			}
+			if !tt.wantRead {
+				if v.Latest != "v1.1.0" || v.Deprecated != "" {
+					t.Errorf("versions = %+v, want v1.1.0 latest and no go.mod read", v)
+				}
+				return
+			}
+			if v.Latest != "v1.0.0" || v.Deprecated != "use go.gllm.dev/app/v2." || len(v.Retracted) != 1 || v.Retracted[0] != "v1.1.0" {
+				t.Errorf("versions = %+v, want v1.1.0 retracted and the module deprecated", v)
+			}
+			if page := string(svc.Page(ctx, "app").HTML); !strings.Contains(page, "Deprecated: use go.gllm.dev/app/v2.") || !strings.Contains(page, "<li>v1.1.0: Broken build.</li>") {
+				t.Errorf("page = %s, want the deprecation and the retraction", page)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package vulnsvc
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config holds the configuration of the vulnerability notices of the registered modules.
+type Config struct {
+	// Source is where the OSV entries are read from: a directory of OSV JSON files, or a
+	// database in the layout of the Go vulnerability database, mirrored in a directory or
+	// served at an http or https URL. Notices are disabled when it is empty.
+	Source string
+	// RefreshInterval is how often the entries are read again. They are only read at
+	// startup when it is zero.
+	RefreshInterval time.Duration
+	// Timeout bounds each reading of the entries.
+	Timeout time.Duration
+}
+
+const (
+	// Default values for the vulnerability configuration.
+	// These can be overridden through the config package.
+
+	// defaultRefreshInterval is the default interval between readings of the entries.
+	defaultRefreshInterval = time.Hour
+	// defaultTimeout is the default time limit of a reading of the entries.
+	defaultTimeout = time.Minute
+)
+
+// DefaultConfig returns the vulnerability configuration used when nothing is overridden.
+func DefaultConfig() *Config {
+	return &Config{
+		RefreshInterval: defaultRefreshInterval,
+		Timeout:         defaultTimeout,
+	}
+}
+
+// Validate reports every invalid value of the configuration.
+func (c *Config) Validate() error {
+	var errs []error
+	if strings.Contains(c.Source, "://") {
+		if u, err := url.Parse(c.Source); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid source %q: must be a directory or an http or https URL", c.Source))
+		}
+	}
+	if c.RefreshInterval < 0 {
+		errs = append(errs, fmt.Errorf("refresh interval must not be negative"))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("timeout must be positive"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,162 @@
+// Package vulnsvc attaches the known vulnerabilities of the registered modules, read from
+// OSV entries, to their pages.
+//
+// Entries are read from a directory or a mirror of the Go vulnerability database, for
+// every module under the vanity domain, so that modules registered later get their
+// notices without reading the entries again.
+package vulnsvc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.gllm.dev/vanity-go/internal/osv"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+// Service reads the vulnerability entries of the modules of a gosvc.Service.
+type Service struct {
+	// svc is the service the advisories are set in.
+	svc *gosvc.Service
+	// source is where the entries are read from; empty when notices are disabled.
+	source string
+	// interval is how often the entries are read again; zero reads them once.
+	interval time.Duration
+	// timeout bounds each reading.
+	timeout time.Duration
+	// client fetches the entries of a database served over HTTP.
+	client *http.Client
+	// logger reports the readings that failed.
+	logger *slog.Logger
+
+	// mu serializes the readings.
+	mu sync.Mutex
+}
+
+// New creates a Service setting the advisories of the modules of svc.
+func New(cfg *Config, svc *gosvc.Service, logger *slog.Logger) *Service {
+	return &Service{
+		svc:      svc,
+		source:   cfg.Source,
+		interval: cfg.RefreshInterval,
+		timeout:  cfg.Timeout,
+		client:   &http.Client{},
+		logger:   logger,
+	}
+}
+
+// Enabled reports whether a source of entries is configured.
+func (s *Service) Enabled() bool {
+	return s.source != ""
+}
+
+// Run reads the entries at once and then at every interval, until ctx is done.
+// A failed reading is logged, and the advisories already set are kept.
+// It returns immediately when notices are disabled.
+func (s *Service) Run(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+	var tick <-chan time.Time
+	if s.interval > 0 {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		if err := s.Load(ctx); err != nil && ctx.Err() == nil {
+			s.logger.WarnContext(ctx, "Vulnerability entries reading failed",
+				slog.String("source", s.source),
+				slog.String("error", err.Error()))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		}
+	}
+}
+
+// Load reads the entries affecting the modules under the vanity domain and replaces
+// the advisories of the gosvc.Service with them. On error, the advisories are kept.
+func (s *Service) Load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	domain := s.svc.Domain()
+	match := func(modulePath string) bool {
+		return modulePath == domain || strings.HasPrefix(modulePath, domain+"/")
+	}
+	entries, err := osv.Load(ctx, s.client, s.source, match)
+	if err != nil {
+		return err
+	}
+
+	advisories := make(map[string][]gosvc.Advisory)
+	for _, e := range entries {
+		for _, a := range e.Affected {
+			if a.Package.Ecosystem != osv.EcosystemGo || !match(a.Package.Name) {
+				continue
+			}
+			advisories[a.Package.Name] = append(advisories[a.Package.Name], advisory(e, a))
+		}
+	}
+	s.svc.SetAdvisories(ctx, advisories)
+	s.logger.DebugContext(ctx, "Vulnerability entries read",
+		slog.String("source", s.source),
+		slog.Int("entries", len(entries)))
+	return nil
+}
+
+// advisory returns the advisory of the entry e for its affected module a.
+func advisory(e osv.Entry, a osv.Affected) gosvc.Advisory {
+	adv := gosvc.Advisory{ID: e.ID, Aliases: e.Aliases, Summary: e.Summary, URL: entryURL(e), Affected: []gosvc.AffectedRange{}}
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" {
+			continue
+		}
+		// An introduced event within a range still open, not fixed yet, extends it. A range
+		// ends before its fixed version, or at its last affected version, included.
+		var open *gosvc.AffectedRange
+		for _, ev := range r.Events {
+			switch {
+			case ev.Introduced != "" && open == nil:
+				open = &gosvc.AffectedRange{}
+				if ev.Introduced != "0" {
+					open.Introduced = "v" + ev.Introduced
+				}
+			case ev.Fixed != "" && open != nil:
+				open.Fixed = "v" + ev.Fixed
+				adv.Affected = append(adv.Affected, *open)
+				open = nil
+			case ev.LastAffected != "" && open != nil:
+				open.LastAffected = "v" + ev.LastAffected
+				adv.Affected = append(adv.Affected, *open)
+				open = nil
+			}
+		}
+		if open != nil {
+			adv.Affected = append(adv.Affected, *open)
+		}
+	}
+	return adv
+}
+
+// entryURL returns the page describing e: the one it names when it is an http or https
+// URL, its page on pkg.go.dev for entries of the Go vulnerability database, and its page
+// on osv.dev otherwise.
+func entryURL(e osv.Entry) string {
+	if e.DatabaseSpecific != nil && (strings.HasPrefix(e.DatabaseSpecific.URL, "https://") || strings.HasPrefix(e.DatabaseSpecific.URL, "http://")) {
+		return e.DatabaseSpecific.URL
+	}
+	if strings.HasPrefix(e.ID, "GO-") {
+		return "https://pkg.go.dev/vuln/" + e.ID
+	}
+	return "https://osv.dev/vulnerability/" + e.ID
+}
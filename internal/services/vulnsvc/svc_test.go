@@ -0,0 +1,166 @@
+package vulnsvc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.gllm.dev/vanity-go/internal/osv"
+	"go.gllm.dev/vanity-go/internal/services/gosvc"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestService_Load(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("GO-2026-0001.json", `{"id":"GO-2026-0001","summary":"Panic <parsing> input","aliases":["CVE-2026-1234"],
+		"affected":[{"package":{"name":"go.gllm.dev/app","ecosystem":"Go"},"ranges":[
+			{"type":"SEMVER","events":[{"introduced":"0"},{"fixed":"1.2.3"},{"introduced":"1.4.0"}]},
+			{"type":"GIT","events":[{"introduced":"0"}]}]}],
+		"database_specific":{"url":"https://pkg.go.dev/vuln/GO-2026-0001"}}`)
+	write("GHSA-aaaa-bbbb-cccc.json", `{"id":"GHSA-aaaa-bbbb-cccc","affected":[
+		{"package":{"name":"go.gllm.dev/app","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"1.0.0"},{"fixed":"1.0.1"}]}]},
+		{"package":{"name":"go.gllm.dev/later","ecosystem":"Go"}},
+		{"package":{"name":"go.gllm.dev/app/v2","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"2.0.0"},{"fixed":"2.0.1"}]}]},
+		{"package":{"name":"example.com/other","ecosystem":"Go"}}],
+		"database_specific":{"url":"javascript:alert(1)"}}`)
+
+	svc := gosvc.New("go.gllm.dev", "https://github.com/gllm-dev")
+	if err := svc.Load(ctx, &gosvc.Config{Modules: []gosvc.ModuleConfig{{Path: "app"}, {Path: "tools"}}}); err != nil {
+		t.Fatal(err)
+	}
+	vuln := New(&Config{Source: dir, Timeout: DefaultConfig().Timeout}, svc, discardLogger)
+	if !vuln.Enabled() {
+		t.Fatal("Enabled() = false with a source")
+	}
+	if err := vuln.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The advisories of the second major version, whose path resolves to app, follow its own.
+	want := []gosvc.Advisory{
+		{ID: "GHSA-aaaa-bbbb-cccc", URL: "https://osv.dev/vulnerability/GHSA-aaaa-bbbb-cccc", Affected: []gosvc.AffectedRange{{Introduced: "v1.0.0", Fixed: "v1.0.1"}}},
+		{ID: "GO-2026-0001", Aliases: []string{"CVE-2026-1234"}, Summary: "Panic <parsing> input", URL: "https://pkg.go.dev/vuln/GO-2026-0001", Affected: []gosvc.AffectedRange{{Fixed: "v1.2.3"}, {Introduced: "v1.4.0"}}},
+		{ID: "GHSA-aaaa-bbbb-cccc", URL: "https://osv.dev/vulnerability/GHSA-aaaa-bbbb-cccc", Module: "go.gllm.dev/app/v2", Affected: []gosvc.AffectedRange{{Introduced: "v2.0.0", Fixed: "v2.0.1"}}},
+	}
+	if got := svc.Advisories("go.gllm.dev/app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Advisories(app) = %+v, want %+v", got, want)
+	}
+	if got := svc.Advisories("example.com/other"); got != nil {
+		t.Errorf("Advisories(other) = %+v, want none", got)
+	}
+
+	page := string(svc.Page(ctx, "app").HTML)
+	for _, want := range []string{
+		`<a href="https://pkg.go.dev/vuln/GO-2026-0001">GO-2026-0001</a>: Panic &lt;parsing&gt; input (affects before v1.2.3, v1.4.0 and later)`,
+		`<a href="https://osv.dev/vulnerability/GHSA-aaaa-bbbb-cccc">GHSA-aaaa-bbbb-cccc</a> (affects v1.0.0 to before v1.0.1)`,
+		`<a href="https://osv.dev/vulnerability/GHSA-aaaa-bbbb-cccc">GHSA-aaaa-bbbb-cccc</a> in go.gllm.dev/app/v2 (affects v2.0.0 to before v2.0.1)`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page = %s, want it to contain %s", page, want)
+		}
+	}
+	if strings.Contains(string(svc.Page(ctx, "tools").HTML), "Known vulnerabilities") {
+		t.Error("page of an unaffected module lists vulnerabilities")
+	}
+
+	// Modules registered later get the advisories already read.
+	if err := svc.Load(ctx, &gosvc.Config{Modules: []gosvc.ModuleConfig{{Path: "app"}, {Path: "later"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(svc.Page(ctx, "later").HTML), "GHSA-aaaa-bbbb-cccc") {
+		t.Error("page of a module registered later does not list its vulnerabilities")
+	}
+
+	// A failed reading keeps the advisories.
+	vuln.source = filepath.Join(dir, "missing")
+	if err := vuln.Load(ctx); err == nil {
+		t.Fatal("Load() of a missing source succeeded")
+	}
+	if got := svc.Advisories("go.gllm.dev/app"); len(got) != 3 {
+		t.Errorf("Advisories(app) after a failed reading = %+v, want them kept", got)
+	}
+}
+
+func TestAdvisory_Ranges(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []osv.Event
+		want   []gosvc.AffectedRange
+	}{
+		{
+			name:   "introduced and fixed",
+			events: []osv.Event{{Introduced: "1.0.0"}, {Fixed: "1.0.1"}},
+			want:   []gosvc.AffectedRange{{Introduced: "v1.0.0", Fixed: "v1.0.1"}},
+		},
+		{
+			name:   "introduced twice before the fix",
+			events: []osv.Event{{Introduced: "0"}, {Introduced: "1.1.0"}, {Fixed: "1.2.0"}},
+			want:   []gosvc.AffectedRange{{Fixed: "v1.2.0"}},
+		},
+		{
+			name:   "introduced twice without a fix",
+			events: []osv.Event{{Introduced: "1.0.0"}, {Fixed: "1.0.1"}, {Introduced: "1.1.0"}, {Introduced: "1.3.0"}},
+			want:   []gosvc.AffectedRange{{Introduced: "v1.0.0", Fixed: "v1.0.1"}, {Introduced: "v1.1.0"}},
+		},
+		{
+			name:   "last affected",
+			events: []osv.Event{{Introduced: "1.0.0"}, {LastAffected: "1.0.4"}, {Introduced: "1.2.0"}, {Fixed: "1.2.1"}},
+			want:   []gosvc.AffectedRange{{Introduced: "v1.0.0", LastAffected: "v1.0.4"}, {Introduced: "v1.2.0", Fixed: "v1.2.1"}},
+		},
+		{
+			name:   "last affected from the beginning",
+			events: []osv.Event{{Introduced: "0"}, {Introduced: "0.9.0"}, {LastAffected: "1.0.4"}},
+			want:   []gosvc.AffectedRange{{LastAffected: "v1.0.4"}},
+		},
+		{
+			name:   "fixed without introduced",
+			events: []osv.Event{{Fixed: "1.0.1"}},
+			want:   []gosvc.AffectedRange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := osv.Affected{Ranges: []osv.Range{{Type: "SEMVER", Events: tt.events}}}
+			if got := advisory(osv.Entry{ID: "GO-2026-0001"}, a).Affected; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Affected = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "default", cfg: *DefaultConfig()},
+		{name: "directory", cfg: Config{Source: "/var/lib/vulndb", RefreshInterval: 0, Timeout: 1}},
+		{name: "URL", cfg: Config{Source: "https://vuln.go.dev", Timeout: 1}},
+		{name: "unsupported URL", cfg: Config{Source: "ftp://vuln.go.dev", Timeout: 1}, wantErr: true},
+		{name: "negative interval", cfg: Config{RefreshInterval: -1, Timeout: 1}, wantErr: true},
+		{name: "no timeout", cfg: Config{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"fmt"
+)
+
+// Config holds the configuration for distributed tracing.
+type Config struct {
+	// Exporter selects where finished spans are sent: "otlp", "stdout" or "none".
+	Exporter string
+	// Endpoint is the OTLP/HTTP collector URL (e.g., "http://otel-collector:4318").
+	// When empty the exporter falls back to its own defaults.
+	Endpoint string
+	// ServiceName is the service.name resource attribute attached to every span.
+	ServiceName string
+}
+
+const (
+	// ExporterOTLP exports spans to an OpenTelemetry collector over OTLP/HTTP.
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans as JSON to standard output.
+	ExporterStdout = "stdout"
+	// ExporterNone disables tracing.
+	ExporterNone = "none"
+)
+
+const (
+	// Default values for the tracing configuration.
+	// These can be overridden through the config package.
+
+	// defaultExporter is the default span exporter.
+	defaultExporter = ExporterNone
+	// defaultServiceName is the default service name reported in spans.
+	defaultServiceName = "vanity-go"
+)
+
+// DefaultConfig returns the tracing configuration used when nothing is overridden.
+func DefaultConfig() *Config {
+	return &Config{
+		Exporter:    defaultExporter,
+		ServiceName: defaultServiceName,
+	}
+}
+
+// Validate reports every invalid value of the configuration.
+func (c *Config) Validate() error {
+	switch c.Exporter {
+	case ExporterOTLP, ExporterStdout, ExporterNone:
+		return nil
+	default:
+		return fmt.Errorf("invalid exporter %q: must be %q, %q or %q", c.Exporter, ExporterOTLP, ExporterStdout, ExporterNone)
+	}
+}
@@ -0,0 +1,48 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// NewTracerProvider creates a tracer provider exporting spans as configured.
+// The stdout exporter writes to w. With the "none" exporter spans are never sampled:
+// they still carry trace IDs, which are propagated and logged, but are not recorded,
+// so tracing costs next to nothing.
+//
+// Callers must call Shutdown on the returned provider to flush pending spans.
+func NewTracerProvider(cfg *Config, w io.Writer) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName))),
+	}
+
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		var exporterOpts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err := otlptracehttp.New(context.Background(), exporterOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	case ExporterNone:
+		opts = append(opts, sdktrace.WithSampler(sdktrace.NeverSample()))
+	}
+
+	return sdktrace.NewTracerProvider(opts...), nil
+}
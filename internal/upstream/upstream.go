@@ -0,0 +1,383 @@
+// Package upstream inspects the git repositories hosting the registered modules.
+// The refs of a repository are read from its git smart HTTP ref advertisement, which
+// needs nothing but an HTTP client. Files are read from a mirror clone kept with the
+// git command, which must then be installed. The go.mod files of module versions can
+// also be read from a module proxy.
+package upstream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// ErrNotFound is returned when a repository does not exist or is not readable anonymously.
+var ErrNotFound = errors.New("repository not found")
+
+// Ref is a ref advertised by a repository.
+type Ref struct {
+	// Name is the full name of the ref (e.g., "refs/tags/v1.2.0" or "HEAD").
+	Name string `json:"name"`
+	// Hash is the object the ref points to.
+	Hash string `json:"hash"`
+	// Peeled is the commit an annotated tag points to; empty for other refs.
+	Peeled string `json:"peeled,omitempty"`
+}
+
+// ListRefs returns the refs advertised by the git repository at repository over the
+// smart HTTP protocol, in the order of the advertisement. It returns ErrNotFound when
+// the server answers 401, 403 or 404, as hosts hide private repositories that way.
+func ListRefs(ctx context.Context, client *http.Client, repository string) ([]Ref, error) {
+	u := strings.TrimSuffix(repository, "/") + "/info/refs?service=git-upload-pack"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Some hosts only speak the smart protocol to clients that look like git.
+	req.Header.Set("User-Agent", "git/vanity-go")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s answered %s", ErrNotFound, repository, resp.Status)
+	default:
+		return nil, fmt.Errorf("%s answered %s", repository, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-git-upload-pack-advertisement" {
+		return nil, fmt.Errorf("%s does not speak the git smart HTTP protocol (content type %q)", repository, ct)
+	}
+	return parseAdvertisement(resp.Body)
+}
+
+// Tags returns the names of the tags among refs (e.g., "v1.0.0" for "refs/tags/v1.0.0").
+func Tags(refs []Ref) []string {
+	var tags []string
+	for _, ref := range refs {
+		if tag, ok := strings.CutPrefix(ref.Name, "refs/tags/"); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// maxGoModSize is the size of the largest go.mod file read from a module proxy.
+const maxGoModSize = 1 << 20
+
+// FetchGoMod returns the go.mod file of the version of the module at modulePath from the
+// module proxy at proxy (e.g., "https://proxy.golang.org"), with the GOPROXY protocol.
+// It returns ErrNotFound when the proxy does not know the version.
+func FetchGoMod(ctx context.Context, client *http.Client, proxy, modulePath, version string) ([]byte, error) {
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	u := strings.TrimSuffix(proxy, "/") + "/" + escapedPath + "/@v/" + escapedVersion + ".mod"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("%w: %s answered %s for %s@%s", ErrNotFound, proxy, resp.Status, modulePath, version)
+	default:
+		return nil, fmt.Errorf("%s answered %s for %s@%s", proxy, resp.Status, modulePath, version)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxGoModSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxGoModSize {
+		return nil, fmt.Errorf("go.mod of %s@%s is larger than %d bytes", modulePath, version, maxGoModSize)
+	}
+	return data, nil
+}
+
+// parseAdvertisement parses the pkt-lines of a version 0 or 1 ref advertisement,
+// preceded by the "# service=git-upload-pack" announcement of smart HTTP.
+func parseAdvertisement(r io.Reader) ([]Ref, error) {
+	br := bufio.NewReader(r)
+	line, err := readPktLine(br)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(line, "\n") != "# service=git-upload-pack" {
+		return nil, fmt.Errorf("unexpected ref advertisement header %q", line)
+	}
+	if line, err = readPktLine(br); err != nil || line != "" {
+		return nil, fmt.Errorf("missing flush after the ref advertisement header")
+	}
+
+	var refs []Ref
+	for first := true; ; first = false {
+		line, err := readPktLine(br)
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			return refs, nil
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if first {
+			// The capabilities follow the first ref after a NUL byte.
+			line, _, _ = strings.Cut(line, "\x00")
+		}
+		if strings.HasPrefix(line, "version ") {
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("malformed ref line %q", line)
+		}
+		switch {
+		case name == "capabilities^{}":
+			// An empty repository advertises its capabilities without refs.
+		case strings.HasSuffix(name, "^{}"):
+			if n := len(refs); n > 0 && refs[n-1].Name == strings.TrimSuffix(name, "^{}") {
+				refs[n-1].Peeled = hash
+			}
+		default:
+			refs = append(refs, Ref{Name: name, Hash: hash})
+		}
+	}
+}
+
+// readPktLine reads a pkt-line and returns its payload; a flush-pkt is returned as "".
+func readPktLine(r *bufio.Reader) (string, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return "", fmt.Errorf("reading pkt-line: %w", err)
+	}
+	n, err := strconv.ParseUint(string(size[:]), 16, 16)
+	if err != nil {
+		return "", fmt.Errorf("malformed pkt-line length %q", size)
+	}
+	if n == 0 {
+		return "", nil
+	}
+	if n < 4 {
+		return "", fmt.Errorf("malformed pkt-line length %q", size)
+	}
+	payload := make([]byte, n-4)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return "", fmt.Errorf("reading pkt-line: %w", err)
+	}
+	return string(payload), nil
+}
+
+// Mirror keeps mirror clones of repositories in a directory, with the git command.
+// Clones are partial: blobs are only fetched when a file is read.
+type Mirror struct {
+	// dir is the directory holding the clones, one per repository at <host>/<path>.git.
+	dir string
+	// git is the git command.
+	git string
+
+	// mu serializes the git commands, so that a clone is never fetched twice at once.
+	mu sync.Mutex
+}
+
+// NewMirror returns a Mirror keeping its clones in dir.
+func NewMirror(dir string) *Mirror {
+	return &Mirror{dir: dir, git: "git"}
+}
+
+// Sync clones repository, or fetches its refs when it is already cloned.
+func (m *Mirror) Sync(ctx context.Context, repository string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dir, err := m.path(repository)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		_, err = m.run(ctx, dir, "fetch", "--quiet", "--prune", "--filter=blob:none", "origin")
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	if _, err := m.run(ctx, "", "clone", "--quiet", "--mirror", "--filter=blob:none", repository, dir); err != nil {
+		// Leave no partial clone behind for the next Sync to fetch into.
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+// Cloned reports whether repository has a clone, which files can be read from even
+// when it cannot be fetched.
+func (m *Mirror) Cloned(repository string) bool {
+	dir, err := m.path(repository)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(dir)
+	return err == nil
+}
+
+// ReadFile returns the content of the file name at the revision rev of the clone of
+// repository, fetching it if needed. It returns an error wrapping fs.ErrNotExist
+// when the revision has no such file.
+func (m *Mirror) ReadFile(ctx context.Context, repository, rev, name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dir, err := m.path(repository)
+	if err != nil {
+		return nil, err
+	}
+	out, err := m.run(ctx, dir, "ls-tree", "-z", rev, "--", name)
+	if err != nil {
+		return nil, err
+	}
+	// ls-tree prints "<mode> <type> <object>\t<name>" for an existing entry, and nothing otherwise.
+	meta, _, _ := bytes.Cut(out, []byte("\t"))
+	f := strings.Fields(string(meta))
+	if len(f) != 3 || f[1] != "blob" {
+		return nil, fmt.Errorf("%s at %s: %w", name, rev, os.ErrNotExist)
+	}
+	return m.run(ctx, dir, "cat-file", "blob", f[2])
+}
+
+// Tags returns the names of the tags of the clone of repository, without fetching it.
+func (m *Mirror) Tags(ctx context.Context, repository string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dir, err := m.path(repository)
+	if err != nil {
+		return nil, err
+	}
+	out, err := m.run(ctx, dir, "for-each-ref", "--format=%(refname:strip=2)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// path returns the directory of the clone of repository, which is always inside the
+// directory of m.
+func (m *Mirror) path(repository string) (string, error) {
+	u, err := url.Parse(repository)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("repository %q is not an absolute URL", repository)
+	}
+	host := strings.ToLower(u.Host)
+	p := strings.TrimSuffix(path.Clean("/"+u.Path), ".git")
+	rel := filepath.Join(host, filepath.FromSlash(p)+".git")
+	if host == "." || !filepath.IsLocal(rel) || strings.Contains(host+p, "\\") {
+		return "", fmt.Errorf("repository %q has no valid clone directory", repository)
+	}
+	return filepath.Join(m.dir, rel), nil
+}
+
+// run runs the git command args in the repository gitDir, if not empty, and returns its
+// standard output. Git never prompts for credentials, so that a private repository
+// fails instead of blocking.
+func (m *Mirror) run(ctx context.Context, gitDir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, m.git, args...)
+	if gitDir != "" {
+		cmd.Args = append([]string{m.git, "--git-dir", gitDir}, args...)
+	}
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// GoMod is the go.mod file a module is loaded from.
+type GoMod struct {
+	// File is the path of the file in the repository (e.g., "go.mod" or "v2/go.mod").
+	File string `json:"file"`
+	// ModulePath is the path declared by its module directive.
+	ModulePath string `json:"module_path"`
+}
+
+// Matches reports whether the go command accepts the go.mod file for the module at
+// importPath: it declares importPath or, when importPath has no major version suffix,
+// importPath with one (e.g., "example.com/m/v2" for "example.com/m"), as the default
+// branch of a module may be at a major version its v0 and v1 tags are not.
+func (g GoMod) Matches(importPath string) bool {
+	if g.ModulePath == importPath {
+		return true
+	}
+	prefix, major, ok := module.SplitPathVersion(g.ModulePath)
+	_, own, _ := module.SplitPathVersion(importPath)
+	return ok && prefix == importPath && own == "" && major != "" && !strings.HasPrefix(major, ".")
+}
+
+// Mismatch describes why the go command rejects the go.mod file for the module at
+// importPath, and how to fix it.
+func (g GoMod) Mismatch(importPath string) string {
+	detail := fmt.Sprintf("%s declares module %s, not %s", g.File, g.ModulePath, importPath)
+	prefix, major, _ := module.SplitPathVersion(importPath)
+	declared, _, _ := module.SplitPathVersion(g.ModulePath)
+	if major != "" && !strings.HasPrefix(major, ".") && declared == prefix {
+		return detail + fmt.Sprintf("; declare %s in go.mod or in %s/go.mod", importPath, major[1:])
+	}
+	return detail + fmt.Sprintf("; change its module directive to %s", importPath)
+}
+
+// FindGoMod returns the go.mod file the go command loads the module at importPath from,
+// when the import path is the root of the repository read by readFile. Like the go
+// command, it prefers the major version subdirectory of an import path ending in /vN
+// (e.g., "v2/go.mod" for "example.com/m/v2") to the go.mod file at the root.
+// readFile returns an error wrapping fs.ErrNotExist for missing files.
+// The returned error wraps fs.ErrNotExist when there is no go.mod file at all.
+func FindGoMod(importPath string, readFile func(name string) ([]byte, error)) (GoMod, error) {
+	files := []string{"go.mod"}
+	if _, major, ok := module.SplitPathVersion(importPath); ok && major != "" && !strings.HasPrefix(major, ".") {
+		files = []string{major[1:] + "/go.mod", "go.mod"}
+	}
+	for _, file := range files {
+		data, err := readFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return GoMod{}, err
+		}
+		modulePath := modfile.ModulePath(data)
+		if modulePath == "" {
+			return GoMod{File: file}, fmt.Errorf("%s has no module directive", file)
+		}
+		return GoMod{File: file, ModulePath: modulePath}, nil
+	}
+	return GoMod{}, fmt.Errorf("no go.mod file: %w", os.ErrNotExist)
+}
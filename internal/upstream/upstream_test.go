@@ -0,0 +1,299 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.gllm.dev/vanity-go/internal/upstream/upstreamtest"
+)
+
+// pkt returns s as a pkt-line.
+func pkt(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+func TestParseAdvertisement(t *testing.T) {
+	header := pkt("# service=git-upload-pack\n") + "0000"
+	tests := []struct {
+		name    string
+		body    string
+		want    []Ref
+		wantErr bool
+	}{
+		{
+			name: "refs",
+			body: header +
+				pkt("aaa HEAD\x00multi_ack symref=HEAD:refs/heads/main\n") +
+				pkt("aaa refs/heads/main\n") +
+				pkt("bbb refs/tags/v1.0.0\n") +
+				pkt("aaa refs/tags/v1.0.0^{}\n") +
+				"0000",
+			want: []Ref{
+				{Name: "HEAD", Hash: "aaa"},
+				{Name: "refs/heads/main", Hash: "aaa"},
+				{Name: "refs/tags/v1.0.0", Hash: "bbb", Peeled: "aaa"},
+			},
+		},
+		{
+			name: "empty repository",
+			body: header + pkt("0000000000000000000000000000000000000000 capabilities^{}\x00agent=git\n") + "0000",
+		},
+		{
+			name:    "not smart HTTP",
+			body:    "aaa\trefs/heads/main\n",
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			body:    header + pkt("aaa HEAD\x00caps\n"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAdvertisement(strings.NewReader(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAdvertisement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAdvertisement() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindGoMod(t *testing.T) {
+	tests := []struct {
+		name       string
+		importPath string
+		files      map[string]string
+		want       GoMod
+		wantErr    error
+	}{
+		{
+			name:       "root",
+			importPath: "go.gllm.dev/app",
+			files:      map[string]string{"go.mod": "module go.gllm.dev/app\n"},
+			want:       GoMod{File: "go.mod", ModulePath: "go.gllm.dev/app"},
+		},
+		{
+			name:       "major version at the root",
+			importPath: "go.gllm.dev/app/v2",
+			files:      map[string]string{"go.mod": "module go.gllm.dev/app/v2\n"},
+			want:       GoMod{File: "go.mod", ModulePath: "go.gllm.dev/app/v2"},
+		},
+		{
+			name:       "major version subdirectory",
+			importPath: "go.gllm.dev/app/v2",
+			files:      map[string]string{"go.mod": "module go.gllm.dev/app\n", "v2/go.mod": "module go.gllm.dev/app/v2\n"},
+			want:       GoMod{File: "v2/go.mod", ModulePath: "go.gllm.dev/app/v2"},
+		},
+		{
+			name:       "v2 directory of a v1 module",
+			importPath: "go.gllm.dev/app",
+			files:      map[string]string{"go.mod": "module github.com/gllm-dev/app\n", "v2/go.mod": "module go.gllm.dev/app\n"},
+			want:       GoMod{File: "go.mod", ModulePath: "github.com/gllm-dev/app"},
+		},
+		{
+			name:       "missing",
+			importPath: "go.gllm.dev/app",
+			files:      map[string]string{"main.go": "package main\n"},
+			wantErr:    os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindGoMod(tt.importPath, func(name string) ([]byte, error) {
+				data, ok := tt.files[name]
+				if !ok {
+					return nil, os.ErrNotExist
+				}
+				return []byte(data), nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FindGoMod() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FindGoMod() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListRefs(t *testing.T) {
+	srv := upstreamtest.NewServer(t, map[string]map[string]string{
+		"app":   {"go.mod": "module go.gllm.dev/app\n"},
+		"empty": nil,
+	})
+	ctx := context.Background()
+
+	refs, err := ListRefs(ctx, http.DefaultClient, srv.URL+"/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 || refs[0].Name != "HEAD" || refs[1].Name != "refs/heads/main" || refs[0].Hash != refs[1].Hash {
+		t.Errorf("ListRefs() = %+v, want HEAD and main", refs)
+	}
+
+	if refs, err := ListRefs(ctx, http.DefaultClient, srv.URL+"/empty"); err != nil || len(refs) != 0 {
+		t.Errorf("ListRefs() of an empty repository = %+v, %v, want none", refs, err)
+	}
+	if _, err := ListRefs(ctx, http.DefaultClient, srv.URL+"/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ListRefs() of a missing repository error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMirror(t *testing.T) {
+	srv := upstreamtest.NewServer(t, map[string]map[string]string{
+		"app": {"go.mod": "module go.gllm.dev/app\n", "cmd/main.go": "package main\n"},
+	})
+	ctx := context.Background()
+	m := NewMirror(t.TempDir())
+	repo := srv.URL + "/app"
+
+	// Syncing an existing clone fetches into it.
+	for i := 0; i < 2; i++ {
+		if err := m.Sync(ctx, repo); err != nil {
+			t.Fatalf("Sync() #%d error = %v", i+1, err)
+		}
+	}
+	data, err := m.ReadFile(ctx, repo, "HEAD", "go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "module go.gllm.dev/app\n" {
+		t.Errorf("ReadFile() = %q", data)
+	}
+	for _, name := range []string{"missing.txt", "cmd"} {
+		if _, err := m.ReadFile(ctx, repo, "HEAD", name); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("ReadFile(%q) error = %v, want ErrNotExist", name, err)
+		}
+	}
+
+	if err := m.Sync(ctx, srv.URL+"/missing"); err == nil {
+		t.Error("Sync() of a missing repository succeeded")
+	}
+}
+
+func TestMirror_Path(t *testing.T) {
+	dir := t.TempDir()
+	m := NewMirror(dir)
+
+	tests := []struct {
+		repository string
+		want       string
+	}{
+		{repository: "https://GitHub.com/gllm-dev/app.git", want: filepath.Join(dir, "github.com", "gllm-dev", "app.git")},
+		{repository: "https://github.com/gllm-dev/../../app", want: filepath.Join(dir, "github.com", "app.git")},
+		{repository: "https://github.com:8443/app", want: filepath.Join(dir, "github.com:8443", "app.git")},
+		{repository: "https://../app"},
+		{repository: "https://./app"},
+		{repository: "https://../"},
+		{repository: "https://host/a%5Cb"},
+		{repository: "/local/app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repository, func(t *testing.T) {
+			got, err := m.path(tt.repository)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("path() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("path() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoMod_Matches(t *testing.T) {
+	tests := []struct {
+		name       string
+		importPath string
+		gomod      GoMod
+		want       bool
+		wantHint   string
+	}{
+		{name: "same path", importPath: "go.gllm.dev/app", gomod: GoMod{File: "go.mod", ModulePath: "go.gllm.dev/app"}, want: true},
+		{name: "hosting path", importPath: "go.gllm.dev/app", gomod: GoMod{File: "go.mod", ModulePath: "github.com/gllm-dev/app"}, wantHint: "change its module directive to go.gllm.dev/app"},
+		{name: "next major version on the default branch", importPath: "go.gllm.dev/app", gomod: GoMod{File: "go.mod", ModulePath: "go.gllm.dev/app/v3"}, want: true},
+		{name: "v1 suffix", importPath: "go.gllm.dev/app", gomod: GoMod{File: "go.mod", ModulePath: "go.gllm.dev/app/v1"}, wantHint: "change its module directive"},
+		{name: "major version registered, v1 declared", importPath: "go.gllm.dev/app/v2", gomod: GoMod{File: "go.mod", ModulePath: "go.gllm.dev/app"}, wantHint: "declare go.gllm.dev/app/v2 in go.mod or in v2/go.mod"},
+		{name: "other major version", importPath: "go.gllm.dev/app/v2", gomod: GoMod{File: "v2/go.mod", ModulePath: "go.gllm.dev/app/v3"}, wantHint: "declare go.gllm.dev/app/v2 in go.mod or in v2/go.mod"},
+		{name: "gopkg.in", importPath: "gopkg.in/yaml.v3", gomod: GoMod{File: "go.mod", ModulePath: "gopkg.in/yaml.v3"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.gomod.Matches(tt.importPath); got != tt.want {
+				t.Fatalf("Matches(%q) = %v, want %v", tt.importPath, got, tt.want)
+			}
+			if !tt.want && !strings.Contains(tt.gomod.Mismatch(tt.importPath), tt.wantHint) {
+				t.Errorf("Mismatch(%q) = %q, want it to contain %q", tt.importPath, tt.gomod.Mismatch(tt.importPath), tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	srv := upstreamtest.NewServer(t, map[string]map[string]string{
+		"app": {"go.mod": "module go.gllm.dev/app\n"},
+	})
+	srv.Tag(t, "app", "v1.0.0", "v1.1.0")
+	ctx := context.Background()
+	repo := srv.URL + "/app"
+
+	refs, err := ListRefs(ctx, http.DefaultClient, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Tags(refs); !reflect.DeepEqual(got, []string{"v1.0.0", "v1.1.0"}) {
+		t.Errorf("Tags() = %q, want v1.0.0 and v1.1.0", got)
+	}
+
+	m := NewMirror(t.TempDir())
+	if err := m.Sync(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := m.Tags(ctx, repo); err != nil || !reflect.DeepEqual(got, []string{"v1.0.0", "v1.1.0"}) {
+		t.Errorf("Mirror.Tags() = %q, %v; want v1.0.0 and v1.1.0", got, err)
+	}
+}
+
+func TestFetchGoMod(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/go.gllm.dev/!my!app/@v/v1.0.0.mod":
+			fmt.Fprint(w, "module go.gllm.dev/MyApp\n")
+		case "/go.gllm.dev/broken/@v/v1.0.0.mod":
+			http.Error(w, "upstream failure", http.StatusBadGateway)
+		default:
+			http.Error(w, "not found", http.StatusGone)
+		}
+	}))
+	defer proxy.Close()
+	ctx := context.Background()
+
+	data, err := FetchGoMod(ctx, http.DefaultClient, proxy.URL+"/", "go.gllm.dev/MyApp", "v1.0.0")
+	if err != nil || string(data) != "module go.gllm.dev/MyApp\n" {
+		t.Errorf("FetchGoMod() = %q, %v", data, err)
+	}
+	if _, err := FetchGoMod(ctx, http.DefaultClient, proxy.URL, "go.gllm.dev/MyApp", "v2.0.0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FetchGoMod() of an unknown version error = %v, want ErrNotFound", err)
+	}
+	if _, err := FetchGoMod(ctx, http.DefaultClient, proxy.URL, "go.gllm.dev/broken", "v1.0.0"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("FetchGoMod() of a failing proxy error = %v, want a failure", err)
+	}
+}
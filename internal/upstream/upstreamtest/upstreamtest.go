@@ -0,0 +1,82 @@
+// Package upstreamtest serves git repositories over smart HTTP for tests, with
+// git http-backend. Tests using it are skipped when git is not installed.
+package upstreamtest
+
+import (
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Server is a git smart HTTP server hosting test repositories.
+type Server struct {
+	*httptest.Server
+	// git is the path of the git command.
+	git string
+	// root is the directory holding the bare repositories.
+	root string
+}
+
+// NewServer returns a server hosting a repository at /<name> for every entry of repos,
+// with a single commit adding the given files, keyed by slash-separated path.
+// A repository without files is empty. The server is closed when the test ends.
+func NewServer(t *testing.T, repos map[string]map[string]string) *Server {
+	t.Helper()
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	for name, files := range repos {
+		dir := filepath.Join(root, name)
+		if len(files) == 0 {
+			run(t, git, "", "init", "--quiet", "--bare", dir)
+			continue
+		}
+		work := t.TempDir()
+		run(t, git, "", "init", "--quiet", work)
+		for file, content := range files {
+			path := filepath.Join(work, filepath.FromSlash(file))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		run(t, git, work, "add", ".")
+		run(t, git, work, "commit", "--quiet", "-m", "initial commit")
+		run(t, git, "", "clone", "--quiet", "--bare", work, dir)
+	}
+
+	srv := httptest.NewServer(&cgi.Handler{
+		Path: git,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	t.Cleanup(srv.Close)
+	return &Server{Server: srv, git: git, root: root}
+}
+
+// Tag adds annotated tags pointing at the commit of the repository name.
+func (s *Server) Tag(t *testing.T, name string, tags ...string) {
+	t.Helper()
+	dir := filepath.Join(s.root, name)
+	for _, tag := range tags {
+		run(t, s.git, "", "--git-dir", dir, "tag", "-a", "-m", tag, tag, "HEAD")
+	}
+}
+
+// run runs git with args in dir, failing the test on error.
+func run(t *testing.T, git, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command(git, append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "init.defaultBranch=main"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}